@@ -30,10 +30,12 @@ func Expr(format string, values ...any) Expression {
 
 // WriteSQL implements the SQLWriter interface.
 func (expr Expression) WriteSQL(ctx context.Context, dialect string, buf *bytes.Buffer, args *[]any, params map[string][]int) error {
+	start := len(*args)
 	err := Writef(ctx, dialect, buf, args, params, expr.format, expr.values)
 	if err != nil {
 		return err
 	}
+	redactExpression(ctx, expr.values, *args, start)
 	return nil
 }
 
@@ -140,6 +142,16 @@ func (b sqlserverQueryBuilder) Queryf(format string, values ...any) CustomQuery
 	return CustomQuery{Dialect: DialectSQLServer, Format: format, Values: values}
 }
 
+// Queryf creates a new DuckDB query using Writef syntax.
+func (b duckdbQueryBuilder) Queryf(format string, values ...any) CustomQuery {
+	return CustomQuery{Dialect: DialectDuckDB, Format: format, Values: values}
+}
+
+// Queryf creates a new Oracle query using Writef syntax.
+func (b oracleQueryBuilder) Queryf(format string, values ...any) CustomQuery {
+	return CustomQuery{Dialect: DialectOracle, Format: format, Values: values}
+}
+
 // Append returns a new CustomQuery with the format string and values slice
 // appended to the current CustomQuery.
 func (q CustomQuery) Append(format string, values ...any) CustomQuery {
@@ -355,7 +367,7 @@ func (a assignment) WriteSQL(ctx context.Context, dialect string, buf *bytes.Buf
 	if isQuery {
 		buf.WriteString("(")
 	}
-	err = WriteValue(ctx, dialect, buf, args, params, a.value)
+	err = WriteValue(ctx, dialect, buf, args, params, redactValue(ctx, a.field, a.value))
 	if err != nil {
 		return err
 	}
@@ -476,6 +488,8 @@ type (
 	postgresQueryBuilder  struct{ ctes []CTE }
 	mysqlQueryBuilder     struct{ ctes []CTE }
 	sqlserverQueryBuilder struct{ ctes []CTE }
+	duckdbQueryBuilder    struct{ ctes []CTE }
+	oracleQueryBuilder    struct{ ctes []CTE }
 )
 
 // Dialect-specific query builder variables.
@@ -484,6 +498,8 @@ var (
 	Postgres  postgresQueryBuilder
 	MySQL     mysqlQueryBuilder
 	SQLServer sqlserverQueryBuilder
+	DuckDB    duckdbQueryBuilder
+	Oracle    oracleQueryBuilder
 )
 
 // With sets the CTEs in the SQLiteQueryBuilder.
@@ -510,6 +526,18 @@ func (b sqlserverQueryBuilder) With(ctes ...CTE) sqlserverQueryBuilder {
 	return b
 }
 
+// With sets the CTEs in the DuckDBQueryBuilder.
+func (b duckdbQueryBuilder) With(ctes ...CTE) duckdbQueryBuilder {
+	b.ctes = ctes
+	return b
+}
+
+// With sets the CTEs in the OracleQueryBuilder.
+func (b oracleQueryBuilder) With(ctes ...CTE) oracleQueryBuilder {
+	b.ctes = ctes
+	return b
+}
+
 // ToSQL converts an SQLWriter into a query string and args slice.
 //
 // The params map is used to hold the mappings between named parameters in the
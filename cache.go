@@ -0,0 +1,445 @@
+package sq
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bokwoon95/sq"
+)
+
+// CacheStore stores the cached results of a CachedDB. Each entry is tagged
+// with the (lowercased) names of the tables its query reads from, so that it
+// can be invalidated when one of those tables is written to.
+//
+// A CacheStore must be safe for concurrent use. Failures should be treated
+// as cache misses.
+type CacheStore interface {
+	// Get returns the value stored under key, if it exists and has not
+	// expired.
+	Get(ctx context.Context, key string) (value any, ok bool)
+
+	// Set stores value under key for the duration of ttl.
+	Set(ctx context.Context, key string, value any, ttl time.Duration, tags []string)
+
+	// Invalidate removes every entry tagged with any of the tags.
+	Invalidate(ctx context.Context, tags ...string)
+
+	// Clear removes every entry.
+	Clear(ctx context.Context)
+}
+
+// MemoryCacheStore is an in-memory CacheStore.
+type MemoryCacheStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryCacheEntry
+	tags    map[string]map[string]struct{} // tag -> keys
+	sets    int
+}
+
+type memoryCacheEntry struct {
+	value     any
+	expiresAt time.Time
+	tags      []string
+}
+
+var _ CacheStore = (*MemoryCacheStore)(nil)
+
+// NewMemoryCacheStore returns a new MemoryCacheStore.
+func NewMemoryCacheStore() *MemoryCacheStore {
+	return &MemoryCacheStore{
+		entries: make(map[string]memoryCacheEntry),
+		tags:    make(map[string]map[string]struct{}),
+	}
+}
+
+// Get implements the CacheStore interface.
+func (s *MemoryCacheStore) Get(ctx context.Context, key string) (value any, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		s.remove(key)
+		return nil, false
+	}
+	return entry.value, true
+}
+
+// Set implements the CacheStore interface.
+func (s *MemoryCacheStore) Set(ctx context.Context, key string, value any, ttl time.Duration, tags []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.remove(key)
+	s.entries[key] = memoryCacheEntry{
+		value:     value,
+		expiresAt: time.Now().Add(ttl),
+		tags:      tags,
+	}
+	for _, tag := range tags {
+		keys := s.tags[tag]
+		if keys == nil {
+			keys = make(map[string]struct{})
+			s.tags[tag] = keys
+		}
+		keys[key] = struct{}{}
+	}
+	// Expired entries are removed lazily by Get, but entries that are never
+	// looked up again would otherwise pile up.
+	s.sets++
+	if s.sets%100 == 0 {
+		now := time.Now()
+		for key, entry := range s.entries {
+			if now.After(entry.expiresAt) {
+				s.remove(key)
+			}
+		}
+	}
+}
+
+// Invalidate implements the CacheStore interface.
+func (s *MemoryCacheStore) Invalidate(ctx context.Context, tags ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, tag := range tags {
+		for key := range s.tags[tag] {
+			s.remove(key)
+		}
+	}
+}
+
+// Clear implements the CacheStore interface.
+func (s *MemoryCacheStore) Clear(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.entries)
+	clear(s.tags)
+}
+
+// Len returns the number of entries in the store, including expired entries
+// that have not been removed yet.
+func (s *MemoryCacheStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+// remove removes the entry for key. The caller must hold s.mu.
+func (s *MemoryCacheStore) remove(key string) {
+	entry, ok := s.entries[key]
+	if !ok {
+		return
+	}
+	delete(s.entries, key)
+	for _, tag := range entry.tags {
+		delete(s.tags[tag], key)
+		if len(s.tags[tag]) == 0 {
+			delete(s.tags, tag)
+		}
+	}
+}
+
+// cacheContextKey is the context key under which Cached stores the
+// *cacheOptions.
+type cacheContextKey struct{}
+
+type cacheOptions struct {
+	key string
+	ttl time.Duration
+}
+
+// Cached returns a context that makes FetchOneContext and FetchAllContext
+// cache their results under key, if they are run on a CachedDB. The results
+// are cached for the duration of ttl, or the TTL of the CachedDB if ttl is
+// zero. A negative ttl or an empty key disables caching for the context.
+//
+// The key stands in for the row mapper, which the cache cannot tell apart
+// from other row mappers returning the same type: the same key must only be
+// used with row mappers that map rows the same way.
+func Cached(ctx context.Context, key string, ttl time.Duration) context.Context {
+	return context.WithValue(ctx, cacheContextKey{}, &cacheOptions{key: key, ttl: ttl})
+}
+
+// CachedDB is a DB that caches the results of the SELECT queries fetched
+// through it with FetchOne and FetchAll (and their Context variants). It is
+// created with NewCachedDB.
+//
+// Results are only cached for SELECT queries run with a context returned by
+// Cached. Each cached result is keyed by the key passed to Cached, the
+// fingerprint of the query, its args and the result type, and is tagged with
+// the tables that the query reads from. Whenever an INSERT, UPDATE or DELETE
+// (or any other query that is not a plain SELECT) is run through the
+// CachedDB, the results tagged with the tables it touches are invalidated.
+// Statements executed with ExecContext whose tables cannot be determined
+// (such as Queryf queries) clear the whole cache. Queries run with
+// QueryContext are assumed to be reads unless they are known to write to
+// known tables, so writes made with raw queries that return rows (such as a
+// Queryf INSERT ... RETURNING) must be followed by a call to Invalidate.
+//
+// Like a DB returned by Chain.Then, a CachedDB wraps the transactions run
+// with RunInTx: their writes invalidate the cache once they are committed,
+// and their reads are not cached. Writes that otherwise bypass the
+// CachedDB, such as those made in a transaction begun with BeginTx, are not
+// seen; call Invalidate after such writes. Tables referenced only inside raw
+// SQL expressions are not tracked either, so queries that rely on them
+// should not be cached.
+//
+// The cached results are shared: FetchAll returns a copy of the cached
+// slice, but the values in it are not deep copied.
+type CachedDB struct {
+	// The chain of the CachedDB wraps its transactions, while the DB of the
+	// chainDB invalidates the cache directly.
+	chainDB
+	store CacheStore
+
+	// TTL is the duration for which results are cached if Cached was given
+	// a ttl of zero.
+	TTL time.Duration
+}
+
+var _ interface {
+	TxDB
+	RunInTxer
+} = (*CachedDB)(nil)
+
+// NewCachedDB wraps a DB with a result cache backed by store. If store is
+// nil, a MemoryCacheStore is used.
+func NewCachedDB(db DB, store CacheStore) *CachedDB {
+	if store == nil {
+		store = NewMemoryCacheStore()
+	}
+	c := &CachedDB{store: store}
+	c.chainDB = chainDB{
+		DB:    cacheInvalidator{DB: db, cache: c},
+		inner: db,
+		chain: NewChain(func(tx sq.DB) sq.DB {
+			return cacheInvalidator{DB: tx, cache: c, pending: &pendingInvalidation{}}
+		}),
+	}
+	return c
+}
+
+// Store returns the CacheStore of the CachedDB.
+func (c *CachedDB) Store() CacheStore { return c.store }
+
+// Invalidate invalidates the cached results of the queries reading from the
+// given tables. If no tables are given, the whole cache is cleared.
+func (c *CachedDB) Invalidate(ctx context.Context, tables ...string) {
+	if len(tables) == 0 {
+		c.store.Clear(ctx)
+		return
+	}
+	tags := make([]string, len(tables))
+	for i, table := range tables {
+		tags[i] = strings.ToLower(table)
+	}
+	c.store.Invalidate(ctx, tags...)
+}
+
+// cacheInvalidator invalidates the cache of a CachedDB when queries write
+// through it. If pending is not nil, DB is a transaction and the
+// invalidations are deferred until it is committed.
+type cacheInvalidator struct {
+	DB
+	cache   *CachedDB
+	pending *pendingInvalidation
+}
+
+// pendingInvalidation collects the invalidations of a transaction.
+type pendingInvalidation struct {
+	mu    sync.Mutex
+	tags  []string
+	clear bool
+}
+
+// Unwrap returns the underlying DB.
+func (db cacheInvalidator) Unwrap() DB { return db.DB }
+
+// QueryContext implements the DB interface. Only queries known to write to
+// known tables (such as an InsertQuery with a RETURNING clause, see
+// QueryFromContext) invalidate the cache: every other call is assumed to be a
+// read.
+func (db cacheInvalidator) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	rows, err := db.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	if q, ok := QueryFromContext(ctx); ok && !isReadOnlyQuery(q) {
+		if tags, ok := QueryTables(q); ok && len(tags) > 0 {
+			db.invalidate(ctx, tags)
+		}
+	}
+	return rows, nil
+}
+
+// ExecContext implements the DB interface. If the statement carries no
+// Query or its tables cannot be determined, the whole cache is cleared.
+func (db cacheInvalidator) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	result, err := db.DB.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	q, ok := QueryFromContext(ctx)
+	if ok && isReadOnlyQuery(q) {
+		return result, nil
+	}
+	var tags []string
+	if ok {
+		tags, ok = QueryTables(q)
+	}
+	if !ok || len(tags) == 0 {
+		tags = nil
+	}
+	db.invalidate(ctx, tags)
+	return result, nil
+}
+
+// invalidate invalidates the results tagged with tags, or the whole cache if
+// tags is nil.
+func (db cacheInvalidator) invalidate(ctx context.Context, tags []string) {
+	if db.pending != nil {
+		db.pending.mu.Lock()
+		defer db.pending.mu.Unlock()
+		db.pending.tags = append(db.pending.tags, tags...)
+		db.pending.clear = db.pending.clear || tags == nil
+		return
+	}
+	if tags == nil {
+		db.cache.store.Clear(ctx)
+		return
+	}
+	db.cache.store.Invalidate(ctx, tags...)
+}
+
+// txCommitted carries out the invalidations deferred by the transaction.
+func (db cacheInvalidator) txCommitted(ctx context.Context) {
+	if db.pending == nil {
+		return
+	}
+	db.pending.mu.Lock()
+	defer db.pending.mu.Unlock()
+	if db.pending.clear {
+		db.cache.store.Clear(ctx)
+	} else if len(db.pending.tags) > 0 {
+		db.cache.store.Invalidate(ctx, db.pending.tags...)
+	}
+	db.pending.tags, db.pending.clear = nil, false
+}
+
+// cacheLookup looks up the results of a fetch in the cache of a CachedDB.
+type cacheLookup struct {
+	cache *CachedDB
+	key   string // the key passed to Cached
+	kind  string // distinguishes between the results of FetchOne and FetchAll
+	tags  []string
+	ttl   time.Duration
+	entry string // the key of the entry in the store, once looked up
+	hit   bool
+}
+
+// newCacheLookup returns the cacheLookup of fetching query on db, or nil if
+// the results are not to be cached.
+func newCacheLookup(ctx context.Context, db DB, query Query, kind string) *cacheLookup {
+	options, ok := ctx.Value(cacheContextKey{}).(*cacheOptions)
+	if !ok || options.key == "" || query == nil {
+		return nil
+	}
+	lookup := &cacheLookup{key: options.key, kind: kind}
+	for db != nil {
+		if cache, ok := db.(*CachedDB); ok {
+			lookup.cache = cache
+			break
+		}
+		unwrapper, ok := db.(interface{ Unwrap() DB })
+		if !ok {
+			return nil
+		}
+		db = unwrapper.Unwrap()
+	}
+	if lookup.cache == nil {
+		return nil
+	}
+	lookup.ttl = options.ttl
+	if lookup.ttl == 0 {
+		lookup.ttl = lookup.cache.TTL
+	}
+	if lookup.ttl <= 0 || !isReadOnlyQuery(query) {
+		return nil
+	}
+	lookup.tags, ok = QueryTables(query)
+	if !ok || len(lookup.tags) == 0 {
+		return nil
+	}
+	return lookup
+}
+
+// cachedResults returns the cached results of the query, rendered as query
+// and args in dialect.
+func cachedResults[T any](ctx context.Context, lookup *cacheLookup, dialect string, query string, args []any) ([]T, bool) {
+	// The entry is keyed by the caller's key and the fingerprint of the
+	// query, which groups the entries of a query in the store, followed by a
+	// hash of the args. The fingerprint normalizes away literals written
+	// into the query itself, so the hash covers the query string as well.
+	_, fingerprint := Fingerprint(dialect, query)
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\x00%s\x00%T\x00%s\x00", lookup.kind, dialect, *new(T), query)
+	for _, arg := range args {
+		fmt.Fprintf(hash, "%T:%#v\x00", arg, arg)
+	}
+	lookup.entry = lookup.key + ":" + fingerprint + ":" + hex.EncodeToString(hash.Sum(nil))
+	value, ok := lookup.cache.store.Get(ctx, lookup.entry)
+	if !ok {
+		return nil, false
+	}
+	results, ok := value.([]T)
+	lookup.hit = ok
+	return results, ok
+}
+
+// set caches results if the lookup was a miss.
+func (lookup *cacheLookup) set(ctx context.Context, results any) {
+	if lookup == nil || lookup.entry == "" || lookup.hit {
+		return
+	}
+	lookup.cache.store.Set(ctx, lookup.entry, results, lookup.ttl, lookup.tags)
+}
+
+// QueryTables returns the sorted, lowercased names of the tables referenced
+// in query, including those in its subqueries and CTEs. It reports false if
+// query references a table that cannot be named, such as a raw SQL
+// expression.
+func QueryTables(query Query) (tables []string, ok bool) {
+	ok = true
+	seen := make(map[string]bool)
+	v := &validator{dialect: query.GetDialect()}
+	v.visitTable = func(table Table) {
+		switch table := table.(type) {
+		case Query, CTE, SelectValues, TableValues:
+			// Subqueries are walked by the validator, CTEs are walked where
+			// they are defined and VALUES lists reference no tables.
+		case interface{ GetName() string }:
+			name := strings.ToLower(table.GetName())
+			if name == "" {
+				ok = false
+				return
+			}
+			if !seen[name] {
+				seen[name] = true
+				tables = append(tables, name)
+			}
+		default:
+			ok = false
+		}
+	}
+	v.query("", query)
+	sort.Strings(tables)
+	return tables, ok
+}
@@ -0,0 +1,313 @@
+package sq
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/blink-io/sq/internal/testutil"
+	"github.com/bokwoon95/sq"
+)
+
+func TestCachedDB(t *testing.T) {
+	FILM := New[struct {
+		TableStruct `sq:"film"`
+		FILM_ID     NumberField
+		TITLE       StringField
+	}]("")
+	newCachedDB := func(t *testing.T) (*CachedDB, *int) {
+		sqlDB := newActorDB(t)
+		_, err := sqlDB.Exec(`CREATE TABLE film (film_id INTEGER PRIMARY KEY, title TEXT);
+INSERT INTO film (film_id, title) VALUES (1, 'ACADEMY DINOSAUR');`)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		// Count the queries that actually reach the database.
+		var queries int
+		db := NewCachedDB(countingDB{DB: sqlDB, count: &queries}, nil)
+		return db, &queries
+	}
+	firstNames := func(t *testing.T, ctx context.Context, db DB) []string {
+		t.Helper()
+		names, err := FetchAllContext(ctx, db, SQLite.From(ACTOR).OrderBy(ACTOR.ACTOR_ID), func(ctx context.Context, row *Row) string {
+			return row.StringField(ACTOR.FIRST_NAME)
+		})
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		return names
+	}
+	ctx := context.Background()
+
+	t.Run("opt-in", func(t *testing.T) {
+		db, queries := newCachedDB(t)
+		db.TTL = time.Hour
+		firstNames(t, ctx, db)
+		firstNames(t, ctx, db)
+		if *queries != 2 {
+			t.Errorf(testutil.Callers()+" expected 2 queries, got %d", *queries)
+		}
+		// An empty key or a negative TTL disables caching.
+		firstNames(t, Cached(ctx, "", 0), db)
+		firstNames(t, Cached(ctx, "actors", -1), db)
+		if *queries != 4 {
+			t.Errorf(testutil.Callers()+" expected 4 queries, got %d", *queries)
+		}
+		// A zero TTL falls back to the TTL of the CachedDB.
+		firstNames(t, Cached(ctx, "actors", 0), db)
+		firstNames(t, Cached(ctx, "actors", 0), db)
+		if *queries != 5 {
+			t.Errorf(testutil.Callers()+" expected 5 queries, got %d", *queries)
+		}
+	})
+
+	t.Run("keys", func(t *testing.T) {
+		db, queries := newCachedDB(t)
+		// The row mappers share the same code but map rows differently.
+		names := func(ctx context.Context, field string) []string {
+			names, err := FetchAllContext(ctx, db, SQLite.From(ACTOR).OrderBy(ACTOR.ACTOR_ID), func(ctx context.Context, row *Row) string {
+				return row.String(field)
+			})
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			return names
+		}
+		if diff := testutil.Diff(names(Cached(ctx, "first", time.Hour), "first_name"), []string{"PENELOPE", "NICK", "ED"}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		if diff := testutil.Diff(names(Cached(ctx, "last", time.Hour), "last_name"), []string{"GUINESS", "WAHLBERG", "CHASE"}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		if diff := testutil.Diff(names(Cached(ctx, "first", time.Hour), "first_name"), []string{"PENELOPE", "NICK", "ED"}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		if *queries != 2 {
+			t.Errorf(testutil.Callers()+" expected 2 queries, got %d", *queries)
+		}
+	})
+
+	t.Run("reads", func(t *testing.T) {
+		db, queries := newCachedDB(t)
+		cachedCtx := Cached(ctx, "actors", time.Hour)
+		firstNames(t, cachedCtx, db)
+
+		// Reads that cannot be cached leave the cache alone, whether or not
+		// they carry a Query.
+		rows, err := db.QueryContext(ctx, "SELECT first_name FROM actor")
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		rows.Close()
+		_, err = FetchAll(db, SQLite.Queryf("SELECT {*} FROM actor"), func(ctx context.Context, row *Row) string {
+			return row.String("first_name")
+		})
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		compiled, err := CompileFetch(SQLite.From(ACTOR), func(ctx context.Context, row *Row) string {
+			return row.StringField(ACTOR.FIRST_NAME)
+		})
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if _, err = compiled.FetchAll(db, nil); err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		firstNames(t, cachedCtx, db)
+		if *queries != 4 {
+			t.Errorf(testutil.Callers()+" expected 4 queries, got %d", *queries)
+		}
+	})
+
+	t.Run("invalidation", func(t *testing.T) {
+		db, queries := newCachedDB(t)
+		cachedCtx := Cached(ctx, "actors", time.Hour)
+		titleCount := func() int {
+			count, err := FetchOneContext(cachedCtx, db, SQLite.From(FILM), func(ctx context.Context, row *Row) int {
+				return row.Int("COUNT(*)")
+			})
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			return count
+		}
+		if diff := testutil.Diff(firstNames(t, cachedCtx, db), []string{"PENELOPE", "NICK", "ED"}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		if count := titleCount(); count != 1 {
+			t.Errorf(testutil.Callers()+" expected 1, got %d", count)
+		}
+		firstNames(t, cachedCtx, db)
+		titleCount()
+		if *queries != 2 {
+			t.Errorf(testutil.Callers()+" expected 2 queries, got %d", *queries)
+		}
+
+		// Writing to film leaves the cached actors alone.
+		_, err := Exec(db, SQLite.InsertInto(FILM).Columns(FILM.FILM_ID, FILM.TITLE).Values(2, "ACE GOLDFINGER"))
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if count := titleCount(); count != 2 {
+			t.Errorf(testutil.Callers()+" expected 2, got %d", count)
+		}
+		firstNames(t, cachedCtx, db)
+		if *queries != 3 {
+			t.Errorf(testutil.Callers()+" expected 3 queries, got %d", *queries)
+		}
+
+		_, err = Exec(db, SQLite.Update(ACTOR).Set(ACTOR.FIRST_NAME.SetString("JOHNNY")).Where(ACTOR.ACTOR_ID.EqInt(2)))
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if diff := testutil.Diff(firstNames(t, cachedCtx, db), []string{"PENELOPE", "JOHNNY", "ED"}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		_, err = Exec(db, SQLite.DeleteFrom(ACTOR).Where(ACTOR.ACTOR_ID.EqInt(1)))
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if diff := testutil.Diff(firstNames(t, cachedCtx, db), []string{"JOHNNY", "ED"}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+
+		// Raw queries clear the whole cache.
+		_, err = Exec(db, SQLite.Queryf("INSERT INTO actor (actor_id, first_name, last_name) VALUES (4, 'JENNIFER', 'DAVIS')"))
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if diff := testutil.Diff(firstNames(t, cachedCtx, db), []string{"JOHNNY", "ED", "JENNIFER"}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		if db.Store().(*MemoryCacheStore).Len() != 1 {
+			t.Errorf(testutil.Callers()+" expected 1 cache entry, got %d", db.Store().(*MemoryCacheStore).Len())
+		}
+	})
+
+	t.Run("transactions", func(t *testing.T) {
+		db := NewCachedDB(newActorDB(t), nil)
+		store := db.Store().(*MemoryCacheStore)
+		cachedCtx := Cached(ctx, "actors", time.Hour)
+		updateActor := func(firstName string, err error) func(context.Context, sq.DB) error {
+			return func(ctx context.Context, tx sq.DB) error {
+				_, execErr := Exec(tx, SQLite.Update(ACTOR).Set(ACTOR.FIRST_NAME.SetString(firstName)).Where(ACTOR.ACTOR_ID.EqInt(2)))
+				if execErr != nil {
+					return execErr
+				}
+				// Reads inside the transaction are not cached, and its
+				// writes are not seen before it is committed.
+				if diff := testutil.Diff(firstNames(t, Cached(ctx, "tx", time.Hour), tx), []string{"PENELOPE", firstName, "ED"}); diff != "" {
+					t.Error(testutil.Callers(), diff)
+				}
+				if store.Len() != 1 {
+					t.Errorf(testutil.Callers()+" expected 1 cache entry, got %d", store.Len())
+				}
+				return err
+			}
+		}
+		firstNames(t, cachedCtx, db)
+
+		// A rolled back transaction leaves the cache alone.
+		errRollback := errors.New("rollback")
+		if err := RunInTx(ctx, db, nil, updateActor("JOHNNY", errRollback)); !errors.Is(err, errRollback) {
+			t.Fatalf(testutil.Callers()+" expected errRollback, got %v", err)
+		}
+		if store.Len() != 1 {
+			t.Errorf(testutil.Callers()+" expected 1 cache entry, got %d", store.Len())
+		}
+
+		// A committed transaction invalidates the tables it wrote to.
+		if err := db.RunInTx(ctx, nil, updateActor("JOHNNY", nil)); err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if store.Len() != 0 {
+			t.Errorf(testutil.Callers()+" expected no cache entries, got %d", store.Len())
+		}
+		if diff := testutil.Diff(firstNames(t, cachedCtx, db), []string{"PENELOPE", "JOHNNY", "ED"}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("expiry", func(t *testing.T) {
+		db, queries := newCachedDB(t)
+		cachedCtx := Cached(ctx, "actors", time.Millisecond)
+		firstNames(t, cachedCtx, db)
+		time.Sleep(5 * time.Millisecond)
+		firstNames(t, cachedCtx, db)
+		if *queries != 2 {
+			t.Errorf(testutil.Callers()+" expected 2 queries, got %d", *queries)
+		}
+	})
+
+	t.Run("logged", func(t *testing.T) {
+		db, queries := newCachedDB(t)
+		var logged int
+		loggedDB := NewCachedDB(struct {
+			DB
+			Logger
+		}{DB: db.Unwrap(), Logger: logQueryFunc(func(context.Context, QueryStats) { logged++ })}, nil)
+		cachedCtx := Cached(ctx, "actors", time.Hour)
+		firstNames(t, cachedCtx, loggedDB)
+		firstNames(t, cachedCtx, loggedDB)
+		if *queries != 1 || logged != 1 {
+			t.Errorf(testutil.Callers()+" expected 1 query and 1 log, got %d and %d", *queries, logged)
+		}
+	})
+}
+
+func TestQueryTables(t *testing.T) {
+	type TT struct {
+		description string
+		query       Query
+		wantTables  []string
+		wantOK      bool
+	}
+	FILM_ACTOR := New[struct {
+		TableStruct `sq:"film_actor"`
+		ACTOR_ID    NumberField
+	}]("")
+	tests := []TT{
+		{"select", SQLite.From(ACTOR), []string{"actor"}, true},
+		{"join and subquery", SQLite.
+			From(ACTOR).
+			Join(FILM_ACTOR, FILM_ACTOR.ACTOR_ID.Eq(ACTOR.ACTOR_ID)).
+			Where(ACTOR.ACTOR_ID.In(SQLite.Select(FILM_ACTOR.ACTOR_ID).From(FILM_ACTOR))),
+			[]string{"actor", "film_actor"}, true},
+		{"cte", SQLite.
+			With(NewCTE("ids", nil, SQLite.Select(FILM_ACTOR.ACTOR_ID).From(FILM_ACTOR))).
+			From(ACTOR),
+			[]string{"actor", "film_actor"}, true},
+		{"insert", SQLite.InsertInto(ACTOR).Columns(ACTOR.FIRST_NAME).Values("ED"), []string{"actor"}, true},
+		{"update", SQLite.Update(ACTOR).Set(ACTOR.FIRST_NAME.SetString("ED")), []string{"actor"}, true},
+		{"delete", SQLite.DeleteFrom(FILM_ACTOR), []string{"film_actor"}, true},
+		{"raw table", SQLite.From(Expr("actor")), nil, false},
+	}
+	for _, tt := range tests {
+		gotTables, gotOK := QueryTables(tt.query)
+		if gotOK != tt.wantOK {
+			t.Errorf(testutil.Callers()+" %s: expected ok=%v, got %v", tt.description, tt.wantOK, gotOK)
+		}
+		if diff := testutil.Diff(gotTables, tt.wantTables); diff != "" {
+			t.Error(testutil.Callers(), tt.description, diff)
+		}
+	}
+}
+
+type countingDB struct {
+	DB
+	count *int
+}
+
+func (db countingDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	*db.count++
+	return db.DB.QueryContext(ctx, query, args...)
+}
+
+type logQueryFunc func(context.Context, QueryStats)
+
+func (f logQueryFunc) LogSettings(context.Context, *LogSettings) {}
+
+func (f logQueryFunc) LogQuery(ctx context.Context, queryStats QueryStats) { f(ctx, queryStats) }
@@ -1,6 +1,10 @@
 package sq
 
 import (
+	"context"
+	"database/sql"
+	"fmt"
+
 	"github.com/bokwoon95/sq"
 )
 
@@ -44,12 +48,34 @@ func NewChain(constructors ...Constructor) Chain {
 // and thus several instances of the same middleware will be created
 // when a chain is reused in this way.
 // For proper middleware, this should cause no problems.
+//
+// The returned DB keeps the optional interfaces of db that the middleware
+// would otherwise hide: it implements Txer and RunInTxer, Close and Conn,
+// and is a Logger if db or any middleware is one. Transactions run with
+// RunInTx on the returned DB are passed to fn wrapped in the same chain, so
+// the middleware sees the queries made inside them too. Transactions begun
+// with BeginTx are not wrapped; use Then on them if needed.
 func (c Chain) Then(db DB) DB {
 	if db != nil {
+		if len(c.constructors) == 0 {
+			return db
+		}
+		wrapped := db
 		for i := range c.constructors {
-			db = c.constructors[len(c.constructors)-1-i](db)
+			wrapped = c.constructors[len(c.constructors)-1-i](wrapped)
 		}
-		return db
+		cdb := chainDB{DB: wrapped, inner: db, chain: c}
+		logger, ok := dbLogger(wrapped)
+		if !ok {
+			logger, ok = dbLogger(db)
+		}
+		if ok {
+			return struct {
+				chainDB
+				Logger
+			}{cdb, logger}
+		}
+		return cdb
 	}
 	return nil
 }
@@ -104,3 +130,97 @@ func ChainFunc(db sq.DB, constructors ...Constructor) sq.DB {
 	chain := NewChain(constructors...)
 	return chain.Then(db)
 }
+
+// chainDB is the DB returned by Chain.Then. It runs queries through the
+// middleware and forwards everything else to the DB passed to Then.
+type chainDB struct {
+	DB    // the DB wrapped by the middleware
+	inner DB
+	chain Chain
+}
+
+var _ interface {
+	TxDB
+	RunInTxer
+} = chainDB{}
+
+// Unwrap returns the DB wrapped by the middleware.
+func (db chainDB) Unwrap() DB { return db.DB }
+
+// GetDialect returns the dialect of the DB.
+func (db chainDB) GetDialect() string {
+	if dialect := dbDialect(db.DB); dialect != "" {
+		return dialect
+	}
+	return dbDialect(db.inner)
+}
+
+// Begin implements the Txer interface.
+func (db chainDB) Begin() (*sql.Tx, error) {
+	return db.BeginTx(context.Background(), nil)
+}
+
+// BeginTx implements the Txer interface. The transaction is begun through
+// the middleware if it supports transactions, and on the inner DB
+// otherwise.
+func (db chainDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	if txer, ok := db.DB.(Txer); ok {
+		return txer.BeginTx(ctx, opts)
+	}
+	if txer, ok := db.inner.(Txer); ok {
+		return txer.BeginTx(ctx, opts)
+	}
+	return nil, fmt.Errorf("sq: %T cannot begin transactions", db.inner)
+}
+
+// RunInTx implements the RunInTxer interface.
+func (db chainDB) RunInTx(ctx context.Context, opts *sql.TxOptions, fn func(context.Context, sq.DB) error) error {
+	return RunInTx(ctx, db, opts, fn)
+}
+
+// Close closes the inner DB, if it can be closed.
+func (db chainDB) Close() error {
+	if closer, ok := db.inner.(interface{ Close() error }); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// Conn returns a single connection of the inner DB. Queries run on the
+// connection bypass the middleware.
+func (db chainDB) Conn(ctx context.Context) (*sql.Conn, error) {
+	if conner, ok := db.inner.(interface {
+		Conn(context.Context) (*sql.Conn, error)
+	}); ok {
+		return conner.Conn(ctx)
+	}
+	return nil, fmt.Errorf("sq: %T does not support Conn", db.inner)
+}
+
+// chainTx wraps tx, which was begun on db, in the middleware chains that db
+// was wrapped in.
+func chainTx(db DB, tx *sql.Tx, dialect string) DB {
+	var chains []Chain
+	for db != nil {
+		if cdb, ok := db.(interface{ chainOf() Chain }); ok {
+			chains = append(chains, cdb.chainOf())
+		}
+		unwrapper, ok := db.(interface{ Unwrap() DB })
+		if !ok {
+			break
+		}
+		db = unwrapper.Unwrap()
+	}
+	var txdb DB = tx
+	if dialect != "" {
+		txdb = dialectDB{DB: tx, dialect: dialect}
+	}
+	for i := len(chains) - 1; i >= 0; i-- {
+		txdb = chains[i].Then(txdb)
+	}
+	return txdb
+}
+
+func (db chainDB) chainOf() Chain { return db.chain }
+
+func (db chainDB) chainInner() DB { return db.inner }
@@ -1 +1,118 @@
 package sq
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"testing"
+
+	"github.com/blink-io/sq/internal/testutil"
+	"github.com/bokwoon95/sq"
+)
+
+func TestChain(t *testing.T) {
+	// counting is a middleware that only implements the DB interface.
+	counting := func(count *int) Constructor {
+		return func(db sq.DB) sq.DB {
+			return countingDB{DB: db, count: count}
+		}
+	}
+	selectActors := SQLite.From(ACTOR)
+	ctx := context.Background()
+
+	t.Run("optional interfaces", func(t *testing.T) {
+		sqlDB := newActorDB(t)
+		var count int
+		db := NewChain(counting(&count)).Then(sqlDB)
+		if _, ok := db.(TxDB); !ok {
+			t.Error(testutil.Callers(), "expected a TxDB")
+		}
+		if _, ok := db.(RunInTxer); !ok {
+			t.Error(testutil.Callers(), "expected a RunInTxer")
+		}
+		if _, ok := db.(Logger); ok {
+			t.Error(testutil.Callers(), "expected no Logger")
+		}
+		if dialect := dbDialect(db); dialect != DialectSQLite {
+			t.Errorf(testutil.Callers()+" expected %q, got %q", DialectSQLite, dialect)
+		}
+		conn, err := db.(interface {
+			Conn(context.Context) (*sql.Conn, error)
+		}).Conn(ctx)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		conn.Close()
+		_, err = FetchAll(db, selectActors, actorRowMapper)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if count != 1 {
+			t.Errorf(testutil.Callers()+" expected 1 query, got %d", count)
+		}
+		if err := db.(io.Closer).Close(); err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if err := sqlDB.Ping(); err == nil {
+			t.Error(testutil.Callers(), "expected the inner DB to be closed")
+		}
+	})
+
+	t.Run("logger", func(t *testing.T) {
+		var count int
+		db := NewChain(counting(&count)).Then(Log(newActorDB(t)))
+		if _, ok := db.(Logger); !ok {
+			t.Error(testutil.Callers(), "expected a Logger")
+		}
+		if _, ok := db.(Txer); !ok {
+			t.Error(testutil.Callers(), "expected a Txer")
+		}
+	})
+
+	t.Run("transactions", func(t *testing.T) {
+		var outer, inner int
+		db := NewChain(counting(&outer), counting(&inner)).Then(newActorDB(t))
+		err := RunInTx(ctx, db, nil, func(ctx context.Context, tx sq.DB) error {
+			if dialect := dbDialect(tx); dialect != DialectSQLite {
+				t.Errorf(testutil.Callers()+" expected %q, got %q", DialectSQLite, dialect)
+			}
+			_, err := FetchAll(tx, selectActors, actorRowMapper)
+			if err != nil {
+				return err
+			}
+			// Nested transactions reuse the wrapped transaction.
+			return db.(RunInTxer).RunInTx(ctx, nil, func(ctx context.Context, tx sq.DB) error {
+				_, err := FetchAll(tx, selectActors, actorRowMapper)
+				return err
+			})
+		})
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if outer != 2 || inner != 2 {
+			t.Errorf(testutil.Callers()+" expected 2 queries in each middleware, got %d and %d", outer, inner)
+		}
+	})
+
+	t.Run("hooks", func(t *testing.T) {
+		hook := &recordingHook{}
+		db := Hooks(newActorDB(t), hook)
+		if _, ok := db.(io.Closer); !ok {
+			t.Error(testutil.Callers(), "expected an io.Closer")
+		}
+		err := InTx(db.(TxDB)).RunInTx(ctx, nil, func(ctx context.Context, tx sq.DB) error {
+			_, err := FetchAll(tx, selectActors, actorRowMapper)
+			return err
+		})
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if diff := testutil.Diff(hook.events, []string{
+			"before begin", "after begin",
+			"before query", "after query", "after rows_end (3 rows)",
+			"before commit", "after commit",
+		}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+}
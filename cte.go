@@ -76,7 +76,7 @@ func writeCTEs(ctx context.Context, dialect string, buf *bytes.Buffer, args *[]a
 			break
 		}
 	}
-	if hasRecursiveCTE {
+	if hasRecursiveCTE && dialect != DialectOracle {
 		buf.WriteString("WITH RECURSIVE ")
 	} else {
 		buf.WriteString("WITH ")
@@ -100,7 +100,7 @@ func writeCTEs(ctx context.Context, dialect string, buf *bytes.Buffer, args *[]a
 			buf.WriteString(")")
 		}
 		buf.WriteString(" AS ")
-		if dialect == DialectPostgres && cte.materialized.Valid {
+		if cte.materialized.Valid && Supports(dialect, CapabilityMaterializedCTE) {
 			if cte.materialized.Bool {
 				buf.WriteString("MATERIALIZED ")
 			} else {
@@ -213,9 +213,13 @@ func (q VariadicQuery) WriteSQL(ctx context.Context, dialect string, buf *bytes.
 	if !q.Toplevel {
 		buf.WriteString("(")
 	}
+	operator := q.Operator
+	if dialect == DialectOracle && operator == QueryExcept {
+		operator = "MINUS"
+	}
 	for i, query := range q.Queries {
 		if i > 0 {
-			buf.WriteString(" " + string(q.Operator) + " ")
+			buf.WriteString(" " + string(operator) + " ")
 		}
 		if query == nil {
 			return fmt.Errorf("query #%d is nil", i+1)
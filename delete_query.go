@@ -27,6 +27,10 @@ type DeleteQuery struct {
 	OffsetRows any
 	// RETURNING
 	ReturningFields []Field
+	ReturningInto   []any
+	// FullTable marks the query as meant to delete every row of the table,
+	// see Guard.
+	FullTable bool
 }
 
 var _ Query = (*DeleteQuery)(nil)
@@ -93,12 +97,12 @@ func (q DeleteQuery) WriteSQL(ctx context.Context, dialect string, buf *bytes.Bu
 		}
 		if dialect != DialectSQLServer {
 			if alias := getAlias(q.DeleteTable); alias != "" {
-				buf.WriteString(" AS " + QuoteIdentifier(dialect, alias))
+				buf.WriteString(tableAliasAS(dialect) + QuoteIdentifier(dialect, alias))
 			}
 		}
 	}
 	if q.UsingTable != nil || len(q.JoinTables) > 0 {
-		if dialect != DialectPostgres && dialect != DialectMySQL && dialect != DialectSQLServer {
+		if !Supports(dialect, CapabilityDeleteJoin) {
 			return fmt.Errorf("%s DELETE does not support JOIN", dialect)
 		}
 	}
@@ -113,21 +117,21 @@ func (q DeleteQuery) WriteSQL(ctx context.Context, dialect string, buf *bytes.Bu
 	// USING/FROM
 	if q.UsingTable != nil {
 		switch dialect {
-		case DialectPostgres:
-			buf.WriteString(" USING ")
-			err = q.UsingTable.WriteSQL(ctx, dialect, buf, args, params)
-			if err != nil {
-				return fmt.Errorf("USING: %w", err)
-			}
 		case DialectMySQL, DialectSQLServer:
 			buf.WriteString(" FROM ")
 			err = q.UsingTable.WriteSQL(ctx, dialect, buf, args, params)
 			if err != nil {
 				return fmt.Errorf("FROM: %w", err)
 			}
+		default:
+			buf.WriteString(" USING ")
+			err = q.UsingTable.WriteSQL(ctx, dialect, buf, args, params)
+			if err != nil {
+				return fmt.Errorf("USING: %w", err)
+			}
 		}
 		if alias := getAlias(q.UsingTable); alias != "" {
-			buf.WriteString(" AS " + QuoteIdentifier(dialect, alias))
+			buf.WriteString(tableAliasAS(dialect) + QuoteIdentifier(dialect, alias))
 		}
 	}
 	// JOIN
@@ -182,11 +186,17 @@ func (q DeleteQuery) WriteSQL(ctx context.Context, dialect string, buf *bytes.Bu
 	}
 	// RETURNING
 	if len(q.ReturningFields) > 0 && dialect != DialectSQLServer {
-		if dialect != DialectPostgres && dialect != DialectSQLite && dialect != DialectMySQL {
+		// MariaDB supports RETURNING even though MySQL does not, so it is
+		// still rendered for MySQL. Validate reports it.
+		if dialect != DialectMySQL && !Supports(dialect, CapabilityDeleteReturning) {
 			return fmt.Errorf("%s UPDATE does not support RETURNING", dialect)
 		}
 		buf.WriteString(" RETURNING ")
-		err = writeFields(ctx, dialect, buf, args, params, q.ReturningFields, true)
+		if dialect == DialectOracle {
+			err = writeReturningInto(ctx, dialect, buf, args, params, q.ReturningFields, q.ReturningInto)
+		} else {
+			err = writeFields(ctx, dialect, buf, args, params, q.ReturningFields, true)
+		}
 		if err != nil {
 			return fmt.Errorf("RETURNING: %w", err)
 		}
@@ -205,28 +215,31 @@ func (q DeleteQuery) Where(predicates ...Predicate) DeleteQuery {
 	return q
 }
 
+// AllowFullTable marks the DeleteQuery as meant to delete every row of the
+// table, so that it is not rejected by a Guard for having no WHERE clause.
+func (q DeleteQuery) AllowFullTable() DeleteQuery {
+	q.FullTable = true
+	return q
+}
+
 // SetFetchableFields implements the Query interface.
 func (q DeleteQuery) SetFetchableFields(fields []Field) (query Query, ok bool) {
-	switch q.Dialect {
-	case DialectPostgres, DialectSQLite:
-		if len(q.ReturningFields) == 0 {
-			q.ReturningFields = fields
-			return q, true
-		}
-		return q, false
-	default:
+	if !supportsFetchableReturning(q.Dialect, CapabilityDeleteReturning) {
 		return q, false
 	}
+	if len(q.ReturningFields) == 0 {
+		q.ReturningFields = fields
+		return q, true
+	}
+	return q, false
 }
 
 // GetFetchableFields returns the fetchable fields of the query.
 func (q DeleteQuery) GetFetchableFields() []Field {
-	switch q.Dialect {
-	case DialectPostgres, DialectSQLite:
-		return q.ReturningFields
-	default:
+	if !supportsFetchableReturning(q.Dialect, CapabilityDeleteReturning) {
 		return nil
 	}
+	return q.ReturningFields
 }
 
 // GetDialect implements the Query interface.
@@ -263,6 +276,13 @@ func (q SQLiteDeleteQuery) Where(predicates ...Predicate) SQLiteDeleteQuery {
 	return q
 }
 
+// AllowFullTable marks the SQLiteDeleteQuery as meant to delete every row of the
+// table, so that it is not rejected by a Guard for having no WHERE clause.
+func (q SQLiteDeleteQuery) AllowFullTable() SQLiteDeleteQuery {
+	q.FullTable = true
+	return q
+}
+
 // Returning appends fields to the RETURNING clause of the SQLiteDeleteQuery.
 func (q SQLiteDeleteQuery) Returning(fields ...Field) SQLiteDeleteQuery {
 	q.ReturningFields = append(q.ReturningFields, fields...)
@@ -356,6 +376,13 @@ func (q PostgresDeleteQuery) Where(predicates ...Predicate) PostgresDeleteQuery
 	return q
 }
 
+// AllowFullTable marks the PostgresDeleteQuery as meant to delete every row of the
+// table, so that it is not rejected by a Guard for having no WHERE clause.
+func (q PostgresDeleteQuery) AllowFullTable() PostgresDeleteQuery {
+	q.FullTable = true
+	return q
+}
+
 // Returning appends fields to the RETURNING clause of the PostgresDeleteQuery.
 func (q PostgresDeleteQuery) Returning(fields ...Field) PostgresDeleteQuery {
 	q.ReturningFields = append(q.ReturningFields, fields...)
@@ -458,6 +485,13 @@ func (q MySQLDeleteQuery) Where(predicates ...Predicate) MySQLDeleteQuery {
 	return q
 }
 
+// AllowFullTable marks the MySQLDeleteQuery as meant to delete every row of the
+// table, so that it is not rejected by a Guard for having no WHERE clause.
+func (q MySQLDeleteQuery) AllowFullTable() MySQLDeleteQuery {
+	q.FullTable = true
+	return q
+}
+
 // OrderBy sets the OrderByFields field of the MySQLDeleteQuery.
 func (q MySQLDeleteQuery) OrderBy(fields ...Field) MySQLDeleteQuery {
 	q.OrderByFields = append(q.OrderByFields, fields...)
@@ -566,6 +600,13 @@ func (q SQLServerDeleteQuery) Where(predicates ...Predicate) SQLServerDeleteQuer
 	return q
 }
 
+// AllowFullTable marks the SQLServerDeleteQuery as meant to delete every row of the
+// table, so that it is not rejected by a Guard for having no WHERE clause.
+func (q SQLServerDeleteQuery) AllowFullTable() SQLServerDeleteQuery {
+	q.FullTable = true
+	return q
+}
+
 // SetFetchableFields implements the Query interface.
 func (q SQLServerDeleteQuery) SetFetchableFields(fields []Field) (query Query, ok bool) {
 	return DeleteQuery(q).SetFetchableFields(fields)
@@ -584,3 +625,167 @@ func (q SQLServerDeleteQuery) SetDialect(dialect string) SQLServerDeleteQuery {
 	q.Dialect = dialect
 	return q
 }
+
+// DuckDBDeleteQuery represents a DuckDB DELETE query.
+type DuckDBDeleteQuery DeleteQuery
+
+var _ Query = (*DuckDBDeleteQuery)(nil)
+
+// WriteSQL implements the SQLWriter interface.
+func (q DuckDBDeleteQuery) WriteSQL(ctx context.Context, dialect string, buf *bytes.Buffer, args *[]any, params map[string][]int) error {
+	return DeleteQuery(q).WriteSQL(ctx, dialect, buf, args, params)
+}
+
+// DeleteFrom returns a new DuckDBDeleteQuery.
+func (b duckdbQueryBuilder) DeleteFrom(table Table) DuckDBDeleteQuery {
+	return DuckDBDeleteQuery{
+		Dialect:     DialectDuckDB,
+		CTEs:        b.ctes,
+		DeleteTable: table,
+	}
+}
+
+// Using sets the UsingTable field of the DuckDBDeleteQuery.
+func (q DuckDBDeleteQuery) Using(table Table) DuckDBDeleteQuery {
+	q.UsingTable = table
+	return q
+}
+
+// Join joins a new Table to the DuckDBDeleteQuery.
+func (q DuckDBDeleteQuery) Join(table Table, predicates ...Predicate) DuckDBDeleteQuery {
+	q.JoinTables = append(q.JoinTables, Join(table, predicates...))
+	return q
+}
+
+// LeftJoin left joins a new Table to the DuckDBDeleteQuery.
+func (q DuckDBDeleteQuery) LeftJoin(table Table, predicates ...Predicate) DuckDBDeleteQuery {
+	q.JoinTables = append(q.JoinTables, LeftJoin(table, predicates...))
+	return q
+}
+
+// FullJoin full joins a new Table to the DuckDBDeleteQuery.
+func (q DuckDBDeleteQuery) FullJoin(table Table, predicates ...Predicate) DuckDBDeleteQuery {
+	q.JoinTables = append(q.JoinTables, FullJoin(table, predicates...))
+	return q
+}
+
+// CrossJoin cross joins a new Table to the DuckDBDeleteQuery.
+func (q DuckDBDeleteQuery) CrossJoin(table Table) DuckDBDeleteQuery {
+	q.JoinTables = append(q.JoinTables, CrossJoin(table))
+	return q
+}
+
+// CustomJoin joins a new Table to the DuckDBDeleteQuery with a custom join
+// operator.
+func (q DuckDBDeleteQuery) CustomJoin(joinOperator string, table Table, predicates ...Predicate) DuckDBDeleteQuery {
+	q.JoinTables = append(q.JoinTables, CustomJoin(joinOperator, table, predicates...))
+	return q
+}
+
+// JoinUsing joins a new Table to the DuckDBDeleteQuery with the USING operator.
+func (q DuckDBDeleteQuery) JoinUsing(table Table, fields ...Field) DuckDBDeleteQuery {
+	q.JoinTables = append(q.JoinTables, JoinUsing(table, fields...))
+	return q
+}
+
+// Where appends to the WherePredicate field of the DuckDBDeleteQuery.
+func (q DuckDBDeleteQuery) Where(predicates ...Predicate) DuckDBDeleteQuery {
+	q.WherePredicate = appendPredicates(q.WherePredicate, predicates)
+	return q
+}
+
+// AllowFullTable marks the DuckDBDeleteQuery as meant to delete every row of the
+// table, so that it is not rejected by a Guard for having no WHERE clause.
+func (q DuckDBDeleteQuery) AllowFullTable() DuckDBDeleteQuery {
+	q.FullTable = true
+	return q
+}
+
+// Returning appends fields to the RETURNING clause of the DuckDBDeleteQuery.
+func (q DuckDBDeleteQuery) Returning(fields ...Field) DuckDBDeleteQuery {
+	q.ReturningFields = append(q.ReturningFields, fields...)
+	return q
+}
+
+// SetFetchableFields implements the Query interface.
+func (q DuckDBDeleteQuery) SetFetchableFields(fields []Field) (query Query, ok bool) {
+	return DeleteQuery(q).SetFetchableFields(fields)
+}
+
+// GetFetchableFields returns the fetchable fields of the query.
+func (q DuckDBDeleteQuery) GetFetchableFields() []Field {
+	return DeleteQuery(q).GetFetchableFields()
+}
+
+// GetDialect implements the Query interface.
+func (q DuckDBDeleteQuery) GetDialect() string { return q.Dialect }
+
+// SetDialect sets the dialect of the query.
+func (q DuckDBDeleteQuery) SetDialect(dialect string) DuckDBDeleteQuery {
+	q.Dialect = dialect
+	return q
+}
+
+// OracleDeleteQuery represents an Oracle DELETE query.
+type OracleDeleteQuery DeleteQuery
+
+var _ Query = (*OracleDeleteQuery)(nil)
+
+// WriteSQL implements the SQLWriter interface.
+func (q OracleDeleteQuery) WriteSQL(ctx context.Context, dialect string, buf *bytes.Buffer, args *[]any, params map[string][]int) error {
+	return DeleteQuery(q).WriteSQL(ctx, dialect, buf, args, params)
+}
+
+// DeleteFrom returns a new OracleDeleteQuery.
+func (b oracleQueryBuilder) DeleteFrom(table Table) OracleDeleteQuery {
+	return OracleDeleteQuery{
+		Dialect:     DialectOracle,
+		CTEs:        b.ctes,
+		DeleteTable: table,
+	}
+}
+
+// Where appends to the WherePredicate field of the OracleDeleteQuery.
+func (q OracleDeleteQuery) Where(predicates ...Predicate) OracleDeleteQuery {
+	q.WherePredicate = appendPredicates(q.WherePredicate, predicates)
+	return q
+}
+
+// AllowFullTable marks the OracleDeleteQuery as meant to delete every row of the
+// table, so that it is not rejected by a Guard for having no WHERE clause.
+func (q OracleDeleteQuery) AllowFullTable() OracleDeleteQuery {
+	q.FullTable = true
+	return q
+}
+
+// Returning appends fields to the RETURNING clause of the OracleDeleteQuery.
+func (q OracleDeleteQuery) Returning(fields ...Field) OracleDeleteQuery {
+	q.ReturningFields = append(q.ReturningFields, fields...)
+	return q
+}
+
+// Into adds destinations to the INTO clause of the OracleDeleteQuery's
+// RETURNING clause. There must be one destination for every RETURNING field.
+func (q OracleDeleteQuery) Into(dest ...any) OracleDeleteQuery {
+	q.ReturningInto = append(q.ReturningInto, dest...)
+	return q
+}
+
+// SetFetchableFields implements the Query interface.
+func (q OracleDeleteQuery) SetFetchableFields(fields []Field) (query Query, ok bool) {
+	return DeleteQuery(q).SetFetchableFields(fields)
+}
+
+// GetFetchableFields returns the fetchable fields of the query.
+func (q OracleDeleteQuery) GetFetchableFields() []Field {
+	return DeleteQuery(q).GetFetchableFields()
+}
+
+// GetDialect implements the Query interface.
+func (q OracleDeleteQuery) GetDialect() string { return q.Dialect }
+
+// SetDialect sets the dialect of the query.
+func (q OracleDeleteQuery) SetDialect(dialect string) OracleDeleteQuery {
+	q.Dialect = dialect
+	return q
+}
@@ -296,6 +296,58 @@ func TestSQLServerDeleteQuery(t *testing.T) {
 	})
 }
 
+func TestDuckDBDeleteQuery(t *testing.T) {
+	type ACTOR struct {
+		TableStruct
+		ACTOR_ID    NumberField
+		FIRST_NAME  StringField
+		LAST_NAME   StringField
+		LAST_UPDATE TimeField
+	}
+	a := New[ACTOR]("a")
+
+	t.Run("basic", func(t *testing.T) {
+		t.Parallel()
+		q1 := DuckDB.DeleteFrom(a).Returning(a.FIRST_NAME).SetDialect("lorem ipsum")
+		if diff := testutil.Diff(q1.GetDialect(), "lorem ipsum"); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		q1 = q1.SetDialect(DialectDuckDB)
+		fields := q1.GetFetchableFields()
+		if diff := testutil.Diff(fields, []Field{a.FIRST_NAME}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		_, ok := q1.SetFetchableFields([]Field{a.LAST_NAME})
+		if ok {
+			t.Fatal(testutil.Callers(), "field should not have been set")
+		}
+		q1.ReturningFields = q1.ReturningFields[:0]
+		_, ok = q1.SetFetchableFields([]Field{a.LAST_NAME})
+		if !ok {
+			t.Fatal(testutil.Callers(), "field should have been set")
+		}
+	})
+
+	t.Run("Using Returning", func(t *testing.T) {
+		t.Parallel()
+		b := New[ACTOR]("b")
+		var tt TestTable
+		tt.item = DuckDB.
+			With(NewCTE("cte", nil, Queryf("SELECT 1"))).
+			DeleteFrom(a).
+			Using(b).
+			Where(a.ACTOR_ID.Eq(b.ACTOR_ID), b.LAST_NAME.EqString("x")).
+			Returning(a.FIRST_NAME, a.LAST_NAME)
+		tt.wantQuery = "WITH cte AS (SELECT 1)" +
+			" DELETE FROM actor AS a" +
+			" USING actor AS b" +
+			" WHERE a.actor_id = b.actor_id AND b.last_name = $1" +
+			" RETURNING a.first_name, a.last_name"
+		tt.wantArgs = []any{"x"}
+		tt.assert(t)
+	})
+}
+
 func TestDeleteQuery(t *testing.T) {
 	t.Run("basic", func(t *testing.T) {
 		t.Parallel()
@@ -0,0 +1,480 @@
+package sq
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bokwoon95/sq"
+)
+
+// PlaceholderStyle is the style of the bind parameter placeholders that a
+// Dialect expects in a query.
+type PlaceholderStyle int
+
+// Placeholder styles.
+const (
+	PlaceholderQuestion PlaceholderStyle = iota // ?, ?, ?
+	PlaceholderDollar                           // $1, $2, $3
+	PlaceholderColon                            // :1, :2, :3
+	PlaceholderAtP                              // @p1, @p2, @p3
+)
+
+// Dialect describes how queries are rendered for a database. The built-in
+// dialects (sqlite, postgres, mysql, sqlserver, duckdb and oracle) are always
+// registered; additional dialects can be added with RegisterDialect and are
+// then usable anywhere a dialect string is accepted, by passing in their
+// Name().
+type Dialect interface {
+	// Name returns the name of the dialect. This is the dialect string that
+	// is passed to WriteSQL.
+	Name() string
+
+	// PlaceholderStyle returns the style of the bind parameter placeholders.
+	PlaceholderStyle() PlaceholderStyle
+
+	// IdentifierQuotes returns the opening and closing characters used for
+	// quoting identifiers.
+	IdentifierQuotes() (open, close byte)
+
+	// IsReservedWord reports whether the (lowercase) word is a keyword that
+	// must be quoted when used as an identifier.
+	IsReservedWord(word string) bool
+
+	// FormatLiteral returns the SQL representation of a value. It is used
+	// by Sprint and Sprintf to interpolate args into a query for logging.
+	// Implementations must not call Sprint with their own dialect name, use
+	// Sprint("", value) to get the generic representation instead.
+	FormatLiteral(value any) (string, error)
+
+	// Supports reports whether the dialect supports the given Capability.
+	Supports(capability Capability) bool
+
+	// WriteLimitOffset writes the LIMIT and OFFSET clauses of a SELECT
+	// query. Either limit or offset may be nil, but not both.
+	WriteLimitOffset(ctx context.Context, buf *bytes.Buffer, args *[]any, params map[string][]int, limit, offset any) error
+}
+
+var (
+	dialectsMu sync.RWMutex
+	dialects   = map[string]Dialect{
+		DialectSQLite: builtinDialect{
+			name:             DialectSQLite,
+			placeholderStyle: PlaceholderDollar,
+			openQuote:        '"',
+			closeQuote:       '"',
+			keywords:         sqliteKeywords,
+		},
+		DialectPostgres: builtinDialect{
+			name:             DialectPostgres,
+			placeholderStyle: PlaceholderDollar,
+			openQuote:        '"',
+			closeQuote:       '"',
+			keywords:         postgresKeywords,
+		},
+		DialectMySQL: builtinDialect{
+			name:             DialectMySQL,
+			placeholderStyle: PlaceholderQuestion,
+			openQuote:        '`',
+			closeQuote:       '`',
+			keywords:         mysqlKeywords,
+		},
+		DialectSQLServer: builtinDialect{
+			name:             DialectSQLServer,
+			placeholderStyle: PlaceholderAtP,
+			openQuote:        '[',
+			closeQuote:       ']',
+			keywords:         sqlserverKeywords,
+		},
+		DialectDuckDB: duckdbDialect{},
+		DialectOracle: oracleDialect{},
+	}
+)
+
+// RegisterDialect makes a Dialect available by its name. It panics if the
+// dialect is nil, has an empty name or if a dialect with the same name is
+// already registered.
+func RegisterDialect(dialect Dialect) {
+	if dialect == nil {
+		panic("sq: RegisterDialect dialect is nil")
+	}
+	name := dialect.Name()
+	if name == "" {
+		panic("sq: RegisterDialect dialect name is empty")
+	}
+	dialectsMu.Lock()
+	defer dialectsMu.Unlock()
+	if _, ok := dialects[name]; ok {
+		panic("sq: RegisterDialect called twice for dialect " + name)
+	}
+	dialects[name] = dialect
+}
+
+// LookupDialect returns the registered Dialect with the given name.
+func LookupDialect(name string) (Dialect, bool) {
+	dialectsMu.RLock()
+	defer dialectsMu.RUnlock()
+	dialect, ok := dialects[name]
+	return dialect, ok
+}
+
+// Dialects returns a sorted list of the names of the registered dialects.
+func Dialects() []string {
+	dialectsMu.RLock()
+	defer dialectsMu.RUnlock()
+	names := make([]string, 0, len(dialects))
+	for name := range dialects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// customDialect returns the registered Dialect for a dialect that is not one
+// of the built-in dialects, or nil if there is none. The built-in dialects
+// are handled directly by the switch statements sprinkled throughout the
+// package.
+func customDialect(dialect string) Dialect {
+	switch dialect {
+	case "", DialectSQLite, DialectPostgres, DialectMySQL, DialectSQLServer:
+		return nil
+	}
+	d, _ := LookupDialect(dialect)
+	return d
+}
+
+// placeholderStyle returns the PlaceholderStyle used by the dialect.
+func placeholderStyle(dialect string) PlaceholderStyle {
+	switch dialect {
+	case DialectPostgres, DialectSQLite:
+		return PlaceholderDollar
+	case DialectSQLServer:
+		return PlaceholderAtP
+	case "", DialectMySQL:
+		return PlaceholderQuestion
+	}
+	if d := customDialect(dialect); d != nil {
+		return d.PlaceholderStyle()
+	}
+	return PlaceholderQuestion
+}
+
+// writePlaceholder writes the placeholder for the arg at the index of the
+// args slice.
+func writePlaceholder(buf *bytes.Buffer, style PlaceholderStyle, index int) {
+	switch style {
+	case PlaceholderDollar:
+		buf.WriteString("$" + strconv.Itoa(index+1))
+	case PlaceholderColon:
+		buf.WriteString(":" + strconv.Itoa(index+1))
+	case PlaceholderAtP:
+		buf.WriteString("@p" + strconv.Itoa(index+1))
+	default:
+		buf.WriteString("?")
+	}
+}
+
+// supportsFetchableReturning reports whether a RETURNING clause can be
+// added to a query so that its results can be fetched.
+func supportsFetchableReturning(dialect string, capability Capability) bool {
+	switch dialect {
+	case DialectPostgres, DialectSQLite:
+		return true
+	case DialectMySQL, DialectSQLServer, DialectOracle:
+		return false
+	}
+	d := customDialect(dialect)
+	return d != nil && d.Supports(capability)
+}
+
+// tableAliasAS returns the keyword that goes between a table and its alias.
+// Oracle does not accept AS there.
+func tableAliasAS(dialect string) string {
+	if dialect == DialectOracle {
+		return " "
+	}
+	return " AS "
+}
+
+// builtinDialect implements the Dialect interface for the built-in dialects.
+type builtinDialect struct {
+	name             string
+	placeholderStyle PlaceholderStyle
+	openQuote        byte
+	closeQuote       byte
+	keywords         map[string]struct{}
+}
+
+var _ Dialect = (*builtinDialect)(nil)
+
+// Name implements the Dialect interface.
+func (d builtinDialect) Name() string { return d.name }
+
+// PlaceholderStyle implements the Dialect interface.
+func (d builtinDialect) PlaceholderStyle() PlaceholderStyle { return d.placeholderStyle }
+
+// IdentifierQuotes implements the Dialect interface.
+func (d builtinDialect) IdentifierQuotes() (open, close byte) { return d.openQuote, d.closeQuote }
+
+// IsReservedWord implements the Dialect interface.
+func (d builtinDialect) IsReservedWord(word string) bool {
+	_, ok := d.keywords[strings.ToLower(word)]
+	return ok
+}
+
+// FormatLiteral implements the Dialect interface.
+func (d builtinDialect) FormatLiteral(value any) (string, error) { return Sprint(d.name, value) }
+
+// Supports implements the Dialect interface.
+func (d builtinDialect) Supports(capability Capability) bool {
+	return dialectCapabilities[d.name][capability]
+}
+
+// WriteLimitOffset implements the Dialect interface.
+func (d builtinDialect) WriteLimitOffset(ctx context.Context, buf *bytes.Buffer, args *[]any, params map[string][]int, limit, offset any) error {
+	return writeLimitOffset(ctx, d.name, buf, args, params, limit, offset)
+}
+
+// writeLimitOffset writes the standard LIMIT n OFFSET m clauses (or OFFSET m
+// ROWS for sqlserver).
+func writeLimitOffset(ctx context.Context, dialect string, buf *bytes.Buffer, args *[]any, params map[string][]int, limit, offset any) error {
+	if limit != nil {
+		if dialect == DialectSQLServer {
+			return fmt.Errorf("sqlserver does not support LIMIT")
+		}
+		buf.WriteString(" LIMIT ")
+		err := WriteValue(ctx, dialect, buf, args, params, limit)
+		if err != nil {
+			return fmt.Errorf("LIMIT: %w", err)
+		}
+	}
+	if offset != nil {
+		buf.WriteString(" OFFSET ")
+		err := WriteValue(ctx, dialect, buf, args, params, offset)
+		if err != nil {
+			return fmt.Errorf("OFFSET: %w", err)
+		}
+		if dialect == DialectSQLServer {
+			buf.WriteString(" ROWS")
+		}
+	}
+	return nil
+}
+
+// duckdbDialect implements the Dialect interface for DuckDB.
+type duckdbDialect struct{}
+
+var _ Dialect = (*duckdbDialect)(nil)
+
+// Name implements the Dialect interface.
+func (d duckdbDialect) Name() string { return DialectDuckDB }
+
+// PlaceholderStyle implements the Dialect interface.
+func (d duckdbDialect) PlaceholderStyle() PlaceholderStyle { return PlaceholderDollar }
+
+// IdentifierQuotes implements the Dialect interface.
+func (d duckdbDialect) IdentifierQuotes() (open, close byte) { return '"', '"' }
+
+// IsReservedWord implements the Dialect interface.
+func (d duckdbDialect) IsReservedWord(word string) bool {
+	_, ok := duckdbKeywords[strings.ToLower(word)]
+	return ok
+}
+
+// FormatLiteral implements the Dialect interface. DuckDB literals are the
+// same as Postgres literals, except for BLOBs which are written as a
+// sequence of \xHH escapes.
+func (d duckdbDialect) FormatLiteral(value any) (string, error) {
+	if b, ok := value.([]byte); ok {
+		var sb strings.Builder
+		sb.Grow(len(b)*4 + 8)
+		sb.WriteString("'")
+		for _, c := range b {
+			fmt.Fprintf(&sb, `\x%02X`, c)
+		}
+		sb.WriteString("'::BLOB")
+		return sb.String(), nil
+	}
+	return Sprint(DialectPostgres, value)
+}
+
+// Supports implements the Dialect interface.
+func (d duckdbDialect) Supports(capability Capability) bool {
+	return dialectCapabilities[DialectDuckDB][capability]
+}
+
+// WriteLimitOffset implements the Dialect interface.
+func (d duckdbDialect) WriteLimitOffset(ctx context.Context, buf *bytes.Buffer, args *[]any, params map[string][]int, limit, offset any) error {
+	return writeLimitOffset(ctx, DialectDuckDB, buf, args, params, limit, offset)
+}
+
+// oracleDialect implements the Dialect interface for Oracle. Oracle queries
+// can only be rendered, sq does not ship with support for any Oracle driver's
+// result types.
+type oracleDialect struct{}
+
+var _ Dialect = (*oracleDialect)(nil)
+
+// Name implements the Dialect interface.
+func (d oracleDialect) Name() string { return DialectOracle }
+
+// PlaceholderStyle implements the Dialect interface.
+func (d oracleDialect) PlaceholderStyle() PlaceholderStyle { return PlaceholderColon }
+
+// IdentifierQuotes implements the Dialect interface.
+func (d oracleDialect) IdentifierQuotes() (open, close byte) { return '"', '"' }
+
+// IsReservedWord implements the Dialect interface.
+func (d oracleDialect) IsReservedWord(word string) bool {
+	_, ok := oracleKeywords[strings.ToLower(word)]
+	return ok
+}
+
+// FormatLiteral implements the Dialect interface. Oracle has no boolean
+// literals so booleans are written as 1 and 0, BLOBs are written with
+// HEXTORAW and times are written as TIMESTAMP literals.
+func (d oracleDialect) FormatLiteral(value any) (string, error) {
+	const timestamp = "2006-01-02 15:04:05.999999999"
+	switch value := value.(type) {
+	case bool:
+		if value {
+			return "1", nil
+		}
+		return "0", nil
+	case sql.NullBool:
+		if !value.Valid {
+			return "NULL", nil
+		}
+		return d.FormatLiteral(value.Bool)
+	case []byte:
+		return "HEXTORAW('" + strings.ToUpper(hex.EncodeToString(value)) + "')", nil
+	case time.Time:
+		return "TIMESTAMP '" + value.UTC().Format(timestamp) + "'", nil
+	case sql.NullTime:
+		if !value.Valid {
+			return "NULL", nil
+		}
+		return d.FormatLiteral(value.Time)
+	case string, sql.NullString:
+		// Oracle uses CHR() and || for newlines, same as Postgres.
+		return Sprint(DialectPostgres, value)
+	case sql.Out:
+		return Sprint("", value.Dest)
+	}
+	return Sprint("", value)
+}
+
+// Supports implements the Dialect interface.
+func (d oracleDialect) Supports(capability Capability) bool {
+	return dialectCapabilities[DialectOracle][capability]
+}
+
+// WriteLimitOffset implements the Dialect interface. Oracle paginates with
+// OFFSET n ROWS FETCH FIRST m ROWS ONLY.
+func (d oracleDialect) WriteLimitOffset(ctx context.Context, buf *bytes.Buffer, args *[]any, params map[string][]int, limit, offset any) error {
+	if offset != nil {
+		buf.WriteString(" OFFSET ")
+		err := WriteValue(ctx, DialectOracle, buf, args, params, offset)
+		if err != nil {
+			return fmt.Errorf("OFFSET: %w", err)
+		}
+		buf.WriteString(" ROWS")
+	}
+	if limit != nil {
+		if offset != nil {
+			buf.WriteString(" FETCH NEXT ")
+		} else {
+			buf.WriteString(" FETCH FIRST ")
+		}
+		err := WriteValue(ctx, DialectOracle, buf, args, params, limit)
+		if err != nil {
+			return fmt.Errorf("LIMIT: %w", err)
+		}
+		buf.WriteString(" ROWS ONLY")
+	}
+	return nil
+}
+
+// WithDialect wraps a DB so that queries without a dialect (e.g. those built
+// with the generic Select, InsertInto, Update and DeleteFrom constructors) are
+// rendered in the given dialect when run against it. This allows a program to
+// talk to databases of different dialects at the same time, which a single
+// DefaultDialect cannot do. Like other middleware, the returned DB keeps the
+// optional interfaces of db (see Chain.Then), and transactions run on it with
+// RunInTx use the same dialect.
+func WithDialect(db DB, dialect string) DB {
+	return NewChain(func(db sq.DB) sq.DB {
+		return dialectDB{DB: db, dialect: dialect}
+	}).Then(db)
+}
+
+type dialectDB struct {
+	DB
+	dialect string
+}
+
+// GetDialect returns the dialect of the DB.
+func (db dialectDB) GetDialect() string { return db.dialect }
+
+// Unwrap returns the underlying DB.
+func (db dialectDB) Unwrap() DB { return db.DB }
+
+// driverDialects maps the package paths of well-known database/sql drivers to
+// their dialects.
+var driverDialects = map[string]string{
+	"github.com/mattn/go-sqlite3":        DialectSQLite,
+	"modernc.org/sqlite":                 DialectSQLite,
+	"github.com/jackc/pgx/v4/stdlib":     DialectPostgres,
+	"github.com/jackc/pgx/v5/stdlib":     DialectPostgres,
+	"github.com/lib/pq":                  DialectPostgres,
+	"github.com/go-sql-driver/mysql":     DialectMySQL,
+	"github.com/microsoft/go-mssqldb":    DialectSQLServer,
+	"github.com/denisenkom/go-mssqldb":   DialectSQLServer,
+	"github.com/marcboeker/go-duckdb":    DialectDuckDB,
+	"github.com/marcboeker/go-duckdb/v2": DialectDuckDB,
+	"github.com/sijms/go-ora/v2":         DialectOracle,
+}
+
+// dbDialect returns the dialect of a DB. A DB that has a GetDialect() string
+// method (such as one returned by WithDialect) reports its own dialect, while
+// the dialect of an *sql.DB (or anything else with a Driver() method) is
+// detected from the type of its driver. An empty string is returned if the
+// dialect is unknown.
+func dbDialect(db DB) string {
+	switch db := db.(type) {
+	case interface{ GetDialect() string }:
+		return db.GetDialect()
+	case interface{ Driver() driver.Driver }:
+		typ := reflect.TypeOf(db.Driver())
+		for typ.Kind() == reflect.Pointer {
+			typ = typ.Elem()
+		}
+		return driverDialects[typ.PkgPath()]
+	}
+	return ""
+}
+
+// queryDialect returns the dialect that a query is rendered in when it is run
+// against a DB: the query's own dialect, otherwise the DB's dialect, otherwise
+// the DefaultDialect.
+func queryDialect(db DB, query Query) string {
+	if dialect := query.GetDialect(); dialect != "" {
+		return dialect
+	}
+	if dialect := dbDialect(db); dialect != "" {
+		return dialect
+	}
+	if defaultDialect := DefaultDialect.Load(); defaultDialect != nil {
+		return *defaultDialect
+	}
+	return ""
+}
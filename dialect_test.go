@@ -0,0 +1,535 @@
+package sq
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/blink-io/sq/internal/testutil"
+	"github.com/bokwoon95/sq"
+)
+
+const dialectTest = "testdialect"
+
+func init() {
+	RegisterDialect(testDialect{})
+}
+
+// testDialect is an Oracle-like dialect with :1 placeholders and
+// OFFSET/FETCH pagination.
+type testDialect struct{}
+
+func (testDialect) Name() string { return dialectTest }
+
+func (testDialect) PlaceholderStyle() PlaceholderStyle { return PlaceholderColon }
+
+func (testDialect) IdentifierQuotes() (open, close byte) { return '"', '"' }
+
+func (testDialect) IsReservedWord(word string) bool { return word == "level" || word == "select" }
+
+func (testDialect) FormatLiteral(value any) (string, error) {
+	if b, ok := value.(bool); ok {
+		if b {
+			return "1", nil
+		}
+		return "0", nil
+	}
+	return Sprint("", value)
+}
+
+func (testDialect) Supports(capability Capability) bool {
+	return capability == CapabilityFetchNext || capability == CapabilityInsertReturning
+}
+
+func (testDialect) WriteLimitOffset(ctx context.Context, buf *bytes.Buffer, args *[]any, params map[string][]int, limit, offset any) error {
+	if offset != nil {
+		buf.WriteString(" OFFSET ")
+		err := WriteValue(ctx, dialectTest, buf, args, params, offset)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(" ROWS")
+	}
+	if limit != nil {
+		buf.WriteString(" FETCH NEXT ")
+		err := WriteValue(ctx, dialectTest, buf, args, params, limit)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(" ROWS ONLY")
+	}
+	return nil
+}
+
+func TestDialect(t *testing.T) {
+	a := NewTableStruct("", "a", "")
+	aID := NewNumberField("id", a)
+	aLevel := NewNumberField("level", a)
+
+	tests := []TestTable{{
+		description: "placeholders and quoting",
+		dialect:     dialectTest,
+		item: Select(aID, aLevel).
+			From(a).
+			Where(aID.In([]int{1, 2}), aLevel.EqInt(3)).
+			Limit(10).
+			Offset(20),
+		wantQuery: "SELECT a.id, a.\"level\" FROM a" +
+			" WHERE a.id IN (:1, :2) AND a.\"level\" = :3" +
+			" OFFSET :4 ROWS FETCH NEXT :5 ROWS ONLY",
+		wantArgs: []any{1, 2, 3, 20, 10},
+	}, {
+		description: "named and ordinal params",
+		dialect:     dialectTest,
+		item:        Expr("{1} = {x} OR {1} = {x}", 5, sql.Named("x", 6)),
+		wantQuery:   ":1 = :2 OR :1 = :2",
+		wantArgs:    []any{5, 6},
+		wantParams:  map[string][]int{"x": {1}},
+	}, {
+		description: "RETURNING",
+		dialect:     dialectTest,
+		item:        Postgres.InsertInto(a).Columns(aID).Values(1).Returning(aID),
+		wantQuery:   "INSERT INTO a (id) VALUES (:1) RETURNING a.id",
+		wantArgs:    []any{1},
+	}}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.description, func(t *testing.T) {
+			t.Parallel()
+			tt.assert(t)
+		})
+	}
+
+	t.Run("unsupported", func(t *testing.T) {
+		t.Parallel()
+		_, _, err := ToSQL(dialectTest, Postgres.Update(a).Set(Set(aID, 1)).Returning(aID), nil)
+		if err == nil {
+			t.Fatal(testutil.Callers(), "expected error but got nil")
+		}
+		_, _, err = ToSQL(dialectTest, Postgres.Select(aID).DistinctOn(aID).From(a), nil)
+		if err == nil {
+			t.Fatal(testutil.Callers(), "expected error but got nil")
+		}
+		if Supports(dialectTest, CapabilityFullJoin) {
+			t.Error(testutil.Callers(), "expected FULL JOIN to be unsupported")
+		}
+	})
+
+	t.Run("Sprintf", func(t *testing.T) {
+		t.Parallel()
+		got, err := Sprintf(dialectTest, "SELECT :1, :2, \"x\" FROM t WHERE y = :1", []any{true, "it's"})
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		want := "SELECT 1, 'it''s', \"x\" FROM t WHERE y = 1"
+		if diff := testutil.Diff(got, want); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("SetFetchableFields", func(t *testing.T) {
+		t.Parallel()
+		query, ok := InsertInto(a).Columns(aID).Values(1).SetDialect(dialectTest).SetFetchableFields([]Field{aID})
+		if !ok {
+			t.Fatal(testutil.Callers(), "expected ok")
+		}
+		gotQuery, _, err := ToSQL(dialectTest, query, nil)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if diff := testutil.Diff(gotQuery, "INSERT INTO a (id) VALUES (:1) RETURNING a.id"); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("registry", func(t *testing.T) {
+		t.Parallel()
+		for _, name := range []string{DialectSQLite, DialectPostgres, DialectMySQL, DialectSQLServer, dialectTest} {
+			d, ok := LookupDialect(name)
+			if !ok {
+				t.Fatalf(testutil.Callers()+" dialect %s not registered", name)
+			}
+			if diff := testutil.Diff(d.Name(), name); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+		}
+		if _, ok := LookupDialect("nonexistent"); ok {
+			t.Error(testutil.Callers(), "expected nonexistent dialect to not be registered")
+		}
+		d, _ := LookupDialect(DialectSQLServer)
+		buf, args := &bytes.Buffer{}, &[]any{}
+		err := d.WriteLimitOffset(context.Background(), buf, args, nil, nil, 5)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if diff := testutil.Diff(buf.String(), " OFFSET @p1 ROWS"); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		if !d.IsReservedWord("SELECT") {
+			t.Error(testutil.Callers(), "expected SELECT to be a reserved word")
+		}
+		literal, err := d.FormatLiteral(true)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if diff := testutil.Diff(literal, "1"); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("RegisterDialect duplicate", func(t *testing.T) {
+		t.Parallel()
+		defer func() {
+			if recover() == nil {
+				t.Error(testutil.Callers(), "expected panic")
+			}
+		}()
+		RegisterDialect(testDialect{})
+	})
+}
+
+// mixedCaseDialect is a testDialect registered under a mixed-case name.
+type mixedCaseDialect struct{ testDialect }
+
+func (mixedCaseDialect) Name() string { return "MixedCaseDialect" }
+
+func TestSetDefaultDialect(t *testing.T) {
+	RegisterDialect(mixedCaseDialect{})
+	defer ResetDefaultDialect()
+	for _, tt := range []struct {
+		dialect     string
+		wantDialect string
+	}{
+		{"SQLite", DialectSQLite},
+		{"MixedCaseDialect", "MixedCaseDialect"},
+		{dialectTest, dialectTest},
+	} {
+		ResetDefaultDialect()
+		SetDefaultDialect(tt.dialect)
+		defaultDialect := DefaultDialect.Load()
+		if defaultDialect == nil {
+			t.Errorf(testutil.Callers()+" %s: expected a default dialect", tt.dialect)
+			continue
+		}
+		if diff := testutil.Diff(*defaultDialect, tt.wantDialect); diff != "" {
+			t.Error(testutil.Callers(), tt.dialect, diff)
+		}
+	}
+}
+
+func TestOracle(t *testing.T) {
+	type ACTOR struct {
+		TableStruct
+		ACTOR_ID    NumberField
+		FIRST_NAME  StringField
+		LAST_NAME   StringField
+		LEVEL       NumberField
+		LAST_UPDATE TimeField
+	}
+	a := New[ACTOR]("a")
+	b := New[ACTOR]("b")
+	var actorID int64
+	var firstName string
+
+	tests := []struct {
+		name string
+		item SQLWriter
+	}{{
+		name: "select_fetch_first",
+		item: Oracle.
+			From(a).
+			Select(a.ACTOR_ID, a.LEVEL).
+			Where(a.ACTOR_ID.In([]int{1, 2, 3})).
+			OrderBy(a.ACTOR_ID).
+			Limit(10),
+	}, {
+		name: "select_offset_fetch",
+		item: Oracle.
+			From(a).
+			Join(b, a.ACTOR_ID.Eq(b.ACTOR_ID)).
+			Select(a.FIRST_NAME, b.LAST_NAME).
+			OrderBy(a.FIRST_NAME).
+			Limit(10).
+			Offset(20),
+	}, {
+		name: "select_with_ties",
+		item: Oracle.
+			From(a).
+			Select(a.ACTOR_ID).
+			OrderBy(a.LAST_UPDATE).
+			Offset(5).
+			FetchNext(10).
+			WithTies(),
+	}, {
+		name: "select_one",
+		item: Oracle.SelectOne(),
+	}, {
+		name: "select_cte_minus",
+		item: Oracle.
+			With(NewRecursiveCTE("cte", []string{"n"}, UnionAll(
+				Oracle.Select(Expr("1")),
+				Oracle.Queryf("SELECT n + 1 FROM cte WHERE n < {}", 10),
+			))).
+			From(NewCTE("cte", nil, nil)).
+			Select(Expr("n")).
+			Where(Expr("n").In(Except(
+				Oracle.Select(Expr("1")),
+				Oracle.Select(Expr("2")),
+			))),
+	}, {
+		name: "insert_values",
+		item: Oracle.
+			InsertInto(a).
+			Columns(a.ACTOR_ID, a.FIRST_NAME, a.LAST_NAME).
+			Values(1, "bob", "the builder"),
+	}, {
+		name: "insert_multiple_rows",
+		item: Oracle.
+			InsertInto(a).
+			Columns(a.ACTOR_ID, a.FIRST_NAME, a.LAST_NAME).
+			Values(1, "bob", "the builder").
+			Values(2, "alice", "in wonderland"),
+	}, {
+		name: "insert_returning_into",
+		item: Oracle.
+			InsertInto(a).
+			Columns(a.FIRST_NAME, a.LAST_NAME).
+			Values("bob", "the builder").
+			Returning(a.ACTOR_ID, a.FIRST_NAME).
+			Into(&actorID, &firstName),
+	}, {
+		name: "update_returning_into",
+		item: Oracle.
+			Update(a).
+			Set(a.FIRST_NAME.SetString("bob")).
+			Where(a.ACTOR_ID.EqInt(1)).
+			Returning(a.ACTOR_ID).
+			Into(&actorID),
+	}, {
+		name: "delete_returning_into",
+		item: Oracle.
+			DeleteFrom(a).
+			Where(a.ACTOR_ID.EqInt(1)).
+			Returning(a.FIRST_NAME).
+			Into(sql.Out{Dest: &firstName}),
+	}, {
+		name: "merge_do_update",
+		item: Oracle.
+			InsertInto(a).
+			Columns(a.ACTOR_ID, a.FIRST_NAME, a.LAST_NAME).
+			Values(1, "bob", "the builder").
+			OnConflict(a.ACTOR_ID).
+			DoUpdateSet(
+				a.FIRST_NAME.Set(a.FIRST_NAME.WithPrefix("EXCLUDED")),
+				a.LAST_NAME.Set(a.LAST_NAME.WithPrefix("EXCLUDED")),
+			).
+			Where(a.LAST_NAME.IsNotNull()),
+	}, {
+		name: "merge_do_nothing",
+		item: Oracle.
+			InsertInto(New[ACTOR]("")).
+			Columns(a.ACTOR_ID, a.FIRST_NAME).
+			Values(1, "bob").
+			Values(2, "alice").
+			OnConflict(a.ACTOR_ID).
+			DoNothing(),
+	}, {
+		name: "literals",
+		item: Expr("SELECT {}, {}, {}, {}, {} FROM DUAL",
+			true,
+			[]byte{0xde, 0xad, 0xbe, 0xef},
+			time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC),
+			"it's\nmultiline",
+			sql.NullBool{},
+		),
+	}}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			query, args, err := ToSQL(DialectOracle, tt.item, nil)
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			var got strings.Builder
+			got.WriteString(query + "\n")
+			got.WriteString("-- args:")
+			for _, arg := range args {
+				literal, err := Sprint(DialectOracle, arg)
+				if err != nil {
+					t.Fatal(testutil.Callers(), err)
+				}
+				got.WriteString(" " + literal)
+			}
+			got.WriteString("\n")
+			// The literals test case checks the interpolated query.
+			if tt.name == "literals" {
+				interpolated, err := Sprintf(DialectOracle, query, args)
+				if err != nil {
+					t.Fatal(testutil.Callers(), err)
+				}
+				got.WriteString(interpolated + "\n")
+			}
+			filename := filepath.Join("testdata", "oracle", tt.name+".sql")
+			if *update {
+				err = os.WriteFile(filename, []byte(got.String()), 0644)
+				if err != nil {
+					t.Fatal(testutil.Callers(), err)
+				}
+			}
+			want, err := os.ReadFile(filename)
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			if diff := testutil.Diff(got.String(), string(want)); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+		})
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		queries := []Query{
+			Oracle.InsertInto(a).Columns(a.ACTOR_ID).Values(1).Returning(a.ACTOR_ID),
+			Oracle.InsertInto(a).Columns(a.ACTOR_ID).Values(1).OnConflict(a.ACTOR_ID).DoNothing().Returning(a.ACTOR_ID).Into(&actorID),
+			Oracle.With(NewCTE("cte", nil, Oracle.SelectOne())).InsertInto(a).Columns(a.ACTOR_ID).Values(1),
+			Oracle.From(a).Select(a.ACTOR_ID).OrderBy(a.ACTOR_ID).Limit(5).FetchNext(5),
+			InsertQuery{
+				InsertTable:   a,
+				InsertColumns: []Field{a.ACTOR_ID},
+				RowValues:     []RowValue{{1}},
+				Conflict:      ConflictClause{ConstraintName: "actor_pkey", DoNothing: true},
+			},
+		}
+		for i, query := range queries {
+			_, _, err := ToSQL(DialectOracle, query, nil)
+			if err == nil {
+				t.Errorf(testutil.Callers()+" query #%d: expected error but got nil", i+1)
+			}
+		}
+	})
+
+	t.Run("SetFetchableFields", func(t *testing.T) {
+		t.Parallel()
+		_, ok := Oracle.InsertInto(a).Columns(a.ACTOR_ID).Values(1).SetFetchableFields([]Field{a.ACTOR_ID})
+		if ok {
+			t.Error(testutil.Callers(), "oracle RETURNING should not be fetchable")
+		}
+	})
+}
+
+type statsLogger struct {
+	mu    sync.Mutex
+	stats []QueryStats
+}
+
+func (l *statsLogger) LogSettings(ctx context.Context, settings *LogSettings) {}
+
+func (l *statsLogger) LogQuery(ctx context.Context, stats QueryStats) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stats = append(l.stats, stats)
+}
+
+func TestWithDialect(t *testing.T) {
+	sqliteDB, err := sql.Open("sqlite3", "file:/TestWithDialect/sqlite?vfs=memdb")
+	if err != nil {
+		t.Fatal(testutil.Callers(), err)
+	}
+	defer sqliteDB.Close()
+
+	t.Run("dbDialect", func(t *testing.T) {
+		tests := []struct {
+			db   DB
+			want string
+		}{
+			{sqliteDB, DialectSQLite},
+			{WithDialect(sqliteDB, DialectMySQL), DialectMySQL},
+			{Log(sqliteDB), ""},
+			{WithDialect(Log(sqliteDB), DialectPostgres), DialectPostgres},
+		}
+		for i, tt := range tests {
+			if diff := testutil.Diff(dbDialect(tt.db), tt.want); diff != "" {
+				t.Errorf(testutil.Callers()+" #%d: %s", i+1, diff)
+			}
+		}
+		if _, ok := WithDialect(Log(sqliteDB), DialectSQLite).(Logger); !ok {
+			t.Error(testutil.Callers(), "WithDialect did not preserve the Logger")
+		}
+	})
+
+	t.Run("query dialect", func(t *testing.T) {
+		logger := &statsLogger{}
+		query := Select(Expr("1")).Where(Expr("{} = 1", 1))
+		tests := []struct {
+			db          DB
+			wantDialect string
+			wantQuery   string
+		}{
+			{struct {
+				*sql.DB
+				Logger
+			}{sqliteDB, logger}, DialectSQLite, "SELECT 1 WHERE $1 = 1"},
+			{WithDialect(struct {
+				DB
+				Logger
+			}{sqliteDB, logger}, DialectMySQL), DialectMySQL, "SELECT 1 WHERE ? = 1"},
+		}
+		for i, tt := range tests {
+			db := tt.db
+			_, err := FetchOne(db, query, func(ctx context.Context, row *Row) int { return row.Int("1") })
+			if err != nil {
+				t.Fatalf(testutil.Callers()+" #%d: %v", i+1, err)
+			}
+			_, err = Exec(db, query)
+			if err != nil {
+				t.Fatalf(testutil.Callers()+" #%d: %v", i+1, err)
+			}
+			_, err = FetchExists(db, query)
+			if err != nil {
+				t.Fatalf(testutil.Callers()+" #%d: %v", i+1, err)
+			}
+			for _, stats := range logger.stats {
+				if diff := testutil.Diff(stats.Dialect, tt.wantDialect); diff != "" {
+					t.Errorf(testutil.Callers()+" #%d: %s", i+1, diff)
+				}
+			}
+			if diff := testutil.Diff(logger.stats[0].Query, tt.wantQuery); diff != "" {
+				t.Errorf(testutil.Callers()+" #%d: %s", i+1, diff)
+			}
+			logger.stats = logger.stats[:0]
+		}
+	})
+
+	t.Run("RunInTx", func(t *testing.T) {
+		db := WithDialect(sqliteDB, DialectMySQL)
+		if _, ok := db.(RunInTxer); !ok {
+			t.Error(testutil.Callers(), "RunInTxer not preserved")
+		}
+		err := RunInTx(context.Background(), db, nil, func(ctx context.Context, tx sq.DB) error {
+			if diff := testutil.Diff(dbDialect(tx), DialectMySQL); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+			_, err := FetchExists(tx, Select(Expr("1")).Where(Expr("{} = 1", 1)))
+			return err
+		})
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+	})
+
+	t.Run("query dialect takes precedence", func(t *testing.T) {
+		dialect := queryDialect(WithDialect(sqliteDB, DialectMySQL), Postgres.Select(Expr("1")))
+		if diff := testutil.Diff(dialect, DialectPostgres); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+}
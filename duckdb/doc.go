@@ -0,0 +1,8 @@
+// Package duckdb runs sq's DuckDB dialect against a real DuckDB database.
+//
+// It lives in its own module so that the cgo-only DuckDB driver (and the
+// dependencies it pulls in) is not a dependency of sq itself. Run its tests
+// from this directory:
+//
+//	go test ./...
+package duckdb
@@ -0,0 +1,217 @@
+package duckdb
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/blink-io/sq"
+	"github.com/blink-io/sq/internal/testutil"
+	"github.com/google/uuid"
+	_ "github.com/marcboeker/go-duckdb"
+)
+
+var TABLE00 = sq.New[struct {
+	sq.TableStruct `sq:"table00"`
+	ID             sq.NumberField
+	UUID           sq.UUIDField
+	DATA           sq.JSONField
+	TEXT_ARRAY     sq.ArrayField
+	INT_ARRAY      sq.ArrayField
+	FLOAT64_ARRAY  sq.ArrayField
+	BOOL_ARRAY     sq.ArrayField
+	BYTES          sq.BinaryField
+	UPDATED_AT     sq.TimeField
+}]("")
+
+type Table00 struct {
+	id           int
+	uuid         uuid.UUID
+	data         any
+	textArray    []string
+	intArray     []int
+	float64Array []float64
+	boolArray    []bool
+	bytes        []byte
+	updatedAt    time.Time
+}
+
+func (t Table00) RowMapper(ctx context.Context, row *sq.Row) Table00 {
+	var value Table00
+	value.id = row.IntField(TABLE00.ID)
+	value.uuid = row.UUIDField(TABLE00.UUID)
+	value.data = row.JSONField(TABLE00.DATA)
+	row.ArrayField(&value.textArray, TABLE00.TEXT_ARRAY)
+	row.ArrayField(&value.intArray, TABLE00.INT_ARRAY)
+	row.ArrayField(&value.float64Array, TABLE00.FLOAT64_ARRAY)
+	row.ArrayField(&value.boolArray, TABLE00.BOOL_ARRAY)
+	value.bytes = row.BytesField(TABLE00.BYTES)
+	value.updatedAt = row.TimeField(TABLE00.UPDATED_AT)
+	return value
+}
+
+var table00Values = []Table00{{
+	id:           1,
+	uuid:         uuid.UUID([16]byte{15: 1}),
+	data:         map[string]any{"lorem ipsum": "dolor sit amet"},
+	textArray:    []string{"one", "two", "three"},
+	intArray:     []int{1, 2, 3},
+	float64Array: []float64{1, 2, 3},
+	boolArray:    []bool{true, false, false},
+	bytes:        []byte{1, 2, 3},
+	updatedAt:    time.Unix(123, 0).UTC(),
+}, {
+	id:           2,
+	uuid:         uuid.UUID([16]byte{15: 2}),
+	data:         map[string]any{"lorem ipsum": "dolor sit amet"},
+	textArray:    []string{"four", "five", "six"},
+	intArray:     []int{4, 5, 6},
+	float64Array: []float64{4, 5, 6},
+	boolArray:    []bool{false, true, false},
+	bytes:        []byte{4, 5, 6},
+	updatedAt:    time.Unix(456, 0).UTC(),
+}, {
+	id:           3,
+	uuid:         uuid.UUID([16]byte{15: 3}),
+	data:         map[string]any{"lorem ipsum": "dolor sit amet"},
+	textArray:    []string{"seven", "eight", "nine"},
+	intArray:     []int{7, 8, 9},
+	float64Array: []float64{7, 8, 9},
+	boolArray:    []bool{false, false, true},
+	bytes:        []byte{7, 8, 9},
+	updatedAt:    time.Unix(789, 0).UTC(),
+}}
+
+// newDB returns a new in-memory DuckDB database with table00 populated with
+// table00Values. The values are inserted with a generic query run directly
+// on the *sql.DB, so the DuckDB dialect must be detected from the driver.
+func newDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatal(testutil.Callers(), err)
+	}
+	t.Cleanup(func() { db.Close() })
+	_, err = db.Exec("CREATE TABLE table00 (" +
+		"\n    id BIGINT" +
+		"\n    ,uuid UUID" +
+		"\n    ,data JSON" +
+		"\n    ,text_array VARCHAR[]" +
+		"\n    ,int_array BIGINT[]" +
+		"\n    ,float64_array DOUBLE[]" +
+		"\n    ,bool_array BOOLEAN[]" +
+		"\n    ,bytes BLOB" +
+		"\n    ,updated_at TIMESTAMP" +
+		"\n);")
+	if err != nil {
+		t.Fatal(testutil.Callers(), err)
+	}
+	result, err := sq.Exec(db, sq.InsertInto(TABLE00).
+		ColumnValues(func(ctx context.Context, col *sq.Column) {
+			for _, value := range table00Values {
+				col.SetInt(TABLE00.ID, value.id)
+				col.SetUUID(TABLE00.UUID, value.uuid)
+				col.SetJSON(TABLE00.DATA, value.data)
+				col.SetArray(TABLE00.TEXT_ARRAY, value.textArray)
+				col.SetArray(TABLE00.INT_ARRAY, value.intArray)
+				col.SetArray(TABLE00.FLOAT64_ARRAY, value.float64Array)
+				col.SetArray(TABLE00.BOOL_ARRAY, value.boolArray)
+				col.SetBytes(TABLE00.BYTES, value.bytes)
+				col.SetTime(TABLE00.UPDATED_AT, value.updatedAt)
+			}
+		}),
+	)
+	if err != nil {
+		t.Fatal(testutil.Callers(), err)
+	}
+	if diff := testutil.Diff(result.RowsAffected, int64(len(table00Values))); diff != "" {
+		t.Fatal(testutil.Callers(), diff)
+	}
+	return db
+}
+
+func TestRow(t *testing.T) {
+	db := newDB(t)
+	values, err := sq.FetchAll(db, sq.From(TABLE00).OrderBy(TABLE00.ID), Table00{}.RowMapper)
+	if err != nil {
+		t.Fatal(testutil.Callers(), err)
+	}
+	if diff := testutil.Diff(values, table00Values); diff != "" {
+		t.Error(testutil.Callers(), diff)
+	}
+}
+
+func TestArrayCast(t *testing.T) {
+	db := newDB(t)
+
+	// Array placeholders are cast to JSON, which DuckDB then casts to the
+	// LIST type of the column.
+	_, err := sq.Exec(sq.Log(db), sq.DuckDB.
+		Update(TABLE00).
+		Set(
+			TABLE00.TEXT_ARRAY.SetArray([]string{`"quoted"`, "comma, separated"}),
+			TABLE00.INT_ARRAY.SetArray([]int64{}),
+		).
+		Where(TABLE00.ID.EqInt(1)),
+	)
+	if err != nil {
+		t.Fatal(testutil.Callers(), err)
+	}
+	value, err := sq.FetchOne(sq.Log(db), sq.DuckDB.From(TABLE00).Where(TABLE00.ID.EqInt(1)), Table00{}.RowMapper)
+	if err != nil {
+		t.Fatal(testutil.Callers(), err)
+	}
+	if diff := testutil.Diff(value.textArray, []string{`"quoted"`, "comma, separated"}); diff != "" {
+		t.Error(testutil.Callers(), diff)
+	}
+	if diff := testutil.Diff(value.intArray, []int{}); diff != "" {
+		t.Error(testutil.Callers(), diff)
+	}
+}
+
+func TestFetchNext(t *testing.T) {
+	db := newDB(t)
+	ids, err := sq.FetchAll(sq.Log(db), sq.DuckDB.
+		From(TABLE00).
+		OrderBy(TABLE00.ID).
+		Offset(1).
+		FetchNext(1),
+		func(ctx context.Context, row *sq.Row) int {
+			return row.IntField(TABLE00.ID)
+		},
+	)
+	if err != nil {
+		t.Fatal(testutil.Callers(), err)
+	}
+	if diff := testutil.Diff(ids, []int{2}); diff != "" {
+		t.Error(testutil.Callers(), diff)
+	}
+}
+
+type statsLogger struct {
+	stats []sq.QueryStats
+}
+
+func (l *statsLogger) LogSettings(ctx context.Context, settings *sq.LogSettings) {}
+
+func (l *statsLogger) LogQuery(ctx context.Context, stats sq.QueryStats) {
+	l.stats = append(l.stats, stats)
+}
+
+func TestDialectDetection(t *testing.T) {
+	db := newDB(t)
+	logger := &statsLogger{}
+	_, err := sq.FetchExists(struct {
+		*sql.DB
+		sq.Logger
+	}{db, logger}, sq.SelectOne().From(TABLE00).Where(TABLE00.ID.EqInt(1)))
+	if err != nil {
+		t.Fatal(testutil.Callers(), err)
+	}
+	if diff := testutil.Diff(logger.stats[0].Dialect, sq.DialectDuckDB); diff != "" {
+		t.Error(testutil.Callers(), diff)
+	}
+	if diff := testutil.Diff(logger.stats[0].Query, "SELECT EXISTS (SELECT 1 FROM table00 WHERE table00.id = $1)"); diff != "" {
+		t.Error(testutil.Callers(), diff)
+	}
+}
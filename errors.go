@@ -0,0 +1,431 @@
+package sq
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+)
+
+// ErrorKind classifies an error returned by the database.
+type ErrorKind int
+
+// Error kinds.
+const (
+	UnknownError         ErrorKind = iota // not classified
+	UniqueViolation                       // unique or primary key constraint violated
+	ForeignKeyViolation                   // foreign key constraint violated
+	NotNullViolation                      // NULL written into a NOT NULL column
+	CheckViolation                        // check constraint violated
+	Deadlock                              // transaction chosen as a deadlock victim
+	SerializationFailure                  // transaction could not be serialized
+	LockTimeout                           // timed out waiting for a lock
+	ConnectionLost                        // connection to the database was lost
+)
+
+// String implements the fmt.Stringer interface.
+func (kind ErrorKind) String() string {
+	switch kind {
+	case UniqueViolation:
+		return "unique violation"
+	case ForeignKeyViolation:
+		return "foreign key violation"
+	case NotNullViolation:
+		return "not null violation"
+	case CheckViolation:
+		return "check violation"
+	case Deadlock:
+		return "deadlock"
+	case SerializationFailure:
+		return "serialization failure"
+	case LockTimeout:
+		return "lock timeout"
+	case ConnectionLost:
+		return "connection lost"
+	}
+	return "unknown error"
+}
+
+// DBError is a database error that has been classified into an ErrorKind.
+// Errors returned by the driver when running a query through sq are wrapped
+// in a DBError if they can be classified; the original driver error remains
+// accessible through errors.As or Unwrap.
+//
+// The pgx, lib/pq, go-sql-driver/mysql, mattn/go-sqlite3, modernc.org/sqlite
+// and go-mssqldb drivers are recognized. sq does not import any of them: their
+// errors are inspected by reflection.
+type DBError struct {
+	// Kind is the classification of the error.
+	Kind ErrorKind
+
+	// Constraint is the name of the violated constraint, if the database
+	// reported it.
+	Constraint string
+
+	// Table is the name of the table involved, if the database reported it.
+	Table string
+
+	// Column is the name of the column involved, if the database reported it.
+	Column string
+
+	// Err is the original driver error.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *DBError) Error() string { return e.Err.Error() }
+
+// Unwrap returns the original driver error.
+func (e *DBError) Unwrap() error { return e.Err }
+
+// AsDBError classifies err, returning the DBError it contains (or that it can
+// be classified as). It reports false if err cannot be classified.
+func AsDBError(err error) (*DBError, bool) {
+	if err == nil {
+		return nil, false
+	}
+	var dbErr *DBError
+	if errors.As(err, &dbErr) {
+		return dbErr, true
+	}
+	dbErr = classifyError(err)
+	return dbErr, dbErr != nil
+}
+
+// ErrorKindOf returns the ErrorKind of err, or UnknownError if it cannot be
+// classified.
+func ErrorKindOf(err error) ErrorKind {
+	dbErr, ok := AsDBError(err)
+	if !ok {
+		return UnknownError
+	}
+	return dbErr.Kind
+}
+
+// IsUniqueViolation reports whether err is a unique or primary key constraint
+// violation.
+func IsUniqueViolation(err error) bool { return ErrorKindOf(err) == UniqueViolation }
+
+// IsForeignKeyViolation reports whether err is a foreign key constraint
+// violation.
+func IsForeignKeyViolation(err error) bool { return ErrorKindOf(err) == ForeignKeyViolation }
+
+// IsNotNullViolation reports whether err is a NOT NULL constraint violation.
+func IsNotNullViolation(err error) bool { return ErrorKindOf(err) == NotNullViolation }
+
+// IsCheckViolation reports whether err is a check constraint violation.
+func IsCheckViolation(err error) bool { return ErrorKindOf(err) == CheckViolation }
+
+// IsDeadlock reports whether err is a deadlock.
+func IsDeadlock(err error) bool { return ErrorKindOf(err) == Deadlock }
+
+// IsSerializationFailure reports whether err is a serialization failure.
+func IsSerializationFailure(err error) bool { return ErrorKindOf(err) == SerializationFailure }
+
+// IsLockTimeout reports whether err is a lock timeout.
+func IsLockTimeout(err error) bool { return ErrorKindOf(err) == LockTimeout }
+
+// IsConnectionLost reports whether err is a lost connection.
+func IsConnectionLost(err error) bool { return ErrorKindOf(err) == ConnectionLost }
+
+// wrapDBError wraps err in a DBError if it can be classified, otherwise it
+// returns err unchanged.
+func wrapDBError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var dbErr *DBError
+	if errors.As(err, &dbErr) {
+		return err
+	}
+	if dbErr = classifyError(err); dbErr != nil {
+		return dbErr
+	}
+	return err
+}
+
+// classifyError walks the error chain of err looking for a driver error it
+// recognizes. It returns nil if there is none.
+func classifyError(err error) *DBError {
+	for _, e := range errorChain(err) {
+		var dbErr *DBError
+		switch {
+		case hasMethod[interface{ SQLState() string }](e):
+			dbErr = classifySQLState(e)
+		case hasMethod[interface{ SQLErrorNumber() int32 }](e):
+			dbErr = classifySQLServer(e)
+		case errorPkgPath(e) == "github.com/go-sql-driver/mysql":
+			dbErr = classifyMySQL(e)
+		case errorPkgPath(e) == "github.com/mattn/go-sqlite3":
+			if code := reflectField(e, "ExtendedCode"); code.CanInt() {
+				dbErr = classifySQLite(e, int(code.Int()))
+			}
+		case hasMethod[interface{ Code() int }](e) && errorPkgPath(e) == "modernc.org/sqlite":
+			dbErr = classifySQLite(e, e.(interface{ Code() int }).Code())
+		}
+		if dbErr != nil {
+			dbErr.Err = err
+			return dbErr
+		}
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) {
+		return &DBError{Kind: ConnectionLost, Err: err}
+	}
+	return nil
+}
+
+// errorChain returns err and every error it wraps, depth first.
+func errorChain(err error) []error {
+	var chain []error
+	stack := []error{err}
+	for len(stack) > 0 {
+		e := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if e == nil {
+			continue
+		}
+		chain = append(chain, e)
+		switch e := e.(type) {
+		case interface{ Unwrap() error }:
+			stack = append(stack, e.Unwrap())
+		case interface{ Unwrap() []error }:
+			errs := e.Unwrap()
+			for i := len(errs) - 1; i >= 0; i-- {
+				stack = append(stack, errs[i])
+			}
+		}
+	}
+	return chain
+}
+
+func hasMethod[T any](err error) bool {
+	_, ok := err.(T)
+	return ok
+}
+
+// errorPkgPath returns the package path of the (dereferenced) type of err.
+func errorPkgPath(err error) string {
+	typ := reflect.TypeOf(err)
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	return typ.PkgPath()
+}
+
+// reflectField returns the named field of the (dereferenced) struct err, or
+// the zero Value if there is no such field.
+func reflectField(err error, name string) reflect.Value {
+	value := reflect.ValueOf(err)
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return reflect.Value{}
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return reflect.Value{}
+	}
+	return value.FieldByName(name)
+}
+
+// reflectString returns the first of the named string fields of err that is
+// present.
+func reflectString(err error, names ...string) string {
+	for _, name := range names {
+		field := reflectField(err, name)
+		if field.IsValid() && field.Kind() == reflect.String {
+			return field.String()
+		}
+	}
+	return ""
+}
+
+// between returns the text in s between the first occurrence of start and the
+// next occurrence of end after it.
+func between(s, start, end string) string {
+	i := strings.Index(s, start)
+	if i < 0 {
+		return ""
+	}
+	s = s[i+len(start):]
+	j := strings.Index(s, end)
+	if j < 0 {
+		return ""
+	}
+	return s[:j]
+}
+
+// classifySQLState classifies errors that expose a Postgres SQLSTATE (pgx's
+// *pgconn.PgError and lib/pq's *pq.Error).
+//
+// https://www.postgresql.org/docs/current/errcodes-appendix.html
+func classifySQLState(err error) *DBError {
+	code := err.(interface{ SQLState() string }).SQLState()
+	dbErr := &DBError{
+		Constraint: reflectString(err, "ConstraintName", "Constraint"),
+		Table:      reflectString(err, "TableName", "Table"),
+		Column:     reflectString(err, "ColumnName", "Column"),
+	}
+	switch {
+	case code == "23505":
+		dbErr.Kind = UniqueViolation
+	case code == "23503":
+		dbErr.Kind = ForeignKeyViolation
+	case code == "23502":
+		dbErr.Kind = NotNullViolation
+	case code == "23514":
+		dbErr.Kind = CheckViolation
+	case code == "40P01":
+		dbErr.Kind = Deadlock
+	case code == "40001":
+		dbErr.Kind = SerializationFailure
+	case code == "55P03":
+		dbErr.Kind = LockTimeout
+	case strings.HasPrefix(code, "08"), code == "57P01", code == "57P02", code == "57P03":
+		dbErr.Kind = ConnectionLost
+	default:
+		return nil
+	}
+	return dbErr
+}
+
+// classifyMySQL classifies go-sql-driver/mysql's *mysql.MySQLError.
+//
+// https://dev.mysql.com/doc/mysql-errors/8.0/en/server-error-reference.html
+func classifyMySQL(err error) *DBError {
+	number := reflectField(err, "Number")
+	if !number.IsValid() || !number.CanUint() {
+		return nil
+	}
+	message := reflectString(err, "Message")
+	dbErr := &DBError{}
+	switch number.Uint() {
+	case 1062: // ER_DUP_ENTRY: Duplicate entry 'x' for key 'table.key'
+		dbErr.Kind = UniqueViolation
+		dbErr.Constraint = between(message, "for key '", "'")
+		if i := strings.LastIndex(dbErr.Constraint, "."); i >= 0 {
+			dbErr.Table, dbErr.Constraint = dbErr.Constraint[:i], dbErr.Constraint[i+1:]
+		}
+	case 1216, 1217, 1451, 1452: // ... a foreign key constraint fails (`db`.`table`, CONSTRAINT `fk` FOREIGN KEY (`column`) ...
+		dbErr.Kind = ForeignKeyViolation
+		dbErr.Table = between(message, "`.`", "`")
+		dbErr.Constraint = between(message, "CONSTRAINT `", "`")
+		dbErr.Column = between(message, "FOREIGN KEY (`", "`")
+	case 1048: // ER_BAD_NULL_ERROR: Column 'x' cannot be null
+		dbErr.Kind = NotNullViolation
+		dbErr.Column = between(message, "Column '", "'")
+	case 3819: // ER_CHECK_CONSTRAINT_VIOLATED: Check constraint 'x' is violated.
+		dbErr.Kind = CheckViolation
+		dbErr.Constraint = between(message, "constraint '", "'")
+	case 1213: // ER_LOCK_DEADLOCK
+		dbErr.Kind = Deadlock
+	case 1205, 3572: // ER_LOCK_WAIT_TIMEOUT, ER_LOCK_NOWAIT
+		dbErr.Kind = LockTimeout
+	case 1053, 1927: // ER_SERVER_SHUTDOWN, ER_CONNECTION_KILLED
+		dbErr.Kind = ConnectionLost
+	default:
+		return nil
+	}
+	return dbErr
+}
+
+// classifySQLServer classifies go-mssqldb's mssql.Error.
+//
+// https://learn.microsoft.com/en-us/sql/relational-databases/errors-events/database-engine-events-and-errors
+func classifySQLServer(err error) *DBError {
+	number := err.(interface{ SQLErrorNumber() int32 }).SQLErrorNumber()
+	var message string
+	if e, ok := err.(interface{ SQLErrorMessage() string }); ok {
+		message = e.SQLErrorMessage()
+	}
+	dbErr := &DBError{}
+	switch number {
+	case 2627: // Violation of UNIQUE KEY constraint 'x'. Cannot insert duplicate key in object 'dbo.t'. ...
+		dbErr.Kind = UniqueViolation
+		dbErr.Constraint = between(message, "constraint '", "'")
+		dbErr.Table = between(message, "object '", "'")
+	case 2601: // Cannot insert duplicate key row in object 'dbo.t' with unique index 'x'. ...
+		dbErr.Kind = UniqueViolation
+		dbErr.Constraint = between(message, "unique index '", "'")
+		dbErr.Table = between(message, "object '", "'")
+	case 547: // The INSERT statement conflicted with the FOREIGN KEY constraint "x". ... table "dbo.t", column 'c'.
+		if strings.Contains(message, "CHECK constraint") {
+			dbErr.Kind = CheckViolation
+		} else {
+			dbErr.Kind = ForeignKeyViolation
+		}
+		dbErr.Constraint = between(message, "constraint \"", "\"")
+		dbErr.Table = between(message, "table \"", "\"")
+		dbErr.Column = between(message, "column '", "'")
+	case 515: // Cannot insert the value NULL into column 'c', table 'db.dbo.t'; ...
+		dbErr.Kind = NotNullViolation
+		dbErr.Column = between(message, "column '", "'")
+		dbErr.Table = between(message, "table '", "'")
+	case 1205:
+		dbErr.Kind = Deadlock
+	case 3960: // snapshot isolation update conflict
+		dbErr.Kind = SerializationFailure
+	case 1222:
+		dbErr.Kind = LockTimeout
+	default:
+		return nil
+	}
+	return dbErr
+}
+
+// classifySQLite classifies SQLite errors by their extended result code.
+//
+// https://www.sqlite.org/rescode.html
+func classifySQLite(err error, extendedCode int) *DBError {
+	const (
+		sqliteBusy                 = 5
+		sqliteLocked               = 6
+		sqliteBusySnapshot         = 5 | 2<<8
+		sqliteConstraintCheck      = 19 | 1<<8
+		sqliteConstraintForeignKey = 19 | 3<<8
+		sqliteConstraintNotNull    = 19 | 5<<8
+		sqliteConstraintPrimaryKey = 19 | 6<<8
+		sqliteConstraintUnique     = 19 | 8<<8
+		sqliteConstraintRowID      = 19 | 10<<8
+	)
+	dbErr := &DBError{}
+	switch extendedCode {
+	case sqliteConstraintUnique, sqliteConstraintPrimaryKey, sqliteConstraintRowID:
+		dbErr.Kind = UniqueViolation
+	case sqliteConstraintForeignKey:
+		dbErr.Kind = ForeignKeyViolation
+	case sqliteConstraintNotNull:
+		dbErr.Kind = NotNullViolation
+	case sqliteConstraintCheck:
+		dbErr.Kind = CheckViolation
+	case sqliteBusySnapshot:
+		dbErr.Kind = SerializationFailure
+	default:
+		switch extendedCode & 0xff {
+		case sqliteBusy, sqliteLocked:
+			dbErr.Kind = LockTimeout
+		default:
+			return nil
+		}
+	}
+	// UNIQUE constraint failed: t.a, t.b
+	// NOT NULL constraint failed: t.a
+	// CHECK constraint failed: name
+	_, detail, ok := strings.Cut(err.Error(), "constraint failed: ")
+	if !ok {
+		return dbErr
+	}
+	if dbErr.Kind == CheckViolation {
+		dbErr.Constraint = detail
+		return dbErr
+	}
+	columns := strings.Split(detail, ", ")
+	if table, column, ok := strings.Cut(columns[0], "."); ok {
+		dbErr.Table = table
+		if len(columns) == 1 {
+			dbErr.Column = column
+		}
+	}
+	return dbErr
+}
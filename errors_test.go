@@ -0,0 +1,275 @@
+package sq
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/blink-io/sq/internal/testutil"
+	"github.com/go-sql-driver/mysql"
+	"github.com/mattn/go-sqlite3"
+)
+
+func TestDBError(t *testing.T) {
+	db, err := sql.Open("sqlite3", "file::memory:?_foreign_keys=true")
+	if err != nil {
+		t.Fatal(testutil.Callers(), err)
+	}
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+	for _, stmt := range []string{
+		`CREATE TABLE author (
+    author_id INTEGER PRIMARY KEY
+    ,email TEXT NOT NULL UNIQUE
+)`,
+		`CREATE TABLE book (
+    book_id INTEGER PRIMARY KEY
+    ,author_id INT REFERENCES author (author_id)
+    ,title TEXT NOT NULL
+    ,price INT CONSTRAINT book_price_check CHECK (price >= 0)
+)`,
+		`INSERT INTO author (author_id, email) VALUES (1, 'bob@example.com')`,
+	} {
+		_, err = db.Exec(stmt)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+	}
+	AUTHOR := New[struct {
+		TableStruct `sq:"author"`
+		AUTHOR_ID   NumberField
+		EMAIL       StringField
+	}]("")
+	BOOK := New[struct {
+		TableStruct `sq:"book"`
+		BOOK_ID     NumberField
+		AUTHOR_ID   NumberField
+		TITLE       StringField
+		PRICE       NumberField
+	}]("")
+
+	type TT struct {
+		description string
+		query       Query
+		kind        ErrorKind
+		is          func(error) bool
+		wantErr     DBError
+	}
+
+	tests := []TT{{
+		description: "unique",
+		query: SQLite.
+			InsertInto(AUTHOR).
+			Columns(AUTHOR.AUTHOR_ID, AUTHOR.EMAIL).
+			Values(2, "bob@example.com"),
+		kind:    UniqueViolation,
+		is:      IsUniqueViolation,
+		wantErr: DBError{Table: "author", Column: "email"},
+	}, {
+		description: "primary key",
+		query: SQLite.
+			InsertInto(AUTHOR).
+			Columns(AUTHOR.AUTHOR_ID, AUTHOR.EMAIL).
+			Values(1, "alice@example.com"),
+		kind:    UniqueViolation,
+		is:      IsUniqueViolation,
+		wantErr: DBError{Table: "author", Column: "author_id"},
+	}, {
+		description: "foreign key",
+		query: SQLite.
+			InsertInto(BOOK).
+			Columns(BOOK.AUTHOR_ID, BOOK.TITLE).
+			Values(99, "The Go Programming Language"),
+		kind: ForeignKeyViolation,
+		is:   IsForeignKeyViolation,
+	}, {
+		description: "not null",
+		query: SQLite.
+			InsertInto(BOOK).
+			Columns(BOOK.AUTHOR_ID, BOOK.TITLE).
+			Values(1, nil),
+		kind:    NotNullViolation,
+		is:      IsNotNullViolation,
+		wantErr: DBError{Table: "book", Column: "title"},
+	}, {
+		description: "check",
+		query: SQLite.
+			InsertInto(BOOK).
+			Columns(BOOK.AUTHOR_ID, BOOK.TITLE, BOOK.PRICE).
+			Values(1, "Learning Go", -1),
+		kind:    CheckViolation,
+		is:      IsCheckViolation,
+		wantErr: DBError{Constraint: "book_price_check"},
+	}}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.description, func(t *testing.T) {
+			assert := func(t *testing.T, err error) {
+				t.Helper()
+				var dbErr *DBError
+				if !errors.As(err, &dbErr) {
+					t.Fatalf(testutil.Callers()+" expected *DBError, got %#v", err)
+				}
+				if !tt.is(err) {
+					t.Error(testutil.Callers(), "Is helper reported false")
+				}
+				gotErr := DBError{Kind: dbErr.Kind, Constraint: dbErr.Constraint, Table: dbErr.Table, Column: dbErr.Column}
+				tt.wantErr.Kind = tt.kind
+				if diff := testutil.Diff(gotErr, tt.wantErr); diff != "" {
+					t.Error(testutil.Callers(), diff)
+				}
+				var sqliteErr sqlite3.Error
+				if !errors.As(err, &sqliteErr) {
+					t.Error(testutil.Callers(), "driver error not reachable through errors.As")
+				}
+			}
+			t.Run("Exec", func(t *testing.T) {
+				_, err := Exec(db, tt.query)
+				assert(t, err)
+			})
+			t.Run("FetchAll", func(t *testing.T) {
+				query := tt.query.(SQLiteInsertQuery).Returning(Expr("1"))
+				_, err := FetchAll(db, query, func(ctx context.Context, row *Row) int { return row.Int("1") })
+				assert(t, err)
+			})
+		})
+	}
+
+	t.Run("raw driver error", func(t *testing.T) {
+		_, err := db.Exec("INSERT INTO author (author_id, email) VALUES (3, 'bob@example.com')")
+		if !IsUniqueViolation(err) {
+			t.Errorf(testutil.Callers()+" expected unique violation, got %v", err)
+		}
+		if IsForeignKeyViolation(err) {
+			t.Error(testutil.Callers(), "unexpected foreign key violation")
+		}
+	})
+
+	t.Run("unclassified", func(t *testing.T) {
+		_, err := Exec(db, SQLite.Queryf("SELECT * FROM no_such_table"))
+		if err == nil {
+			t.Fatal(testutil.Callers(), "expected error")
+		}
+		var dbErr *DBError
+		if errors.As(err, &dbErr) {
+			t.Errorf(testutil.Callers()+" unexpected *DBError %#v", dbErr)
+		}
+		if kind := ErrorKindOf(err); kind != UnknownError {
+			t.Errorf(testutil.Callers()+" expected %s, got %s", UnknownError, kind)
+		}
+	})
+
+	t.Run("connection lost", func(t *testing.T) {
+		err := fmt.Errorf("query failed: %w", driver.ErrBadConn)
+		if !IsConnectionLost(err) {
+			t.Errorf(testutil.Callers()+" expected connection lost, got %v", ErrorKindOf(err))
+		}
+	})
+}
+
+type sqlStateError struct {
+	Code           string
+	ConstraintName string
+	TableName      string
+}
+
+func (e *sqlStateError) Error() string    { return "ERROR (SQLSTATE " + e.Code + ")" }
+func (e *sqlStateError) SQLState() string { return e.Code }
+
+type sqlServerError struct {
+	Number  int32
+	Message string
+}
+
+func (e sqlServerError) Error() string           { return e.Message }
+func (e sqlServerError) SQLErrorNumber() int32   { return e.Number }
+func (e sqlServerError) SQLErrorMessage() string { return e.Message }
+
+func Test_classifyError(t *testing.T) {
+	type TT struct {
+		description string
+		err         error
+		wantErr     *DBError
+	}
+
+	tests := []TT{{
+		description: "postgres unique",
+		err:         &sqlStateError{Code: "23505", ConstraintName: "actor_pkey", TableName: "actor"},
+		wantErr:     &DBError{Kind: UniqueViolation, Constraint: "actor_pkey", Table: "actor"},
+	}, {
+		description: "postgres serialization failure",
+		err:         &sqlStateError{Code: "40001"},
+		wantErr:     &DBError{Kind: SerializationFailure},
+	}, {
+		description: "postgres connection failure",
+		err:         &sqlStateError{Code: "08006"},
+		wantErr:     &DBError{Kind: ConnectionLost},
+	}, {
+		description: "postgres syntax error",
+		err:         &sqlStateError{Code: "42601"},
+	}, {
+		description: "sqlserver unique",
+		err:         sqlServerError{Number: 2627, Message: "Violation of UNIQUE KEY constraint 'UQ_actor_name'. Cannot insert duplicate key in object 'dbo.actor'. The duplicate key value is (bob)."},
+		wantErr:     &DBError{Kind: UniqueViolation, Constraint: "UQ_actor_name", Table: "dbo.actor"},
+	}, {
+		description: "sqlserver foreign key",
+		err:         sqlServerError{Number: 547, Message: `The INSERT statement conflicted with the FOREIGN KEY constraint "FK_film_language". The conflict occurred in database "sakila", table "dbo.language", column 'language_id'.`},
+		wantErr:     &DBError{Kind: ForeignKeyViolation, Constraint: "FK_film_language", Table: "dbo.language", Column: "language_id"},
+	}, {
+		description: "sqlserver check",
+		err:         sqlServerError{Number: 547, Message: `The INSERT statement conflicted with the CHECK constraint "CK_film_rating". The conflict occurred in database "sakila", table "dbo.film", column 'rating'.`},
+		wantErr:     &DBError{Kind: CheckViolation, Constraint: "CK_film_rating", Table: "dbo.film", Column: "rating"},
+	}, {
+		description: "sqlserver deadlock",
+		err:         fmt.Errorf("wrapped: %w", sqlServerError{Number: 1205}),
+		wantErr:     &DBError{Kind: Deadlock},
+	}, {
+		description: "mysql unique",
+		err:         &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'bob' for key 'actor.actor_name_key'"},
+		wantErr:     &DBError{Kind: UniqueViolation, Constraint: "actor_name_key", Table: "actor"},
+	}, {
+		description: "mysql foreign key",
+		err:         &mysql.MySQLError{Number: 1452, Message: "Cannot add or update a child row: a foreign key constraint fails (`sakila`.`film`, CONSTRAINT `film_language_id_fkey` FOREIGN KEY (`language_id`) REFERENCES `language` (`language_id`))"},
+		wantErr:     &DBError{Kind: ForeignKeyViolation, Constraint: "film_language_id_fkey", Table: "film", Column: "language_id"},
+	}, {
+		description: "mysql not null",
+		err:         &mysql.MySQLError{Number: 1048, Message: "Column 'first_name' cannot be null"},
+		wantErr:     &DBError{Kind: NotNullViolation, Column: "first_name"},
+	}, {
+		description: "mysql lock wait timeout",
+		err:         &mysql.MySQLError{Number: 1205, Message: "Lock wait timeout exceeded; try restarting transaction"},
+		wantErr:     &DBError{Kind: LockTimeout},
+	}, {
+		description: "sqlite busy",
+		err:         sqlite3.Error{Code: sqlite3.ErrBusy, ExtendedCode: sqlite3.ErrNoExtended(sqlite3.ErrBusy)},
+		wantErr:     &DBError{Kind: LockTimeout},
+	}}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.description, func(t *testing.T) {
+			t.Parallel()
+			gotErr := classifyError(tt.err)
+			if tt.wantErr == nil {
+				if gotErr != nil {
+					t.Errorf(testutil.Callers()+" expected nil, got %#v", gotErr)
+				}
+				return
+			}
+			if gotErr == nil {
+				t.Fatal(testutil.Callers(), "expected *DBError, got nil")
+			}
+			if gotErr.Err != tt.err {
+				t.Error(testutil.Callers(), "original error not preserved")
+			}
+			gotErr.Err = nil
+			if diff := testutil.Diff(gotErr, tt.wantErr); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,808 @@
+package sq
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ExplainFormat is the format in which EXPLAIN is asked to output the plan.
+type ExplainFormat string
+
+const (
+	// ExplainDefault is the format that Explain parses into a tree of
+	// PlanNodes: JSON for Postgres, MySQL and DuckDB, XML for SQL Server and
+	// the rows of EXPLAIN QUERY PLAN or the plan table for SQLite and
+	// Oracle.
+	ExplainDefault ExplainFormat = ""
+
+	ExplainText ExplainFormat = "text"
+	ExplainJSON ExplainFormat = "json"
+	ExplainXML  ExplainFormat = "xml"
+)
+
+// ExplainOptions are the options of Explain.
+type ExplainOptions struct {
+	// Analyze runs the query to report the actual number of rows of each
+	// node alongside the estimates (EXPLAIN ANALYZE, or SET STATISTICS XML
+	// for SQL Server). Beware that the query is executed, including any
+	// data it modifies. SQLite and Oracle do not support it.
+	Analyze bool
+
+	// Format is the format of the plan. Only plans in the ExplainDefault
+	// format (or the format it stands for) are parsed into PlanNodes, the
+	// others are only available as Plan.Raw.
+	Format ExplainFormat
+}
+
+// Plan is the query plan of a query, as reported by Explain.
+type Plan struct {
+	// Dialect is the dialect of the query.
+	Dialect string
+
+	// Raw is the output of EXPLAIN, in the requested format.
+	Raw string
+
+	// Nodes are the top-level nodes of the plan, if it could be parsed.
+	Nodes []*PlanNode
+}
+
+// PlanNode is a node of a query plan. Fields that the database does not
+// report are left empty.
+type PlanNode struct {
+	// Type is the operation of the node, such as "Seq Scan" or "Index Scan"
+	// for Postgres, the access type ("ALL", "ref", ...) for MySQL and
+	// "SCAN" or "SEARCH" for SQLite.
+	Type string
+
+	// Table is the table read by the node.
+	Table string
+
+	// Index is the index used by the node. For SQLite rowid lookups it is
+	// "INTEGER PRIMARY KEY".
+	Index string
+
+	// EstimatedRows is the number of rows the node is estimated to return.
+	EstimatedRows float64
+
+	// Cost is the estimated (total) cost of the node, in the units of the
+	// database.
+	Cost float64
+
+	// ActualRows is the number of rows the node returned, if the plan was
+	// obtained with ExplainOptions.Analyze.
+	ActualRows sql.NullFloat64
+
+	// Detail is the description of the node given by the database, if any.
+	Detail string
+
+	Children []*PlanNode
+}
+
+// Explain returns the query plan of query on db. The query is rendered with
+// WriteSQL and wrapped in the EXPLAIN form of its dialect:
+//
+//   - Postgres: EXPLAIN (FORMAT JSON)
+//   - MySQL: EXPLAIN FORMAT=JSON
+//   - SQLite: EXPLAIN QUERY PLAN
+//   - SQL Server: SET SHOWPLAN_XML ON
+//   - DuckDB: EXPLAIN (FORMAT JSON)
+//   - Oracle: EXPLAIN PLAN FOR, read from the plan table
+//
+// SQL Server and Oracle need the session to be set up first, so db must
+// provide a dedicated connection with a Conn method, as *sql.DB does.
+//
+// Explain makes it possible to assert how a query is run:
+//
+//	plan, err := sq.Explain(ctx, db, query, sq.ExplainOptions{})
+//	if !plan.UsesIndex("actor_last_name_idx") {
+//		t.Error("expected the query to use actor_last_name_idx")
+//	}
+func Explain(ctx context.Context, db DB, query Query, opts ExplainOptions) (*Plan, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db is nil")
+	}
+	if query == nil {
+		return nil, fmt.Errorf("query is nil")
+	}
+	dialect := queryDialect(db, query)
+	buf := bufPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer bufPool.Put(buf)
+	var args []any
+	err := query.WriteSQL(ctx, dialect, buf, &args, make(map[string][]int))
+	if err != nil {
+		return nil, err
+	}
+	return explain(ctx, db, dialect, buf.String(), args, opts)
+}
+
+// explainQuery returns the raw plan of query and args in the default format
+// of dialect.
+func explainQuery(ctx context.Context, db DB, dialect string, query string, args []any) (string, error) {
+	plan, err := explain(ctx, db, dialect, query, args, ExplainOptions{})
+	if err != nil {
+		return "", err
+	}
+	return plan.Raw, nil
+}
+
+// explain runs the EXPLAIN of dialect for query and args on db.
+func explain(ctx context.Context, db DB, dialect string, query string, args []any, opts ExplainOptions) (plan *Plan, err error) {
+	plan = &Plan{Dialect: dialect}
+	format := opts.Format
+	unsupported := func() (*Plan, error) {
+		if opts.Analyze {
+			return nil, fmt.Errorf("sq: cannot EXPLAIN ANALYZE in format %q in dialect %q", format, dialect)
+		}
+		return nil, fmt.Errorf("sq: cannot EXPLAIN in format %q in dialect %q", format, dialect)
+	}
+	switch dialect {
+	case DialectPostgres:
+		if format == ExplainDefault {
+			format = ExplainJSON
+		}
+		options := "FORMAT " + strings.ToUpper(string(format))
+		if opts.Analyze {
+			options = "ANALYZE, " + options
+		}
+		plan.Raw, err = explainRows(ctx, db, "EXPLAIN ("+options+") "+query, args, false)
+		if err != nil || format != ExplainJSON {
+			return plan, err
+		}
+		plan.Nodes, err = parsePostgresPlan(plan.Raw)
+		return plan, err
+	case DialectMySQL:
+		if opts.Analyze {
+			// EXPLAIN ANALYZE only supports the TREE format.
+			if format != ExplainDefault && format != ExplainText {
+				return unsupported()
+			}
+			plan.Raw, err = explainRows(ctx, db, "EXPLAIN ANALYZE "+query, args, false)
+			return plan, err
+		}
+		switch format {
+		case ExplainDefault, ExplainJSON:
+			plan.Raw, err = explainRows(ctx, db, "EXPLAIN FORMAT=JSON "+query, args, false)
+			if err != nil {
+				return plan, err
+			}
+			plan.Nodes, err = parseMySQLPlan(plan.Raw)
+			return plan, err
+		case ExplainText:
+			plan.Raw, err = explainRows(ctx, db, "EXPLAIN FORMAT=TREE "+query, args, false)
+			return plan, err
+		}
+		return unsupported()
+	case DialectSQLite:
+		if opts.Analyze || (format != ExplainDefault && format != ExplainText) {
+			return unsupported()
+		}
+		planRows, err := sqliteQueryPlan(ctx, db, query, args)
+		if err != nil {
+			return plan, err
+		}
+		plan.Raw, plan.Nodes = sqlitePlan(planRows)
+		return plan, nil
+	case DialectDuckDB:
+		stmt := "EXPLAIN "
+		switch format {
+		case ExplainDefault, ExplainJSON:
+			format = ExplainJSON
+			if opts.Analyze {
+				stmt = "EXPLAIN (ANALYZE, FORMAT JSON) "
+			} else {
+				stmt = "EXPLAIN (FORMAT JSON) "
+			}
+		case ExplainText:
+			if opts.Analyze {
+				stmt = "EXPLAIN ANALYZE "
+			}
+		default:
+			return unsupported()
+		}
+		// The plan is in the explain_value column.
+		plan.Raw, err = explainRows(ctx, db, stmt+query, args, true)
+		if err != nil || format != ExplainJSON {
+			return plan, err
+		}
+		plan.Nodes, err = parseDuckDBPlan(plan.Raw)
+		return plan, err
+	case DialectSQLServer:
+		var setting string
+		switch format {
+		case ExplainDefault, ExplainXML:
+			format, setting = ExplainXML, "SHOWPLAN_XML"
+			if opts.Analyze {
+				setting = "STATISTICS XML"
+			}
+		case ExplainText:
+			if opts.Analyze {
+				return unsupported()
+			}
+			setting = "SHOWPLAN_TEXT"
+		default:
+			return unsupported()
+		}
+		conn, err := dbConn(ctx, db)
+		if err != nil {
+			return plan, err
+		}
+		defer conn.Close()
+		_, err = conn.ExecContext(ctx, "SET "+setting+" ON")
+		if err != nil {
+			return plan, err
+		}
+		// The setting applies to the session, so it must be turned off
+		// before the connection is returned to the pool.
+		defer conn.ExecContext(context.WithoutCancel(ctx), "SET "+setting+" OFF")
+		plan.Raw, err = explainLastResultSet(ctx, conn, query, args)
+		if err != nil || format != ExplainXML {
+			return plan, err
+		}
+		plan.Nodes, err = parseSQLServerPlan(plan.Raw)
+		return plan, err
+	case DialectOracle:
+		if opts.Analyze || (format != ExplainDefault && format != ExplainText) {
+			return unsupported()
+		}
+		conn, err := dbConn(ctx, db)
+		if err != nil {
+			return plan, err
+		}
+		defer conn.Close()
+		const statementID = "sq_explain"
+		_, err = conn.ExecContext(ctx, "EXPLAIN PLAN SET STATEMENT_ID = '"+statementID+"' FOR "+query, args...)
+		if err != nil {
+			return plan, err
+		}
+		defer conn.ExecContext(context.WithoutCancel(ctx), "DELETE FROM plan_table WHERE statement_id = '"+statementID+"'")
+		plan.Raw, err = explainRows(ctx, conn, "SELECT plan_table_output FROM TABLE(DBMS_XPLAN.DISPLAY('PLAN_TABLE', '"+statementID+"'))", nil, false)
+		if err != nil || format == ExplainText {
+			return plan, err
+		}
+		plan.Nodes, err = oraclePlan(ctx, conn, statementID)
+		return plan, err
+	}
+	return nil, fmt.Errorf("sq: cannot EXPLAIN queries in dialect %q", dialect)
+}
+
+// Find returns the first node of the plan (in depth-first order) for which
+// fn returns true, or nil if there is none.
+func (plan *Plan) Find(fn func(node *PlanNode) bool) *PlanNode {
+	var find func(nodes []*PlanNode) *PlanNode
+	find = func(nodes []*PlanNode) *PlanNode {
+		for _, node := range nodes {
+			if fn(node) {
+				return node
+			}
+			if found := find(node.Children); found != nil {
+				return found
+			}
+		}
+		return nil
+	}
+	return find(plan.Nodes)
+}
+
+// UsesIndex reports whether a node of the plan uses the given index (case
+// insensitively), or any index if index is empty.
+func (plan *Plan) UsesIndex(index string) bool {
+	return plan.Find(func(node *PlanNode) bool {
+		if index == "" {
+			return node.Index != ""
+		}
+		return strings.EqualFold(node.Index, index)
+	}) != nil
+}
+
+// String returns the plan as an indented tree, or the raw plan if it was not
+// parsed.
+func (plan *Plan) String() string {
+	if len(plan.Nodes) == 0 {
+		return plan.Raw
+	}
+	var b strings.Builder
+	var write func(nodes []*PlanNode, depth int)
+	write = func(nodes []*PlanNode, depth int) {
+		for _, node := range nodes {
+			if b.Len() > 0 {
+				b.WriteString("\n")
+			}
+			if depth > 0 {
+				b.WriteString(strings.Repeat("  ", depth-1) + "-> ")
+			}
+			b.WriteString(node.String())
+			write(node.Children, depth+1)
+		}
+	}
+	write(plan.Nodes, 0)
+	return b.String()
+}
+
+// String returns a one-line description of the node.
+func (node *PlanNode) String() string {
+	if node.Detail != "" {
+		return node.Detail
+	}
+	var b strings.Builder
+	b.WriteString(node.Type)
+	if node.Table != "" {
+		b.WriteString(" on " + node.Table)
+	}
+	if node.Index != "" {
+		b.WriteString(" using " + node.Index)
+	}
+	if node.EstimatedRows != 0 || node.Cost != 0 {
+		b.WriteString(" (rows=" + strconv.FormatFloat(node.EstimatedRows, 'f', -1, 64) + " cost=" + strconv.FormatFloat(node.Cost, 'f', -1, 64) + ")")
+	}
+	if node.ActualRows.Valid {
+		b.WriteString(" (actual rows=" + strconv.FormatFloat(node.ActualRows.Float64, 'f', -1, 64) + ")")
+	}
+	return b.String()
+}
+
+// dbConn returns a dedicated connection of db, looking through DB wrappers
+// that expose the DB they wrap with an Unwrap() DB method.
+func dbConn(ctx context.Context, db DB) (*sql.Conn, error) {
+	for inner := db; inner != nil; {
+		if conner, ok := inner.(interface {
+			Conn(context.Context) (*sql.Conn, error)
+		}); ok {
+			return conner.Conn(ctx)
+		}
+		unwrapper, ok := inner.(interface{ Unwrap() DB })
+		if !ok {
+			break
+		}
+		inner = unwrapper.Unwrap()
+	}
+	return nil, fmt.Errorf("sq: %T does not provide a dedicated connection", db)
+}
+
+type queryer interface {
+	QueryContext(context.Context, string, ...any) (*sql.Rows, error)
+}
+
+// explainRows runs query and returns its rows as lines, with the columns of
+// each row separated by spaces (or only the last column of each row, if
+// lastColumn is true).
+func explainRows(ctx context.Context, db queryer, query string, args []any, lastColumn bool) (string, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+	values := make([]sql.NullString, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	var b strings.Builder
+	for rows.Next() {
+		err = rows.Scan(dest...)
+		if err != nil {
+			return "", err
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		if lastColumn {
+			b.WriteString(values[len(values)-1].String)
+			continue
+		}
+		for i, value := range values {
+			if i > 0 {
+				b.WriteString(" ")
+			}
+			b.WriteString(value.String)
+		}
+	}
+	return b.String(), rows.Err()
+}
+
+// explainLastResultSet runs query and returns the first column of the rows
+// of its last result set, which is where SQL Server puts the plan when SET
+// STATISTICS XML is on.
+func explainLastResultSet(ctx context.Context, db queryer, query string, args []any) (string, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+	var lines []string
+	for {
+		lines = lines[:0]
+		columns, err := rows.Columns()
+		if err != nil {
+			return "", err
+		}
+		values := make([]any, len(columns))
+		for rows.Next() {
+			var line sql.NullString
+			values[0] = &line
+			for i := 1; i < len(values); i++ {
+				values[i] = new(any)
+			}
+			err = rows.Scan(values...)
+			if err != nil {
+				return "", err
+			}
+			lines = append(lines, line.String)
+		}
+		if !rows.NextResultSet() {
+			break
+		}
+	}
+	return strings.Join(lines, "\n"), rows.Err()
+}
+
+// sqliteQueryPlanRow is a row of SQLite's EXPLAIN QUERY PLAN.
+type sqliteQueryPlanRow struct {
+	id, parent int
+	detail     string
+}
+
+// sqliteQueryPlan runs EXPLAIN QUERY PLAN for query and args on db.
+func sqliteQueryPlan(ctx context.Context, db DB, query string, args []any) ([]sqliteQueryPlanRow, error) {
+	rows, err := db.QueryContext(ctx, "EXPLAIN QUERY PLAN "+query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var planRows []sqliteQueryPlanRow
+	for rows.Next() {
+		var planRow sqliteQueryPlanRow
+		var notused int
+		err = rows.Scan(&planRow.id, &planRow.parent, &notused, &planRow.detail)
+		if err != nil {
+			return nil, err
+		}
+		planRows = append(planRows, planRow)
+	}
+	return planRows, rows.Err()
+}
+
+// sqlitePlan returns the rows of EXPLAIN QUERY PLAN as an indented tree, like
+// the .eqp mode of the sqlite3 shell, and as PlanNodes.
+func sqlitePlan(planRows []sqliteQueryPlanRow) (raw string, nodes []*PlanNode) {
+	type entry struct {
+		node  *PlanNode
+		depth int
+	}
+	entries := make(map[int]entry)
+	var b strings.Builder
+	b.WriteString("QUERY PLAN")
+	for _, planRow := range planRows {
+		node := parseSQLitePlanDetail(planRow.detail)
+		depth := 0
+		if parent, ok := entries[planRow.parent]; ok {
+			depth = parent.depth + 1
+			parent.node.Children = append(parent.node.Children, node)
+		} else {
+			nodes = append(nodes, node)
+		}
+		entries[planRow.id] = entry{node: node, depth: depth}
+		b.WriteString("\n" + strings.Repeat("   ", depth) + "|--" + planRow.detail)
+	}
+	return b.String(), nodes
+}
+
+// parseSQLitePlanDetail parses the detail of a row of EXPLAIN QUERY PLAN,
+// such as "SEARCH actor USING INDEX actor_last_name_idx (last_name=?)".
+func parseSQLitePlanDetail(detail string) *PlanNode {
+	node := &PlanNode{Type: detail, Detail: detail}
+	for _, typ := range []string{"SCAN", "SEARCH"} {
+		rest, ok := strings.CutPrefix(detail, typ+" ")
+		if !ok {
+			continue
+		}
+		node.Type = typ
+		// SQLite versions before 3.36 print "SCAN TABLE actor".
+		rest = strings.TrimPrefix(rest, "TABLE ")
+		node.Table, rest, _ = strings.Cut(rest, " ")
+		if alias, ok := strings.CutPrefix(rest, "AS "); ok {
+			_, rest, _ = strings.Cut(alias, " ")
+		}
+		using, ok := strings.CutPrefix(rest, "USING ")
+		if !ok {
+			break
+		}
+		switch {
+		case strings.HasPrefix(using, "INTEGER PRIMARY KEY"):
+			node.Index = "INTEGER PRIMARY KEY"
+		case strings.HasPrefix(using, "PRIMARY KEY"):
+			node.Index = "PRIMARY KEY"
+		default:
+			using = strings.TrimPrefix(using, "COVERING ")
+			if index, ok := strings.CutPrefix(using, "INDEX "); ok {
+				node.Index, _, _ = strings.Cut(index, " ")
+			}
+		}
+		break
+	}
+	return node
+}
+
+// parsePostgresPlan parses the output of EXPLAIN (FORMAT JSON).
+func parsePostgresPlan(raw string) ([]*PlanNode, error) {
+	type postgresPlanNode struct {
+		NodeType     string            `json:"Node Type"`
+		RelationName string            `json:"Relation Name"`
+		IndexName    string            `json:"Index Name"`
+		PlanRows     float64           `json:"Plan Rows"`
+		TotalCost    float64           `json:"Total Cost"`
+		ActualRows   *float64          `json:"Actual Rows"`
+		Plans        []json.RawMessage `json:"Plans"`
+	}
+	var convert func(data []byte) (*PlanNode, error)
+	convert = func(data []byte) (*PlanNode, error) {
+		var planNode postgresPlanNode
+		err := json.Unmarshal(data, &planNode)
+		if err != nil {
+			return nil, err
+		}
+		node := &PlanNode{
+			Type:          planNode.NodeType,
+			Table:         planNode.RelationName,
+			Index:         planNode.IndexName,
+			EstimatedRows: planNode.PlanRows,
+			Cost:          planNode.TotalCost,
+		}
+		if planNode.ActualRows != nil {
+			node.ActualRows = sql.NullFloat64{Float64: *planNode.ActualRows, Valid: true}
+		}
+		for _, data := range planNode.Plans {
+			child, err := convert(data)
+			if err != nil {
+				return nil, err
+			}
+			node.Children = append(node.Children, child)
+		}
+		return node, nil
+	}
+	var output []struct {
+		Plan json.RawMessage `json:"Plan"`
+	}
+	err := json.Unmarshal([]byte(raw), &output)
+	if err != nil {
+		return nil, fmt.Errorf("sq: parsing Postgres plan: %w", err)
+	}
+	var nodes []*PlanNode
+	for _, item := range output {
+		node, err := convert(item.Plan)
+		if err != nil {
+			return nil, fmt.Errorf("sq: parsing Postgres plan: %w", err)
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+// parseMySQLPlan parses the output of EXPLAIN FORMAT=JSON. Query blocks and
+// operations (ordering_operation, grouping_operation, ...) become nodes of
+// their own, with the tables they read as children.
+func parseMySQLPlan(raw string) ([]*PlanNode, error) {
+	var output map[string]any
+	err := json.Unmarshal([]byte(raw), &output)
+	if err != nil {
+		return nil, fmt.Errorf("sq: parsing MySQL plan: %w", err)
+	}
+	root := &PlanNode{}
+	var walk func(object map[string]any, parent *PlanNode)
+	walk = func(object map[string]any, parent *PlanNode) {
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			switch value := object[key].(type) {
+			case map[string]any:
+				node := parent
+				if key == "table" {
+					node = &PlanNode{
+						Type:          jsonString(value["access_type"]),
+						Table:         jsonString(value["table_name"]),
+						Index:         jsonString(value["key"]),
+						EstimatedRows: jsonFloat(value["rows_produced_per_join"]),
+					}
+					if node.EstimatedRows == 0 {
+						node.EstimatedRows = jsonFloat(value["rows_examined_per_scan"])
+					}
+					if costInfo, ok := value["cost_info"].(map[string]any); ok {
+						node.Cost = jsonFloat(costInfo["prefix_cost"])
+					}
+					parent.Children = append(parent.Children, node)
+				} else if key == "query_block" || strings.HasSuffix(key, "_operation") || key == "union_result" {
+					node = &PlanNode{Type: key}
+					if costInfo, ok := value["cost_info"].(map[string]any); ok {
+						node.Cost = jsonFloat(costInfo["query_cost"])
+						if node.Cost == 0 {
+							node.Cost = jsonFloat(costInfo["sort_cost"])
+						}
+					}
+					parent.Children = append(parent.Children, node)
+				}
+				walk(value, node)
+			case []any:
+				for _, item := range value {
+					if item, ok := item.(map[string]any); ok {
+						walk(item, parent)
+					}
+				}
+			}
+		}
+	}
+	walk(output, root)
+	return root.Children, nil
+}
+
+// parseDuckDBPlan parses the output of EXPLAIN (FORMAT JSON), with or
+// without ANALYZE.
+func parseDuckDBPlan(raw string) ([]*PlanNode, error) {
+	var convert func(object map[string]any) *PlanNode
+	convert = func(object map[string]any) *PlanNode {
+		node := &PlanNode{Type: strings.TrimSpace(jsonString(object["name"]))}
+		if node.Type == "" {
+			node.Type = strings.TrimSpace(jsonString(object["operator_type"]))
+		}
+		if extraInfo, ok := object["extra_info"].(map[string]any); ok {
+			node.Table = jsonString(extraInfo["Table"])
+			if node.Table == "" && strings.HasSuffix(node.Type, "SCAN") {
+				node.Table = jsonString(extraInfo["Text"])
+			}
+			node.EstimatedRows = jsonFloat(extraInfo["Estimated Cardinality"])
+		}
+		if cardinality, ok := object["operator_cardinality"]; ok {
+			node.ActualRows = sql.NullFloat64{Float64: jsonFloat(cardinality), Valid: true}
+		}
+		children, _ := object["children"].([]any)
+		for _, child := range children {
+			if child, ok := child.(map[string]any); ok {
+				node.Children = append(node.Children, convert(child))
+			}
+		}
+		return node
+	}
+	var output any
+	err := json.Unmarshal([]byte(raw), &output)
+	if err != nil {
+		return nil, fmt.Errorf("sq: parsing DuckDB plan: %w", err)
+	}
+	var nodes []*PlanNode
+	switch output := output.(type) {
+	case []any:
+		for _, item := range output {
+			if item, ok := item.(map[string]any); ok {
+				nodes = append(nodes, convert(item))
+			}
+		}
+	case map[string]any:
+		// The analyzed plan is a profiling tree whose root is the query.
+		nodes = convert(output).Children
+	}
+	return nodes, nil
+}
+
+// parseSQLServerPlan parses an XML showplan.
+func parseSQLServerPlan(raw string) ([]*PlanNode, error) {
+	var nodes, stack []*PlanNode
+	decoder := xml.NewDecoder(strings.NewReader(raw))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("sq: parsing SQL Server plan: %w", err)
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			attrs := make(map[string]string, len(token.Attr))
+			for _, attr := range token.Attr {
+				attrs[attr.Name.Local] = attr.Value
+			}
+			switch token.Name.Local {
+			case "RelOp":
+				node := &PlanNode{Type: attrs["PhysicalOp"]}
+				node.EstimatedRows, _ = strconv.ParseFloat(attrs["EstimateRows"], 64)
+				node.Cost, _ = strconv.ParseFloat(attrs["EstimatedTotalSubtreeCost"], 64)
+				if len(stack) > 0 {
+					parent := stack[len(stack)-1]
+					parent.Children = append(parent.Children, node)
+				} else {
+					nodes = append(nodes, node)
+				}
+				stack = append(stack, node)
+			case "Object":
+				if len(stack) > 0 && stack[len(stack)-1].Table == "" {
+					node := stack[len(stack)-1]
+					node.Table = strings.Trim(attrs["Table"], "[]")
+					node.Index = strings.Trim(attrs["Index"], "[]")
+				}
+			case "RunTimeCountersPerThread":
+				if len(stack) > 0 {
+					node := stack[len(stack)-1]
+					actualRows, _ := strconv.ParseFloat(attrs["ActualRows"], 64)
+					node.ActualRows = sql.NullFloat64{Float64: node.ActualRows.Float64 + actualRows, Valid: true}
+				}
+			}
+		case xml.EndElement:
+			if token.Name.Local == "RelOp" && len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+	return nodes, nil
+}
+
+// oraclePlan reads the plan of statementID from the plan table.
+func oraclePlan(ctx context.Context, db queryer, statementID string) ([]*PlanNode, error) {
+	rows, err := db.QueryContext(ctx, "SELECT id, parent_id, operation, options, object_name, cardinality, cost"+
+		" FROM plan_table WHERE statement_id = '"+statementID+"' ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var nodes []*PlanNode
+	nodesByID := make(map[int64]*PlanNode)
+	for rows.Next() {
+		var id int64
+		var parentID sql.NullInt64
+		var operation string
+		var options, objectName sql.NullString
+		var cardinality, cost sql.NullFloat64
+		err = rows.Scan(&id, &parentID, &operation, &options, &objectName, &cardinality, &cost)
+		if err != nil {
+			return nil, err
+		}
+		node := &PlanNode{
+			Type:          strings.TrimSpace(operation + " " + options.String),
+			EstimatedRows: cardinality.Float64,
+			Cost:          cost.Float64,
+		}
+		if strings.HasPrefix(operation, "INDEX") {
+			node.Index = objectName.String
+		} else {
+			node.Table = objectName.String
+		}
+		if parent, ok := nodesByID[parentID.Int64]; ok && parentID.Valid {
+			parent.Children = append(parent.Children, node)
+		} else {
+			nodes = append(nodes, node)
+		}
+		nodesByID[id] = node
+	}
+	return nodes, rows.Err()
+}
+
+// jsonString returns value if it is a string.
+func jsonString(value any) string {
+	s, _ := value.(string)
+	return s
+}
+
+// jsonFloat returns value as a float64 if it is a number or a numeric
+// string.
+func jsonFloat(value any) float64 {
+	switch value := value.(type) {
+	case float64:
+		return value
+	case string:
+		f, _ := strconv.ParseFloat(value, 64)
+		return f
+	}
+	return 0
+}
@@ -0,0 +1,144 @@
+package sq
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/blink-io/sq/internal/testutil"
+)
+
+func TestExplain(t *testing.T) {
+	ctx := context.Background()
+	db := newActorDB(t)
+	_, err := db.Exec(`CREATE INDEX actor_last_name_idx ON actor (last_name)`)
+	if err != nil {
+		t.Fatal(testutil.Callers(), err)
+	}
+
+	t.Run("index", func(t *testing.T) {
+		plan, err := Explain(ctx, db, SQLite.From(ACTOR).Where(ACTOR.LAST_NAME.EqString("CHASE")).Select(ACTOR.ACTOR_ID), ExplainOptions{})
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if !plan.UsesIndex("actor_last_name_idx") {
+			t.Errorf(testutil.Callers()+" expected the plan to use actor_last_name_idx:\n%s", plan)
+		}
+		node := plan.Find(func(node *PlanNode) bool { return node.Table == "actor" })
+		if node == nil || node.Type != "SEARCH" {
+			t.Errorf(testutil.Callers()+" expected a SEARCH of actor:\n%s", plan)
+		}
+	})
+
+	t.Run("scan", func(t *testing.T) {
+		plan, err := Explain(ctx, db, SQLite.From(ACTOR).Where(ACTOR.FIRST_NAME.EqString("NICK")).Select(ACTOR.FIRST_NAME), ExplainOptions{})
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if plan.UsesIndex("") {
+			t.Errorf(testutil.Callers()+" expected the plan not to use an index:\n%s", plan)
+		}
+		if diff := testutil.Diff(plan.Nodes, []*PlanNode{{Type: "SCAN", Table: "actor", Detail: "SCAN actor"}}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		if diff := testutil.Diff(plan.Raw, "QUERY PLAN\n|--SCAN actor"); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("primary key", func(t *testing.T) {
+		plan, err := Explain(ctx, db, SQLite.From(ACTOR).Where(ACTOR.ACTOR_ID.EqInt(1)).Select(ACTOR.FIRST_NAME), ExplainOptions{})
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if !plan.UsesIndex("INTEGER PRIMARY KEY") {
+			t.Errorf(testutil.Callers()+" expected a rowid lookup:\n%s", plan)
+		}
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		_, err := Explain(ctx, db, SQLite.From(ACTOR), ExplainOptions{Analyze: true})
+		if err == nil {
+			t.Error(testutil.Callers(), "expected an error")
+		}
+		_, err = Explain(ctx, db, SQLite.From(ACTOR), ExplainOptions{Format: ExplainJSON})
+		if err == nil {
+			t.Error(testutil.Callers(), "expected an error")
+		}
+	})
+}
+
+func TestParsePlan(t *testing.T) {
+	t.Run("sqlite", func(t *testing.T) {
+		for detail, want := range map[string]PlanNode{
+			"SCAN TABLE actor": {Type: "SCAN", Table: "actor"},
+			"SCAN a":           {Type: "SCAN", Table: "a"},
+			"SEARCH actor AS a USING COVERING INDEX idx (last_name=?)": {Type: "SEARCH", Table: "actor", Index: "idx"},
+			"SEARCH actor USING INTEGER PRIMARY KEY (rowid=?)":         {Type: "SEARCH", Table: "actor", Index: "INTEGER PRIMARY KEY"},
+			"USE TEMP B-TREE FOR ORDER BY":                             {Type: "USE TEMP B-TREE FOR ORDER BY"},
+		} {
+			want.Detail = detail
+			if diff := testutil.Diff(*parseSQLitePlanDetail(detail), want); diff != "" {
+				t.Error(testutil.Callers(), detail, diff)
+			}
+		}
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		nodes, err := parsePostgresPlan(`[{"Plan": {"Node Type": "Nested Loop", "Plan Rows": 10, "Total Cost": 20.5, "Actual Rows": 3,
+"Plans": [{"Node Type": "Index Scan", "Relation Name": "actor", "Index Name": "actor_pkey", "Plan Rows": 1, "Total Cost": 8.3}]}}]`)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		want := []*PlanNode{{
+			Type: "Nested Loop", EstimatedRows: 10, Cost: 20.5, ActualRows: sql.NullFloat64{Float64: 3, Valid: true},
+			Children: []*PlanNode{{Type: "Index Scan", Table: "actor", Index: "actor_pkey", EstimatedRows: 1, Cost: 8.3}},
+		}}
+		if diff := testutil.Diff(nodes, want); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("mysql", func(t *testing.T) {
+		nodes, err := parseMySQLPlan(`{"query_block": {"select_id": 1, "cost_info": {"query_cost": "1.20"}, "nested_loop": [
+{"table": {"table_name": "a", "access_type": "ALL", "rows_examined_per_scan": 4, "rows_produced_per_join": 4, "cost_info": {"prefix_cost": "0.65"}}},
+{"table": {"table_name": "fa", "access_type": "ref", "key": "idx_actor_id", "rows_examined_per_scan": 1, "rows_produced_per_join": 4, "cost_info": {"prefix_cost": "1.20"}}}]}}`)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		want := []*PlanNode{{
+			Type: "query_block", Cost: 1.2,
+			Children: []*PlanNode{
+				{Type: "ALL", Table: "a", EstimatedRows: 4, Cost: 0.65},
+				{Type: "ref", Table: "fa", Index: "idx_actor_id", EstimatedRows: 4, Cost: 1.2},
+			},
+		}}
+		if diff := testutil.Diff(nodes, want); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("sqlserver", func(t *testing.T) {
+		nodes, err := parseSQLServerPlan(`<ShowPlanXML><BatchSequence><Batch><Statements><StmtSimple><QueryPlan>
+<RelOp PhysicalOp="Index Seek" EstimateRows="2" EstimatedTotalSubtreeCost="0.0032"><IndexScan><Object Database="[db]" Table="[actor]" Index="[actor_last_name_idx]"/></IndexScan></RelOp>
+</QueryPlan></StmtSimple></Statements></Batch></BatchSequence></ShowPlanXML>`)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		want := []*PlanNode{{Type: "Index Seek", Table: "actor", Index: "actor_last_name_idx", EstimatedRows: 2, Cost: 0.0032}}
+		if diff := testutil.Diff(nodes, want); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("duckdb", func(t *testing.T) {
+		nodes, err := parseDuckDBPlan(`[{"name": "PROJECTION ", "children": [{"name": "INDEX_SCAN ", "children": [], "extra_info": {"Text": "actor", "Estimated Cardinality": "2"}}], "extra_info": {}}]`)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		want := []*PlanNode{{Type: "PROJECTION", Children: []*PlanNode{{Type: "INDEX_SCAN", Table: "actor", EstimatedRows: 2}}}}
+		if diff := testutil.Diff(nodes, want); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+}
@@ -8,23 +8,43 @@ import (
 	"log/slog"
 	"reflect"
 	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 	"sync/atomic"
 	"time"
 )
 
+// queryContextKey is the context key under which the Query being run is
+// passed to the DB, so that DBs such as Router can inspect it.
+type queryContextKey struct{}
+
+// QueryFromContext returns the Query that is being run with ctx. It is
+// available to the QueryContext and ExecContext methods of a DB when the
+// query is run through FetchCursor, FetchOne, FetchAll, FetchExists or Exec
+// (and their Context variants), or a CompiledFetch or PreparedFetch created
+// from a Query.
+func QueryFromContext(ctx context.Context) (Query, bool) {
+	query, ok := ctx.Value(queryContextKey{}).(Query)
+	return query, ok
+}
+
 // DefaultDialect is used by all queries (if no dialect is explicitly provided).
 var DefaultDialect atomic.Pointer[string]
 
 func SetDefaultDialect(dialect string) {
-	switch dialect := strings.ToLower(dialect); dialect {
+	switch lower := strings.ToLower(dialect); lower {
 	case DialectPostgres,
 		DialectSQLite,
 		DialectSQLServer,
 		DialectMySQL:
-		DefaultDialect.Store(&dialect)
+		DefaultDialect.Store(&lower)
 	default:
+		// Registered dialects are looked up by their exact name.
+		if _, ok := LookupDialect(dialect); ok {
+			DefaultDialect.Store(&dialect)
+			return
+		}
 		slog.Warn(fmt.Sprintf("unsupported dialect: %s, default dialect is unset", dialect))
 	}
 }
@@ -45,6 +65,13 @@ type Cursor[T any] struct {
 	logged        int32
 	fieldNames    []string
 	resultsBuffer *bytes.Buffer
+	sensitiveArgs map[int]bool // args masked when the query is logged
+	sensitive     []bool       // result columns masked when the results are logged
+	rowsEnd       *rowsEndNotifier
+	call          *QueryCall
+	iterated      bool // true once Next has returned false
+	results       []T  // results collected for call or set by an interceptor
+	presetResults bool // if true, the cursor iterates over results instead of rows
 }
 
 // FetchCursor returns a new cursor.
@@ -54,10 +81,12 @@ func FetchCursor[T any](db DB, query Query, rowMapper RowMapper[T]) (*Cursor[T],
 
 // FetchCursorContext is like FetchCursor but additionally requires a context.Context.
 func FetchCursorContext[T any](ctx context.Context, db DB, query Query, rowMapper RowMapper[T]) (*Cursor[T], error) {
-	return fetchCursor[T](ctx, db, query, rowMapper, 1)
+	return fetchCursor[T](ctx, db, query, rowMapper, nil, 1)
 }
 
-func fetchCursor[T any](ctx context.Context, db DB, query Query, rowMapper RowMapper[T], skip int) (cursor *Cursor[T], err error) {
+// fetchCursor returns a new cursor. If lookup is not nil, the results are
+// looked up in the cache once the query is rendered.
+func fetchCursor[T any](ctx context.Context, db DB, query Query, rowMapper RowMapper[T], lookup *cacheLookup, skip int) (cursor *Cursor[T], err error) {
 	if db == nil {
 		return nil, fmt.Errorf("db is nil")
 	}
@@ -67,18 +96,55 @@ func fetchCursor[T any](ctx context.Context, db DB, query Query, rowMapper RowMa
 	if rowMapper == nil {
 		return nil, fmt.Errorf("rowMapper is nil")
 	}
-	dialect := query.GetDialect()
-	if dialect == "" {
-		defaultDialect := DefaultDialect.Load()
-		if defaultDialect != nil {
-			dialect = *defaultDialect
+	interceptors := dbInterceptors(db)
+	if len(interceptors) == 0 {
+		return newCursor(ctx, db, queryDialect(db, query), query, rowMapper, nil, lookup, skip+1)
+	}
+	call := newQueryCall(FetchCall, queryDialect(db, query), query, skip+1)
+	ran, err := intercept(ctx, interceptors, call, func(ctx context.Context, call *QueryCall) error {
+		if cursor != nil {
+			_ = cursor.Close()
 		}
+		cursor, err = newCursor(ctx, db, call.Dialect, call.Query, rowMapper, call, lookup, 0)
+		return err
+	})
+	if err != nil {
+		if cursor != nil {
+			_ = cursor.Close()
+		}
+		return nil, err
+	}
+	if ran {
+		return cursor, nil
+	}
+	results, ok := call.Results.([]T)
+	if !ok {
+		return nil, fmt.Errorf("sq: interceptor results: expected %T, got %T", results, call.Results)
+	}
+	return &Cursor[T]{
+		ctx:           ctx,
+		call:          call,
+		results:       results,
+		presetResults: true,
+		queryStats: QueryStats{
+			Dialect:  call.Dialect,
+			RowCount: sql.NullInt64{Valid: true},
+		},
+	}, nil
+}
+
+// newCursor renders query in the given dialect and runs it on db, unless its
+// results are found in the cache.
+func newCursor[T any](ctx context.Context, db DB, dialect string, query Query, rowMapper RowMapper[T], call *QueryCall, lookup *cacheLookup, skip int) (cursor *Cursor[T], err error) {
+	if query == nil {
+		return nil, fmt.Errorf("query is nil")
 	}
 	// If we can't set the fetchable fields, the query is static.
 	_, ok := query.SetFetchableFields(nil)
 	cursor = &Cursor[T]{
 		ctx:       ctx,
 		rowMapper: rowMapper,
+		call:      call,
 		row: &Row{
 			dialect:       dialect,
 			queryIsStatic: !ok,
@@ -102,14 +168,22 @@ func fetchCursor[T any](ctx context.Context, db DB, query Query, rowMapper RowMa
 	buf := bufPool.Get().(*bytes.Buffer)
 	buf.Reset()
 	defer bufPool.Put(buf)
-	err = query.WriteSQL(ctx, dialect, buf, &cursor.queryStats.Args, cursor.queryStats.Params)
+	writeCtx, recorder := withRedactionRecorder(ctx)
+	err = query.WriteSQL(writeCtx, dialect, buf, &cursor.queryStats.Args, cursor.queryStats.Params)
 	cursor.queryStats.Query = buf.String()
 	if err != nil {
 		return nil, err
 	}
+	cursor.sensitiveArgs = recorder.sensitiveArgs()
+	if lookup != nil {
+		if results, ok := cachedResults[T](ctx, lookup, dialect, cursor.queryStats.Query, cursor.queryStats.Args); ok {
+			cursor.results, cursor.presetResults = results, true
+			return cursor, nil
+		}
+	}
 
 	// Setup logger.
-	cursor.logger, _ = db.(Logger)
+	cursor.logger, _ = dbLogger(db)
 	if cursor.logger == nil {
 		logQuery, _ := defaultLogQuery.Load().(func(context.Context, QueryStats))
 		if logQuery != nil {
@@ -123,16 +197,25 @@ func fetchCursor[T any](ctx context.Context, db DB, query Query, rowMapper RowMa
 	if cursor.logger != nil {
 		cursor.logger.LogSettings(ctx, &cursor.logSettings)
 		if cursor.logSettings.IncludeCaller {
-			cursor.queryStats.CallerFile, cursor.queryStats.CallerLine, cursor.queryStats.CallerFunction = caller(skip + 1)
+			cursor.queryStats.CallerFile, cursor.queryStats.CallerLine, cursor.queryStats.CallerFunction = call.caller(skip + 1)
+		}
+		if cursor.logSettings.IncludeFingerprint {
+			cursor.queryStats.NormalizedQuery, cursor.queryStats.Fingerprint = Fingerprint(cursor.queryStats.Dialect, cursor.queryStats.Query)
 		}
 	}
+	if call.wantsStats() {
+		cursor.logSettings.IncludeTime = true
+	}
 
 	// Run query.
 	if cursor.logSettings.IncludeTime {
 		cursor.queryStats.StartedAt = time.Now()
 	}
 	// Execute query.
-	cursor.row.sqlRows, cursor.queryStats.Err = db.QueryContext(ctx, cursor.queryStats.Query, cursor.queryStats.Args...)
+	cursor.rowsEnd = &rowsEndNotifier{}
+	queryCtx := context.WithValue(context.WithValue(ctx, queryContextKey{}, query), rowsEndContextKey{}, cursor.rowsEnd)
+	cursor.row.sqlRows, cursor.queryStats.Err = db.QueryContext(queryCtx, cursor.queryStats.Query, cursor.queryStats.Args...)
+	cursor.queryStats.Err = wrapDBError(cursor.queryStats.Err)
 	if cursor.logSettings.IncludeTime {
 		cursor.queryStats.TimeTaken = time.Since(cursor.queryStats.StartedAt)
 	}
@@ -174,10 +257,16 @@ func fetchCursor[T any](ctx context.Context, db DB, query Query, rowMapper RowMa
 
 // Next advances the cursor to the next result.
 func (cursor *Cursor[T]) Next() bool {
-	hasNext := cursor.row.sqlRows.Next()
+	var hasNext bool
+	if cursor.presetResults {
+		hasNext = cursor.queryStats.RowCount.Int64 < int64(len(cursor.results))
+	} else {
+		hasNext = cursor.row.sqlRows.Next()
+	}
 	if hasNext {
 		cursor.queryStats.RowCount.Int64++
 	} else {
+		cursor.iterated = true
 		cursor.log()
 	}
 	return hasNext
@@ -188,6 +277,16 @@ func (cursor *Cursor[T]) RowCount() int64 { return cursor.queryStats.RowCount.In
 
 // Result returns the cursor result.
 func (cursor *Cursor[T]) Result() (result T, err error) {
+	if cursor.presetResults {
+		return cursor.results[cursor.queryStats.RowCount.Int64-1], nil
+	}
+	if cursor.call.wantsResults() {
+		defer func() {
+			if err == nil {
+				cursor.results = append(cursor.results, result)
+			}
+		}()
+	}
 	err = cursor.row.sqlRows.Scan(cursor.row.scanDest...)
 	if err != nil {
 		cursor.log()
@@ -198,6 +297,7 @@ func (cursor *Cursor[T]) Result() (result T, err error) {
 	if cursor.resultsBuffer != nil && cursor.queryStats.RowCount.Int64 <= int64(cursor.logSettings.IncludeResults) {
 		if len(cursor.fieldNames) == 0 {
 			cursor.fieldNames = getFieldNames(cursor.ctx, cursor.row)
+			cursor.sensitive = sensitiveResultColumns(cursor.row)
 		}
 		cursor.resultsBuffer.WriteString("\n----[ Row " + strconv.FormatInt(cursor.queryStats.RowCount.Int64, 10) + " ]----")
 		for i := range cursor.row.scanDest {
@@ -206,6 +306,10 @@ func (cursor *Cursor[T]) Result() (result T, err error) {
 				cursor.resultsBuffer.WriteString(cursor.fieldNames[i])
 			}
 			cursor.resultsBuffer.WriteString(": ")
+			if i < len(cursor.sensitive) && cursor.sensitive[i] {
+				cursor.resultsBuffer.WriteString(redactionPolicy(cursor.logSettings).mask())
+				continue
+			}
 			scanDest := cursor.row.scanDest[i]
 			rhs, err := Sprint(cursor.queryStats.Dialect, scanDest)
 			if err != nil {
@@ -229,24 +333,40 @@ func (cursor *Cursor[T]) log() {
 		cursor.queryStats.Results = cursor.resultsBuffer.String()
 		bufPool.Put(cursor.resultsBuffer)
 	}
+	if cursor.row != nil && cursor.row.sqlRows != nil {
+		err := cursor.queryStats.Err
+		if err == nil {
+			err = cursor.row.sqlRows.Err()
+		}
+		cursor.rowsEnd.notify(cursor.queryStats.RowCount.Int64, err)
+		if cursor.call != nil {
+			stats := cursor.queryStats
+			stats.Err = err
+			cursor.call.complete(stats)
+			if cursor.iterated && err == nil {
+				cursor.call.deliverResults(cursor.results)
+			}
+		}
+	} else {
+		cursor.call.complete(cursor.queryStats)
+	}
 	if cursor.logger == nil {
 		return
 	}
-	if cursor.logSettings.LogAsynchronously {
-		go cursor.logger.LogQuery(cursor.ctx, cursor.queryStats)
-	} else {
-		cursor.logger.LogQuery(cursor.ctx, cursor.queryStats)
-	}
+	logQueryStats(cursor.ctx, cursor.logger, cursor.logSettings, cursor.queryStats, cursor.sensitiveArgs)
 }
 
 // Close closes the cursor.
 func (cursor *Cursor[T]) Close() error {
 	cursor.log()
+	if cursor.presetResults {
+		return nil
+	}
 	if err := cursor.row.sqlRows.Close(); err != nil {
-		return err
+		return wrapDBError(err)
 	}
 	if err := cursor.row.sqlRows.Err(); err != nil {
-		return err
+		return wrapDBError(err)
 	}
 	return nil
 }
@@ -259,12 +379,18 @@ func FetchOne[T any](db DB, query Query, rowMapper RowMapper[T]) (T, error) {
 
 // FetchOneContext is like FetchOne but additionally requires a context.Context.
 func FetchOneContext[T any](ctx context.Context, db DB, query Query, rowMapper RowMapper[T]) (T, error) {
-	cursor, err := fetchCursor[T](ctx, db, query, rowMapper, 1)
+	lookup := newCacheLookup(ctx, db, query, "one")
+	cursor, err := fetchCursor[T](ctx, db, query, rowMapper, lookup, 1)
 	if err != nil {
 		return *new(T), err
 	}
 	defer closeQuietly(cursor.Close)
-	return cursorResult(cursor)
+	result, err := cursorResult(cursor)
+	if err != nil {
+		return result, err
+	}
+	lookup.set(ctx, []T{result})
+	return result, nil
 }
 
 // FetchAll returns all results from running the given Query on the given DB.
@@ -274,12 +400,18 @@ func FetchAll[T any](db DB, query Query, rowMapper RowMapper[T]) ([]T, error) {
 
 // FetchAllContext is like FetchAll but additionally requires a context.Context.
 func FetchAllContext[T any](ctx context.Context, db DB, query Query, rowMapper RowMapper[T]) ([]T, error) {
-	cursor, err := fetchCursor[T](ctx, db, query, rowMapper, 1)
+	lookup := newCacheLookup(ctx, db, query, "all")
+	cursor, err := fetchCursor[T](ctx, db, query, rowMapper, lookup, 1)
 	if err != nil {
 		return nil, err
 	}
 	defer closeQuietly(cursor.Close)
-	return cursorResults(cursor)
+	results, err := cursorResults(cursor)
+	if err != nil {
+		return results, err
+	}
+	lookup.set(ctx, slices.Clone(results))
+	return results, nil
 }
 
 // CompiledFetch is the result of compiling a Query down into a query string
@@ -294,6 +426,11 @@ type CompiledFetch[T any] struct {
 	// columns are in the query, and it must be determined at runtime after
 	// running the query.
 	queryIsStatic bool
+	// args written for sensitive fields, masked when the query is logged.
+	sensitiveArgs map[int]bool
+	// source is the Query that was compiled, if known. It is passed to the DB
+	// in the context (see QueryFromContext).
+	source Query
 }
 
 // NewCompiledFetch returns a new CompiledFetch.
@@ -352,11 +489,14 @@ func CompileFetchContext[T any](ctx context.Context, query Query, rowMapper RowM
 	buf := bufPool.Get().(*bytes.Buffer)
 	buf.Reset()
 	defer bufPool.Put(buf)
-	err = query.WriteSQL(ctx, dialect, buf, &compiledFetch.args, compiledFetch.params)
+	writeCtx, recorder := withRedactionRecorder(ctx)
+	err = query.WriteSQL(writeCtx, dialect, buf, &compiledFetch.args, compiledFetch.params)
 	compiledFetch.query = buf.String()
 	if err != nil {
 		return nil, err
 	}
+	compiledFetch.sensitiveArgs = recorder.sensitiveArgs()
+	compiledFetch.source = query
 	return compiledFetch, nil
 }
 
@@ -387,6 +527,7 @@ func (compiledFetch *CompiledFetch[T]) fetchCursor(ctx context.Context, db DB, p
 			Args:    compiledFetch.args,
 			Params:  compiledFetch.params,
 		},
+		sensitiveArgs: compiledFetch.sensitiveArgs,
 	}
 
 	// Call the rowMapper to populate row.scanDest.
@@ -403,7 +544,7 @@ func (compiledFetch *CompiledFetch[T]) fetchCursor(ctx context.Context, db DB, p
 
 	// Setup logger.
 	cursor.queryStats.RowCount.Valid = true
-	cursor.logger, _ = db.(Logger)
+	cursor.logger, _ = dbLogger(db)
 	if cursor.logger == nil {
 		logQuery, _ := defaultLogQuery.Load().(func(context.Context, QueryStats))
 		if logQuery != nil {
@@ -419,13 +560,22 @@ func (compiledFetch *CompiledFetch[T]) fetchCursor(ctx context.Context, db DB, p
 		if cursor.logSettings.IncludeCaller {
 			cursor.queryStats.CallerFile, cursor.queryStats.CallerLine, cursor.queryStats.CallerFunction = caller(skip + 1)
 		}
+		if cursor.logSettings.IncludeFingerprint {
+			cursor.queryStats.NormalizedQuery, cursor.queryStats.Fingerprint = Fingerprint(cursor.queryStats.Dialect, cursor.queryStats.Query)
+		}
 	}
 
 	// Run query.
 	if cursor.logSettings.IncludeTime {
 		cursor.queryStats.StartedAt = time.Now()
 	}
-	cursor.row.sqlRows, cursor.queryStats.Err = db.QueryContext(ctx, cursor.queryStats.Query, cursor.queryStats.Args...)
+	cursor.rowsEnd = &rowsEndNotifier{}
+	queryCtx := context.WithValue(ctx, rowsEndContextKey{}, cursor.rowsEnd)
+	if compiledFetch.source != nil {
+		queryCtx = context.WithValue(queryCtx, queryContextKey{}, compiledFetch.source)
+	}
+	cursor.row.sqlRows, cursor.queryStats.Err = db.QueryContext(queryCtx, cursor.queryStats.Query, cursor.queryStats.Args...)
+	cursor.queryStats.Err = wrapDBError(cursor.queryStats.Err)
 	if cursor.logSettings.IncludeTime {
 		cursor.queryStats.TimeTaken = time.Since(cursor.queryStats.StartedAt)
 	}
@@ -542,7 +692,8 @@ func (compiledFetch *CompiledFetch[T]) PrepareContext(ctx context.Context, db DB
 	if err != nil {
 		return nil, err
 	}
-	preparedFetch.logger, _ = db.(Logger)
+	preparedFetch.hooks, _ = dbHooks(db)
+	preparedFetch.logger, _ = dbLogger(db)
 	if preparedFetch.logger == nil {
 		logQuery, _ := defaultLogQuery.Load().(func(context.Context, QueryStats))
 		if logQuery != nil {
@@ -561,6 +712,7 @@ type PreparedFetch[T any] struct {
 	compiledFetch *CompiledFetch[T]
 	stmt          *sql.Stmt
 	logger        Logger
+	hooks         hookDB
 }
 
 // PrepareFetch returns a new PreparedFetch.
@@ -602,7 +754,8 @@ func (preparedFetch *PreparedFetch[T]) fetchCursor(ctx context.Context, params P
 			Params:   preparedFetch.compiledFetch.params,
 			RowCount: sql.NullInt64{Valid: true},
 		},
-		logger: preparedFetch.logger,
+		logger:        preparedFetch.logger,
+		sensitiveArgs: preparedFetch.compiledFetch.sensitiveArgs,
 	}
 
 	// If the query is dynamic, call the rowMapper to populate row.scanDest.
@@ -623,13 +776,27 @@ func (preparedFetch *PreparedFetch[T]) fetchCursor(ctx context.Context, params P
 		if cursor.logSettings.IncludeCaller {
 			cursor.queryStats.CallerFile, cursor.queryStats.CallerLine, cursor.queryStats.CallerFunction = caller(skip + 1)
 		}
+		if cursor.logSettings.IncludeFingerprint {
+			cursor.queryStats.NormalizedQuery, cursor.queryStats.Fingerprint = Fingerprint(cursor.queryStats.Dialect, cursor.queryStats.Query)
+		}
 	}
 
 	// Run query.
 	if cursor.logSettings.IncludeTime {
 		cursor.queryStats.StartedAt = time.Now()
 	}
-	cursor.row.sqlRows, cursor.queryStats.Err = preparedFetch.stmt.QueryContext(ctx, cursor.queryStats.Args...)
+	hookCtx := ctx
+	if preparedFetch.compiledFetch.source != nil {
+		hookCtx = context.WithValue(hookCtx, queryContextKey{}, preparedFetch.compiledFetch.source)
+	}
+	hookCtx, event := preparedFetch.hooks.beforeQuery(hookCtx, HookStmtQuery, cursor.queryStats.Dialect, cursor.queryStats.Query, cursor.queryStats.Args...)
+	cursor.row.sqlRows, cursor.queryStats.Err = preparedFetch.stmt.QueryContext(hookCtx, cursor.queryStats.Args...)
+	preparedFetch.hooks.afterQuery(hookCtx, event, nil, cursor.queryStats.Err)
+	if event != nil && cursor.queryStats.Err == nil {
+		cursor.rowsEnd = &rowsEndNotifier{}
+		preparedFetch.hooks.onRowsEnd(hookCtx, event, cursor.rowsEnd)
+	}
+	cursor.queryStats.Err = wrapDBError(cursor.queryStats.Err)
 	if cursor.logSettings.IncludeTime {
 		cursor.queryStats.TimeTaken = time.Since(cursor.queryStats.StartedAt)
 	}
@@ -742,12 +909,29 @@ func exec(ctx context.Context, db DB, query Query, skip int) (result Result, err
 	if query == nil {
 		return result, fmt.Errorf("query is nil")
 	}
-	dialect := query.GetDialect()
-	if dialect == "" {
-		defaultDialect := DefaultDialect.Load()
-		if defaultDialect != nil {
-			dialect = *defaultDialect
-		}
+	interceptors := dbInterceptors(db)
+	if len(interceptors) == 0 {
+		return runExec(ctx, db, queryDialect(db, query), query, nil, skip+1)
+	}
+	call := newQueryCall(ExecCall, queryDialect(db, query), query, skip+1)
+	ran, err := intercept(ctx, interceptors, call, func(ctx context.Context, call *QueryCall) (err error) {
+		result, err = runExec(ctx, db, call.Dialect, call.Query, call, 0)
+		return err
+	})
+	if err != nil || ran {
+		return result, err
+	}
+	result, ok := call.Results.(Result)
+	if !ok {
+		return result, fmt.Errorf("sq: interceptor results: expected %T, got %T", result, call.Results)
+	}
+	return result, nil
+}
+
+// runExec renders query in the given dialect and executes it on db.
+func runExec(ctx context.Context, db DB, dialect string, query Query, call *QueryCall, skip int) (result Result, err error) {
+	if query == nil {
+		return result, fmt.Errorf("query is nil")
 	}
 	queryStats := QueryStats{
 		Dialect: dialect,
@@ -758,7 +942,8 @@ func exec(ctx context.Context, db DB, query Query, skip int) (result Result, err
 	buf := bufPool.Get().(*bytes.Buffer)
 	buf.Reset()
 	defer bufPool.Put(buf)
-	err = query.WriteSQL(ctx, dialect, buf, &queryStats.Args, queryStats.Params)
+	writeCtx, recorder := withRedactionRecorder(ctx)
+	err = query.WriteSQL(writeCtx, dialect, buf, &queryStats.Args, queryStats.Params)
 	queryStats.Query = buf.String()
 	if err != nil {
 		return result, err
@@ -766,7 +951,7 @@ func exec(ctx context.Context, db DB, query Query, skip int) (result Result, err
 
 	// Setup logger.
 	var logSettings LogSettings
-	logger, _ := db.(Logger)
+	logger, _ := dbLogger(db)
 	if logger == nil {
 		logQuery, _ := defaultLogQuery.Load().(func(context.Context, QueryStats))
 		if logQuery != nil {
@@ -780,13 +965,24 @@ func exec(ctx context.Context, db DB, query Query, skip int) (result Result, err
 	if logger != nil {
 		logger.LogSettings(ctx, &logSettings)
 		if logSettings.IncludeCaller {
-			queryStats.CallerFile, queryStats.CallerLine, queryStats.CallerFunction = caller(skip + 1)
+			queryStats.CallerFile, queryStats.CallerLine, queryStats.CallerFunction = call.caller(skip + 1)
+		}
+		if logSettings.IncludeFingerprint {
+			queryStats.NormalizedQuery, queryStats.Fingerprint = Fingerprint(queryStats.Dialect, queryStats.Query)
 		}
 		defer func() {
-			if logSettings.LogAsynchronously {
-				go logger.LogQuery(ctx, queryStats)
-			} else {
-				logger.LogQuery(ctx, queryStats)
+			logQueryStats(ctx, logger, logSettings, queryStats, recorder.sensitiveArgs())
+		}()
+	}
+	if call != nil {
+		logSettings.IncludeTime = logSettings.IncludeTime || call.wantsStats()
+		defer func() {
+			if queryStats.Err == nil && err != nil {
+				queryStats.Err = err
+			}
+			call.complete(queryStats)
+			if err == nil {
+				call.deliverResults(result)
 			}
 		}()
 	}
@@ -796,7 +992,8 @@ func exec(ctx context.Context, db DB, query Query, skip int) (result Result, err
 		queryStats.StartedAt = time.Now()
 	}
 	var sqlResult sql.Result
-	sqlResult, queryStats.Err = db.ExecContext(ctx, queryStats.Query, queryStats.Args...)
+	sqlResult, queryStats.Err = db.ExecContext(context.WithValue(ctx, queryContextKey{}, query), queryStats.Query, queryStats.Args...)
+	queryStats.Err = wrapDBError(queryStats.Err)
 	if logSettings.IncludeTime {
 		queryStats.TimeTaken = time.Since(queryStats.StartedAt)
 	}
@@ -813,6 +1010,8 @@ type CompiledExec struct {
 	query   string
 	args    []any
 	params  map[string][]int
+	// args written for sensitive fields, masked when the query is logged.
+	sensitiveArgs map[int]bool
 }
 
 // NewCompiledExec returns a new CompiledExec.
@@ -851,11 +1050,13 @@ func CompileExecContext(ctx context.Context, query Query) (*CompiledExec, error)
 	buf := bufPool.Get().(*bytes.Buffer)
 	buf.Reset()
 	defer bufPool.Put(buf)
-	err := query.WriteSQL(ctx, dialect, buf, &compiledExec.args, compiledExec.params)
+	writeCtx, recorder := withRedactionRecorder(ctx)
+	err := query.WriteSQL(writeCtx, dialect, buf, &compiledExec.args, compiledExec.params)
 	compiledExec.query = buf.String()
 	if err != nil {
 		return nil, err
 	}
+	compiledExec.sensitiveArgs = recorder.sensitiveArgs()
 	return compiledExec, nil
 }
 
@@ -882,7 +1083,7 @@ func (compiledExec *CompiledExec) exec(ctx context.Context, db DB, params Params
 
 	// Setup logger.
 	var logSettings LogSettings
-	logger, _ := db.(Logger)
+	logger, _ := dbLogger(db)
 	if logger == nil {
 		logQuery, _ := defaultLogQuery.Load().(func(context.Context, QueryStats))
 		if logQuery != nil {
@@ -898,12 +1099,11 @@ func (compiledExec *CompiledExec) exec(ctx context.Context, db DB, params Params
 		if logSettings.IncludeCaller {
 			queryStats.CallerFile, queryStats.CallerLine, queryStats.CallerFunction = caller(skip + 1)
 		}
+		if logSettings.IncludeFingerprint {
+			queryStats.NormalizedQuery, queryStats.Fingerprint = Fingerprint(queryStats.Dialect, queryStats.Query)
+		}
 		defer func() {
-			if logSettings.LogAsynchronously {
-				go logger.LogQuery(ctx, queryStats)
-			} else {
-				logger.LogQuery(ctx, queryStats)
-			}
+			logQueryStats(ctx, logger, logSettings, queryStats, compiledExec.sensitiveArgs)
 		}()
 	}
 
@@ -919,6 +1119,7 @@ func (compiledExec *CompiledExec) exec(ctx context.Context, db DB, params Params
 	}
 	var sqlResult sql.Result
 	sqlResult, queryStats.Err = db.ExecContext(ctx, queryStats.Query, queryStats.Args...)
+	queryStats.Err = wrapDBError(queryStats.Err)
 	if logSettings.IncludeTime {
 		queryStats.TimeTaken = time.Since(queryStats.StartedAt)
 	}
@@ -960,7 +1161,8 @@ func (compiledExec *CompiledExec) PrepareContext(ctx context.Context, db DB) (*P
 	if err != nil {
 		return nil, err
 	}
-	preparedExec.logger, _ = db.(Logger)
+	preparedExec.hooks, _ = dbHooks(db)
+	preparedExec.logger, _ = dbLogger(db)
 	if preparedExec.logger == nil {
 		logQuery, _ := defaultLogQuery.Load().(func(context.Context, QueryStats))
 		if logQuery != nil {
@@ -979,6 +1181,7 @@ type PreparedExec struct {
 	compiledExec *CompiledExec
 	stmt         *sql.Stmt
 	logger       Logger
+	hooks        hookDB
 }
 
 // PrepareExec returns a new PreparedExec.
@@ -1029,12 +1232,11 @@ func (preparedExec *PreparedExec) exec(ctx context.Context, params Params, skip
 		if logSettings.IncludeCaller {
 			queryStats.CallerFile, queryStats.CallerLine, queryStats.CallerFunction = caller(skip + 1)
 		}
+		if logSettings.IncludeFingerprint {
+			queryStats.NormalizedQuery, queryStats.Fingerprint = Fingerprint(queryStats.Dialect, queryStats.Query)
+		}
 		defer func() {
-			if logSettings.LogAsynchronously {
-				go preparedExec.logger.LogQuery(ctx, queryStats)
-			} else {
-				preparedExec.logger.LogQuery(ctx, queryStats)
-			}
+			logQueryStats(ctx, preparedExec.logger, logSettings, queryStats, preparedExec.compiledExec.sensitiveArgs)
 		}()
 	}
 
@@ -1049,7 +1251,10 @@ func (preparedExec *PreparedExec) exec(ctx context.Context, params Params, skip
 		queryStats.StartedAt = time.Now()
 	}
 	var sqlResult sql.Result
-	sqlResult, queryStats.Err = preparedExec.stmt.ExecContext(ctx, queryStats.Args...)
+	hookCtx, event := preparedExec.hooks.beforeQuery(ctx, HookStmtExec, queryStats.Dialect, queryStats.Query, queryStats.Args...)
+	sqlResult, queryStats.Err = preparedExec.stmt.ExecContext(hookCtx, queryStats.Args...)
+	preparedExec.hooks.afterQuery(hookCtx, event, sqlResult, queryStats.Err)
+	queryStats.Err = wrapDBError(queryStats.Err)
 	if logSettings.IncludeTime {
 		queryStats.TimeTaken = time.Since(queryStats.StartedAt)
 	}
@@ -1175,12 +1380,36 @@ func FetchExistsContext(ctx context.Context, db DB, query Query) (exists bool, e
 }
 
 func fetchExists(ctx context.Context, db DB, query Query, skip int) (exists bool, err error) {
-	dialect := query.GetDialect()
-	if dialect == "" {
-		defaultDialect := DefaultDialect.Load()
-		if defaultDialect != nil {
-			dialect = *defaultDialect
-		}
+	if db == nil {
+		return false, fmt.Errorf("db is nil")
+	}
+	if query == nil {
+		return false, fmt.Errorf("query is nil")
+	}
+	interceptors := dbInterceptors(db)
+	if len(interceptors) == 0 {
+		return runExists(ctx, db, queryDialect(db, query), query, nil, skip+1)
+	}
+	call := newQueryCall(ExistsCall, queryDialect(db, query), query, skip+1)
+	ran, err := intercept(ctx, interceptors, call, func(ctx context.Context, call *QueryCall) (err error) {
+		exists, err = runExists(ctx, db, call.Dialect, call.Query, call, 0)
+		return err
+	})
+	if err != nil || ran {
+		return exists, err
+	}
+	exists, ok := call.Results.(bool)
+	if !ok {
+		return false, fmt.Errorf("sq: interceptor results: expected bool, got %T", call.Results)
+	}
+	return exists, nil
+}
+
+// runExists renders query in the given dialect and checks if it returns any
+// results on db.
+func runExists(ctx context.Context, db DB, dialect string, query Query, call *QueryCall, skip int) (exists bool, err error) {
+	if query == nil {
+		return false, fmt.Errorf("query is nil")
 	}
 	queryStats := QueryStats{
 		Dialect: dialect,
@@ -1192,12 +1421,14 @@ func fetchExists(ctx context.Context, db DB, query Query, skip int) (exists bool
 	buf := bufPool.Get().(*bytes.Buffer)
 	buf.Reset()
 	defer bufPool.Put(buf)
+	var existsQuery Query
 	if dialect == DialectSQLServer {
-		query = Queryf("SELECT CASE WHEN EXISTS ({}) THEN 1 ELSE 0 END", query)
+		existsQuery = Queryf("SELECT CASE WHEN EXISTS ({}) THEN 1 ELSE 0 END", query)
 	} else {
-		query = Queryf("SELECT EXISTS ({})", query)
+		existsQuery = Queryf("SELECT EXISTS ({})", query)
 	}
-	err = query.WriteSQL(ctx, dialect, buf, &queryStats.Args, queryStats.Params)
+	writeCtx, recorder := withRedactionRecorder(ctx)
+	err = existsQuery.WriteSQL(writeCtx, dialect, buf, &queryStats.Args, queryStats.Params)
 	queryStats.Query = buf.String()
 	if err != nil {
 		return false, err
@@ -1205,7 +1436,7 @@ func fetchExists(ctx context.Context, db DB, query Query, skip int) (exists bool
 
 	// Setup logger.
 	var logSettings LogSettings
-	logger, _ := db.(Logger)
+	logger, _ := dbLogger(db)
 	if logger == nil {
 		logQuery, _ := defaultLogQuery.Load().(func(context.Context, QueryStats))
 		if logQuery != nil {
@@ -1219,13 +1450,24 @@ func fetchExists(ctx context.Context, db DB, query Query, skip int) (exists bool
 	if logger != nil {
 		logger.LogSettings(ctx, &logSettings)
 		if logSettings.IncludeCaller {
-			queryStats.CallerFile, queryStats.CallerLine, queryStats.CallerFunction = caller(skip + 1)
+			queryStats.CallerFile, queryStats.CallerLine, queryStats.CallerFunction = call.caller(skip + 1)
 		}
+		if logSettings.IncludeFingerprint {
+			queryStats.NormalizedQuery, queryStats.Fingerprint = Fingerprint(queryStats.Dialect, queryStats.Query)
+		}
+		defer func() {
+			logQueryStats(ctx, logger, logSettings, queryStats, recorder.sensitiveArgs())
+		}()
+	}
+	if call != nil {
+		logSettings.IncludeTime = logSettings.IncludeTime || call.wantsStats()
 		defer func() {
-			if logSettings.LogAsynchronously {
-				go logger.LogQuery(ctx, queryStats)
-			} else {
-				logger.LogQuery(ctx, queryStats)
+			if queryStats.Err == nil && err != nil {
+				queryStats.Err = err
+			}
+			call.complete(queryStats)
+			if err == nil {
+				call.deliverResults(exists)
 			}
 		}()
 	}
@@ -1235,7 +1477,8 @@ func fetchExists(ctx context.Context, db DB, query Query, skip int) (exists bool
 		queryStats.StartedAt = time.Now()
 	}
 	var sqlRows *sql.Rows
-	sqlRows, queryStats.Err = db.QueryContext(ctx, queryStats.Query, queryStats.Args...)
+	sqlRows, queryStats.Err = db.QueryContext(context.WithValue(ctx, queryContextKey{}, query), queryStats.Query, queryStats.Args...)
+	queryStats.Err = wrapDBError(queryStats.Err)
 	if logSettings.IncludeTime {
 		queryStats.TimeTaken = time.Since(queryStats.StartedAt)
 	}
@@ -1253,10 +1496,10 @@ func fetchExists(ctx context.Context, db DB, query Query, skip int) (exists bool
 	queryStats.Exists.Bool = exists
 
 	if err := sqlRows.Close(); err != nil {
-		return exists, err
+		return exists, wrapDBError(err)
 	}
 	if err := sqlRows.Err(); err != nil {
-		return exists, err
+		return exists, wrapDBError(err)
 	}
 	return exists, nil
 }
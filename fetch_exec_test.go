@@ -3,6 +3,7 @@ package sq
 import (
 	"context"
 	"database/sql"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -469,3 +470,30 @@ func newDB(t *testing.T) *sql.DB {
 	}
 	return db
 }
+
+// newSQLiteDB returns a SQLite DB in a temporary file (so that every
+// connection sees the same data) created with schema.
+func newSQLiteDB(t *testing.T, schema string) *sql.DB {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "sqlite.db"))
+	if err != nil {
+		t.Fatal(testutil.Callers(), err)
+	}
+	t.Cleanup(func() { db.Close() })
+	_, err = db.Exec(schema)
+	if err != nil {
+		t.Fatal(testutil.Callers(), err)
+	}
+	return db
+}
+
+// newActorDB is like newDB, but the DB is shared by all connections and the
+// actor table is filled with three actors.
+func newActorDB(t *testing.T) *sql.DB {
+	return newSQLiteDB(t, `CREATE TABLE actor (
+    actor_id INTEGER PRIMARY KEY AUTOINCREMENT
+    ,first_name TEXT NOT NULL
+    ,last_name TEXT NOT NULL
+    ,last_update DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+INSERT INTO actor (actor_id, first_name, last_name) VALUES (1, 'PENELOPE', 'GUINESS'), (2, 'NICK', 'WAHLBERG'), (3, 'ED', 'CHASE')`)
+}
@@ -7,6 +7,7 @@ import (
 	"database/sql/driver"
 	"fmt"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -1503,7 +1504,7 @@ func New[T Table](alias string) T {
 			continue
 		}
 		fieldType := typ.Field(i)
-		name := fieldType.Tag.Get("sq")
+		name, options, _ := strings.Cut(fieldType.Tag.Get("sq"), ",")
 		if name == "" {
 			name = strings.ToLower(fieldType.Name)
 		}
@@ -1529,6 +1530,11 @@ func New[T Table](alias string) T {
 		case UUIDField:
 			v.Set(reflect.ValueOf(NewUUIDField(name, tableStruct)))
 		}
+		if slices.Contains(strings.Split(options, ","), "sensitive") {
+			if field, ok := v.Interface().(Field); ok {
+				MarkSensitive(field)
+			}
+		}
 	}
 	return tbl
 }
@@ -0,0 +1,249 @@
+package sq
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"strings"
+)
+
+// Fingerprint normalizes a query string so that queries with the same shape
+// produce the same normalized text, regardless of the literal values or
+// number of arguments used. It returns the normalized query together with a
+// hash of the normalized query, which can be used for grouping queries in
+// logs and metrics.
+//
+// During normalization:
+//
+// - Comments are stripped and consecutive whitespace is collapsed into a
+// single space.
+//
+// - String literals, numeric literals, boolean literals and bind parameter
+// placeholders (?, $1, @p1, :name etc) are replaced with '?'.
+//
+// - Lists that consist only of placeholders (e.g. IN (?, ?, ?)) are collapsed
+// into (...), and repeated identical lists (e.g. VALUES (?, ?), (?, ?)) are
+// collapsed into a single list.
+//
+// Quoted identifiers are left untouched.
+func Fingerprint(dialect string, query string) (normalized string, hash string) {
+	buf := bufPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer bufPool.Put(buf)
+	buf.Grow(len(query))
+	var parens []int
+	pendingSpace := false
+	writeSpace := func() {
+		if pendingSpace && buf.Len() > 0 {
+			last := buf.Bytes()[buf.Len()-1]
+			if last != '(' {
+				buf.WriteByte(' ')
+			}
+		}
+		pendingSpace = false
+	}
+	for i := 0; i < len(query); {
+		char := query[i]
+		switch {
+		// whitespace
+		case char == ' ' || char == '\t' || char == '\n' || char == '\r' || char == '\f':
+			pendingSpace = true
+			i++
+		// -- line comment
+		case char == '-' && i+1 < len(query) && query[i+1] == '-':
+			j := strings.IndexByte(query[i:], '\n')
+			if j < 0 {
+				i = len(query)
+			} else {
+				i += j + 1
+			}
+			pendingSpace = true
+		// /* block comment */
+		case char == '/' && i+1 < len(query) && query[i+1] == '*':
+			j := strings.Index(query[i+2:], "*/")
+			if j < 0 {
+				i = len(query)
+			} else {
+				i += j + 4
+			}
+			pendingSpace = true
+		// 'string literal'
+		case char == '\'':
+			i = skipQuoted(query, i, '\'')
+			writeSpace()
+			buf.WriteByte('?')
+		// "quoted identifier", `quoted identifier` or [quoted identifier]
+		case char == '"' || (char == '`' && dialect == DialectMySQL) || (char == '[' && dialect == DialectSQLServer):
+			closingQuote := char
+			if char == '[' {
+				closingQuote = ']'
+			}
+			j := skipQuoted(query, i, closingQuote)
+			writeSpace()
+			buf.WriteString(query[i:j])
+			i = j
+		// @@system_variable
+		case char == '@' && i+1 < len(query) && query[i+1] == '@':
+			j := i + 2
+			for j < len(query) && isIdentifierChar(query[j]) {
+				j++
+			}
+			writeSpace()
+			buf.WriteString(query[i:j])
+			i = j
+		// numeric literal
+		case char >= '0' && char <= '9' || (char == '.' && i+1 < len(query) && query[i+1] >= '0' && query[i+1] <= '9'):
+			i = skipNumber(query, i)
+			writeSpace()
+			buf.WriteByte('?')
+		// $1, $name, @p1, @name, :name, ?1 or ?name placeholders
+		case (char == '$' && dialect != DialectMySQL && dialect != DialectSQLServer) ||
+			(char == '@' && (dialect == DialectSQLite || dialect == DialectSQLServer)) ||
+			(char == ':' && (dialect == DialectSQLite || placeholderStyle(dialect) == PlaceholderColon)) ||
+			char == '?':
+			j := i + 1
+			for j < len(query) && isIdentifierChar(query[j]) {
+				j++
+			}
+			if char != '?' && j == i+1 {
+				// A lone '$', '@' or ':' is not a placeholder.
+				writeSpace()
+				buf.WriteByte(char)
+				i++
+				continue
+			}
+			writeSpace()
+			buf.WriteByte('?')
+			i = j
+		// keyword or unquoted identifier
+		case isIdentifierChar(char):
+			j := i + 1
+			for j < len(query) && isIdentifierChar(query[j]) {
+				j++
+			}
+			word := query[i:j]
+			// Prefixed string literals e.g. x'ff', N'foo', E'bar'.
+			if j < len(query) && query[j] == '\'' && len(word) == 1 && strings.ContainsAny(word, "xXnNeEbB") {
+				i = skipQuoted(query, j, '\'')
+				writeSpace()
+				buf.WriteByte('?')
+				continue
+			}
+			writeSpace()
+			if strings.EqualFold(word, "TRUE") || strings.EqualFold(word, "FALSE") {
+				buf.WriteByte('?')
+			} else {
+				buf.WriteString(word)
+			}
+			i = j
+		case char == '(':
+			writeSpace()
+			parens = append(parens, buf.Len())
+			buf.WriteByte('(')
+			i++
+		case char == ')':
+			pendingSpace = false
+			buf.WriteByte(')')
+			i++
+			if len(parens) == 0 {
+				continue
+			}
+			start := parens[len(parens)-1]
+			parens = parens[:len(parens)-1]
+			if isPlaceholderList(buf.Bytes()[start+1 : buf.Len()-1]) {
+				buf.Truncate(start)
+				buf.WriteString("(...)")
+			}
+			// Collapse a list that is identical to the list immediately
+			// preceding it e.g. VALUES (?, ?), (?, ?) => VALUES (?, ?).
+			group := buf.Bytes()[start:]
+			if start >= len(group)+2 {
+				prev := buf.Bytes()[start-len(group)-2 : start]
+				if bytes.Equal(prev[:len(group)], group) && prev[len(group)] == ',' && prev[len(group)+1] == ' ' {
+					buf.Truncate(start - 2)
+				}
+			}
+		case char == ',':
+			pendingSpace = false
+			buf.WriteByte(',')
+			pendingSpace = true
+			i++
+		default:
+			writeSpace()
+			buf.WriteByte(char)
+			i++
+		}
+	}
+	normalized = buf.String()
+	h := fnv.New64a()
+	h.Write(buf.Bytes())
+	return normalized, fmt.Sprintf("%016x", h.Sum64())
+}
+
+// isIdentifierChar reports whether char can be part of an unquoted identifier
+// or keyword.
+func isIdentifierChar(char byte) bool {
+	return char == '_' || char >= 0x80 ||
+		(char >= 'a' && char <= 'z') ||
+		(char >= 'A' && char <= 'Z') ||
+		(char >= '0' && char <= '9')
+}
+
+// skipQuoted returns the index just after the closing quote of the quoted
+// string or identifier starting at query[i]. Doubled closing quotes are
+// treated as escaped quotes.
+func skipQuoted(query string, i int, closingQuote byte) int {
+	for j := i + 1; j < len(query); j++ {
+		if query[j] != closingQuote {
+			continue
+		}
+		if j+1 < len(query) && query[j+1] == closingQuote {
+			j++
+			continue
+		}
+		return j + 1
+	}
+	return len(query)
+}
+
+// skipNumber returns the index just after the numeric literal starting at
+// query[i].
+func skipNumber(query string, i int) int {
+	j := i
+	for j < len(query) {
+		char := query[j]
+		switch {
+		case char >= '0' && char <= '9', char == '.':
+			j++
+		case (char == 'e' || char == 'E') && j+1 < len(query):
+			j++
+			if query[j] == '+' || query[j] == '-' {
+				j++
+			}
+		case (char == 'x' || char == 'X') && j == i+1 && query[i] == '0':
+			// 0x hexadecimal literal.
+			j++
+			for j < len(query) && isIdentifierChar(query[j]) {
+				j++
+			}
+			return j
+		default:
+			return j
+		}
+	}
+	return j
+}
+
+// isPlaceholderList reports whether b consists only of '?' placeholders and
+// collapsed (...) lists separated by commas.
+func isPlaceholderList(b []byte) bool {
+	if len(b) == 0 {
+		return false
+	}
+	for _, item := range bytes.Split(b, []byte(", ")) {
+		if string(item) != "?" && string(item) != "(...)" {
+			return false
+		}
+	}
+	return true
+}
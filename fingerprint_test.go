@@ -0,0 +1,108 @@
+package sq
+
+import (
+	"context"
+	"testing"
+
+	"github.com/blink-io/sq/internal/testutil"
+)
+
+func TestFingerprint(t *testing.T) {
+	type TT struct {
+		description    string
+		dialect        string
+		query          string
+		wantNormalized string
+	}
+
+	tests := []TT{{
+		description:    "empty",
+		query:          "",
+		wantNormalized: "",
+	}, {
+		description:    "whitespace and comments",
+		query:          "SELECT a\n\t,  b -- trailing comment\nFROM /* block\ncomment */ tbl",
+		wantNormalized: "SELECT a, b FROM tbl",
+	}, {
+		description:    "literals",
+		query:          "SELECT 'it''s', 12, -3.5e+10, x'ff', TRUE, false, NULL",
+		wantNormalized: "SELECT ?, ?, -?, ?, ?, ?, NULL",
+	}, {
+		description:    "postgres placeholders and casts",
+		dialect:        DialectPostgres,
+		query:          "SELECT $1::TEXT WHERE id = $2",
+		wantNormalized: "SELECT ?::TEXT WHERE id = ?",
+	}, {
+		description:    "sqlite named placeholders",
+		dialect:        DialectSQLite,
+		query:          "SELECT :foo, @bar, $baz, ?1, ?",
+		wantNormalized: "SELECT ?, ?, ?, ?, ?",
+	}, {
+		description:    "sqlserver placeholders and identifiers",
+		dialect:        DialectSQLServer,
+		query:          "SELECT [select], @p1, @@ROWCOUNT",
+		wantNormalized: "SELECT [select], ?, @@ROWCOUNT",
+	}, {
+		description:    "mysql identifiers",
+		dialect:        DialectMySQL,
+		query:          "SELECT `a 1`, \"b 2\" FROM t WHERE c = ?",
+		wantNormalized: "SELECT `a 1`, \"b 2\" FROM t WHERE c = ?",
+	}, {
+		description:    "IN list",
+		dialect:        DialectPostgres,
+		query:          "SELECT * FROM t WHERE id IN ($1, $2, $3) AND name IN ('a')",
+		wantNormalized: "SELECT * FROM t WHERE id IN (...) AND name IN (...)",
+	}, {
+		description:    "row value IN list",
+		query:          "SELECT 1 WHERE (a, b) IN ((?, ?), (?, ?), (?, ?))",
+		wantNormalized: "SELECT ? WHERE (a, b) IN (...)",
+	}, {
+		description:    "multi-row VALUES",
+		dialect:        DialectPostgres,
+		query:          "INSERT INTO t (a, b) VALUES ($1, $2), ($3, $4), ($5, DEFAULT), ($6, DEFAULT)",
+		wantNormalized: "INSERT INTO t (a, b) VALUES (...), (?, DEFAULT)",
+	}, {
+		description:    "function calls",
+		query:          "SELECT COUNT( * ), COALESCE(a, 1) FROM t",
+		wantNormalized: "SELECT COUNT(*), COALESCE(a, ?) FROM t",
+	}}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.description, func(t *testing.T) {
+			t.Parallel()
+			gotNormalized, gotHash := Fingerprint(tt.dialect, tt.query)
+			if diff := testutil.Diff(gotNormalized, tt.wantNormalized); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+			_, wantHash := Fingerprint(tt.dialect, tt.wantNormalized)
+			if diff := testutil.Diff(gotHash, wantHash); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+		})
+	}
+
+	t.Run("same shape", func(t *testing.T) {
+		t.Parallel()
+		ctx := context.Background()
+		var hashes []string
+		for _, ids := range [][]int{{1}, {1, 2}, {4, 5, 6, 7}} {
+			query, args, err := ToSQLContext(ctx, DialectPostgres, Select(Expr("name")).
+				From(Expr("actor")).
+				Where(Expr("actor_id IN ({})", ids)), nil)
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			if len(args) != len(ids) {
+				t.Fatalf(testutil.Callers()+" expected %d args, got %d", len(ids), len(args))
+			}
+			_, hash := Fingerprint(DialectPostgres, query)
+			hashes = append(hashes, hash)
+		}
+		for _, hash := range hashes[1:] {
+			if diff := testutil.Diff(hash, hashes[0]); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+		}
+	})
+}
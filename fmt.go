@@ -176,20 +176,14 @@ func WriteValue(ctx context.Context, dialect string, buf *bytes.Buffer, args *[]
 	if isExpandableSlice(value) {
 		return expandSlice(ctx, dialect, buf, args, params, value)
 	}
+	cast := arrayCast(dialect, value)
 	value, err := preprocessValue(dialect, value)
 	if err != nil {
 		return err
 	}
 	*args = append(*args, value)
-	index := len(*args) - 1
-	switch dialect {
-	case DialectPostgres, DialectSQLite:
-		buf.WriteString("$" + strconv.Itoa(index+1))
-	case DialectSQLServer:
-		buf.WriteString("@p" + strconv.Itoa(index+1))
-	default:
-		buf.WriteString("?")
-	}
+	writePlaceholder(buf, placeholderStyle(dialect), len(*args)-1)
+	buf.WriteString(cast)
 	return nil
 }
 
@@ -232,6 +226,10 @@ func QuoteIdentifier(dialect string, identifier string) string {
 				_, needsQuoting = mysqlKeywords[strings.ToLower(identifier)]
 			case DialectSQLServer:
 				_, needsQuoting = sqlserverKeywords[strings.ToLower(identifier)]
+			default:
+				if d := customDialect(dialect); d != nil {
+					needsQuoting = d.IsReservedWord(strings.ToLower(identifier))
+				}
 			}
 		}
 	}
@@ -244,6 +242,10 @@ func QuoteIdentifier(dialect string, identifier string) string {
 	case DialectSQLServer:
 		return "[" + EscapeQuote(identifier, ']') + "]"
 	default:
+		if d := customDialect(dialect); d != nil {
+			openQuote, closeQuote := d.IdentifierQuotes()
+			return string(openQuote) + EscapeQuote(identifier, closeQuote) + string(closeQuote)
+		}
 		return `"` + EscapeQuote(identifier, '"') + `"`
 	}
 }
@@ -291,6 +293,13 @@ func Sprintf(dialect string, query string, args []any) (string, error) {
 			namedIndices[arg.Name] = i
 		}
 	}
+	style := placeholderStyle(dialect)
+	var customQuote rune
+	if d := customDialect(dialect); d != nil {
+		if openQuote, _ := d.IdentifierQuotes(); openQuote == '`' || openQuote == '[' {
+			customQuote = rune(openQuote)
+		}
+	}
 	runningArgsIndex := 0
 	mustWriteCharAt := -1
 	insideStringOrIdentifier := false
@@ -334,7 +343,7 @@ func Sprintf(dialect string, query string, args []any) (string, error) {
 			continue
 		}
 		// does the current char mark the start of a new string or identifier?
-		if char == '\'' || char == '"' || (char == '`' && dialect == DialectMySQL) || (char == '[' && dialect == DialectSQLServer) {
+		if char == '\'' || char == '"' || (char == '`' && dialect == DialectMySQL) || (char == '[' && dialect == DialectSQLServer) || (customQuote != 0 && char == customQuote) {
 			insideStringOrIdentifier = true
 			openingQuote = char
 			buf.WriteRune(char)
@@ -360,14 +369,14 @@ func Sprintf(dialect string, query string, args []any) (string, error) {
 			continue
 		}
 		// does the current char mark the start of a new parameter name?
-		if (char == '$' && (dialect == DialectSQLite || dialect == DialectPostgres)) ||
-			(char == ':' && dialect == DialectSQLite) ||
-			(char == '@' && (dialect == DialectSQLite || dialect == DialectSQLServer)) {
+		if (char == '$' && (dialect == DialectSQLite || style == PlaceholderDollar)) ||
+			(char == ':' && (dialect == DialectSQLite || style == PlaceholderColon)) ||
+			(char == '@' && (dialect == DialectSQLite || style == PlaceholderAtP)) {
 			paramName = append(paramName, char)
 			continue
 		}
 		// is the current char the anonymous '?' parameter?
-		if char == '?' && dialect != DialectPostgres {
+		if char == '?' && (style != PlaceholderDollar || dialect == DialectSQLite) {
 			// for sqlite, just because we encounter a '?' doesn't mean it
 			// is an anonymous param. sqlite also supports using '?' for
 			// ordinal params (e.g. ?1, ?2, ?3) or named params (?foo,
@@ -413,6 +422,9 @@ func Sprint(dialect string, v any) (string, error) {
 		timestamp             = "2006-01-02 15:04:05"
 		timestampWithTimezone = "2006-01-02 15:04:05.9999999-07:00"
 	)
+	if d := customDialect(dialect); d != nil {
+		return d.FormatLiteral(v)
+	}
 	switch v := v.(type) {
 	case nil:
 		return "NULL", nil
@@ -608,6 +620,7 @@ func isExpandableSlice(value any) bool {
 // expandable slice first by checking it with isExpandableSlice().
 func expandSlice(ctx context.Context, dialect string, buf *bytes.Buffer, args *[]any, params map[string][]int, value any) error {
 	slice := reflect.ValueOf(value)
+	style := placeholderStyle(dialect)
 	var err error
 	for i := 0; i < slice.Len(); i++ {
 		if i > 0 {
@@ -621,14 +634,7 @@ func expandSlice(ctx context.Context, dialect string, buf *bytes.Buffer, args *[
 			}
 			continue
 		}
-		switch dialect {
-		case DialectPostgres, DialectSQLite:
-			buf.WriteString("$" + strconv.Itoa(len(*args)+1))
-		case DialectSQLServer:
-			buf.WriteString("@p" + strconv.Itoa(len(*args)+1))
-		default:
-			buf.WriteString("?")
-		}
+		writePlaceholder(buf, style, len(*args))
 		arg, err = preprocessValue(dialect, arg)
 		if err != nil {
 			return err
@@ -646,55 +652,61 @@ func writeNamedArg(ctx context.Context, dialect string, buf *bytes.Buffer, args
 	if isExpandableSlice(namedArg.Value) {
 		return expandSlice(ctx, dialect, buf, args, params, namedArg.Value)
 	}
+	cast := arrayCast(dialect, namedArg.Value)
 	var err error
 	namedArg.Value, err = preprocessValue(dialect, namedArg.Value)
 	if err != nil {
 		return err
 	}
+	// Dialects with numbered placeholders (other than sqlite and sqlserver,
+	// which support named parameters) reuse the index of the named arg.
+	style := placeholderStyle(dialect)
 	paramIndices := params[namedArg.Name]
 	if len(paramIndices) > 0 {
 		index := paramIndices[0]
-		switch dialect {
-		case DialectSQLite:
+		switch {
+		case dialect == DialectSQLite:
 			(*args)[index] = namedArg
 			buf.WriteString("$" + namedArg.Name)
 			return nil
-		case DialectPostgres:
-			(*args)[index] = namedArg.Value
-			buf.WriteString("$" + strconv.Itoa(index+1))
-			return nil
-		case DialectSQLServer:
+		case dialect == DialectSQLServer:
 			(*args)[index] = namedArg
 			buf.WriteString("@" + namedArg.Name)
 			return nil
+		case style != PlaceholderQuestion:
+			(*args)[index] = namedArg.Value
+			writePlaceholder(buf, style, index)
+			buf.WriteString(cast)
+			return nil
 		default:
 			for _, index := range paramIndices {
 				(*args)[index] = namedArg.Value
 			}
 		}
 	}
-	switch dialect {
-	case DialectSQLite:
+	switch {
+	case dialect == DialectSQLite:
 		*args = append(*args, namedArg)
 		if params != nil {
 			index := len(*args) - 1
 			params[namedArg.Name] = []int{index}
 		}
 		buf.WriteString("$" + namedArg.Name)
-	case DialectPostgres:
-		*args = append(*args, namedArg.Value)
-		index := len(*args) - 1
-		if params != nil {
-			params[namedArg.Name] = []int{index}
-		}
-		buf.WriteString("$" + strconv.Itoa(index+1))
-	case DialectSQLServer:
+	case dialect == DialectSQLServer:
 		*args = append(*args, namedArg)
 		if params != nil {
 			index := len(*args) - 1
 			params[namedArg.Name] = []int{index}
 		}
 		buf.WriteString("@" + namedArg.Name)
+	case style != PlaceholderQuestion:
+		*args = append(*args, namedArg.Value)
+		index := len(*args) - 1
+		if params != nil {
+			params[namedArg.Name] = []int{index}
+		}
+		writePlaceholder(buf, style, index)
+		buf.WriteString(cast)
 	default:
 		*args = append(*args, namedArg.Value)
 		if params != nil {
@@ -724,25 +736,22 @@ func writeOrdinalValue(ctx context.Context, dialect string, buf *bytes.Buffer, a
 	if isExpandableSlice(value) {
 		return expandSlice(ctx, dialect, buf, args, params, value)
 	}
+	cast := arrayCast(dialect, value)
 	var err error
 	value, err = preprocessValue(dialect, value)
 	if err != nil {
 		return err
 	}
-	switch dialect {
-	case DialectSQLite, DialectPostgres, DialectSQLServer:
+	switch style := placeholderStyle(dialect); style {
+	case PlaceholderDollar, PlaceholderColon, PlaceholderAtP:
 		index, ok := ordinalIndices[ordinal]
 		if !ok {
 			*args = append(*args, value)
 			index = len(*args) - 1
 			ordinalIndices[ordinal] = index
 		}
-		switch dialect {
-		case DialectSQLite, DialectPostgres:
-			buf.WriteString("$" + strconv.Itoa(index+1))
-		case DialectSQLServer:
-			buf.WriteString("@p" + strconv.Itoa(index+1))
-		}
+		writePlaceholder(buf, style, index)
+		buf.WriteString(cast)
 	default:
 		err := WriteValue(ctx, dialect, buf, args, params, value)
 		if err != nil {
@@ -756,7 +765,7 @@ func writeOrdinalValue(ctx context.Context, dialect string, buf *bytes.Buffer, a
 // slice).
 func lookupParam(dialect string, args []any, paramName []rune, namedIndices map[string]int, runningArgsIndex int) (paramValue string, err error) {
 	var maybeNum string
-	if paramName[0] == '@' && dialect == DialectSQLServer && len(paramName) >= 2 && (paramName[1] == 'p' || paramName[1] == 'P') {
+	if paramName[0] == '@' && placeholderStyle(dialect) == PlaceholderAtP && len(paramName) >= 2 && (paramName[1] == 'p' || paramName[1] == 'P') {
 		maybeNum = string(paramName[2:])
 	} else {
 		maybeNum = string(paramName[1:])
@@ -1250,3 +1259,50 @@ var sqlserverKeywords = map[string]struct{}{
 	"execute": {}, "primary": {}, "within group": {}, "exists": {}, "print": {},
 	"writetext": {}, "exit": {}, "proc": {},
 }
+
+// DuckDB keyword reference:
+// SELECT keyword_name FROM duckdb_keywords() WHERE keyword_category = 'reserved'
+var duckdbKeywords = map[string]struct{}{
+	"all": {}, "analyse": {}, "analyze": {}, "and": {}, "any": {}, "array": {},
+	"as": {}, "asc": {}, "asymmetric": {}, "both": {}, "case": {}, "cast": {},
+	"check": {}, "collate": {}, "column": {}, "constraint": {}, "create": {},
+	"default": {}, "deferrable": {}, "desc": {}, "describe": {}, "distinct": {},
+	"do": {}, "else": {}, "end": {}, "except": {}, "false": {}, "fetch": {},
+	"for": {}, "foreign": {}, "from": {}, "grant": {}, "group": {}, "having": {},
+	"in": {}, "initially": {}, "intersect": {}, "into": {}, "lateral": {},
+	"leading": {}, "limit": {}, "not": {}, "null": {}, "offset": {}, "on": {},
+	"only": {}, "or": {}, "order": {}, "pivot": {}, "pivot_longer": {},
+	"pivot_wider": {}, "placing": {}, "primary": {}, "qualify": {},
+	"references": {}, "returning": {}, "select": {}, "show": {}, "some": {},
+	"summarize": {}, "symmetric": {}, "table": {}, "then": {}, "to": {},
+	"trailing": {}, "true": {}, "union": {}, "unique": {}, "unpivot": {},
+	"using": {}, "variadic": {}, "when": {}, "where": {}, "window": {},
+	"with": {},
+}
+
+// Oracle keyword reference:
+// https://docs.oracle.com/en/database/oracle/oracle-database/19/sqlrf/Oracle-SQL-Reserved-Words.html
+var oracleKeywords = map[string]struct{}{
+	"access": {}, "add": {}, "all": {}, "alter": {}, "and": {}, "any": {},
+	"as": {}, "asc": {}, "audit": {}, "between": {}, "by": {}, "char": {},
+	"check": {}, "cluster": {}, "column": {}, "column_value": {}, "comment": {},
+	"compress": {}, "connect": {}, "create": {}, "current": {}, "date": {},
+	"decimal": {}, "default": {}, "delete": {}, "desc": {}, "distinct": {},
+	"drop": {}, "else": {}, "exclusive": {}, "exists": {}, "file": {},
+	"float": {}, "for": {}, "from": {}, "grant": {}, "group": {}, "having": {},
+	"identified": {}, "immediate": {}, "in": {}, "increment": {}, "index": {},
+	"initial": {}, "insert": {}, "integer": {}, "intersect": {}, "into": {},
+	"is": {}, "level": {}, "like": {}, "lock": {}, "long": {},
+	"maxextents": {}, "minus": {}, "mlslabel": {}, "mode": {}, "modify": {},
+	"nested_table_id": {}, "noaudit": {}, "nocompress": {}, "not": {},
+	"nowait": {}, "null": {}, "number": {}, "of": {}, "offline": {}, "on": {},
+	"online": {}, "option": {}, "or": {}, "order": {}, "pctfree": {},
+	"prior": {}, "public": {}, "raw": {}, "rename": {}, "resource": {},
+	"revoke": {}, "row": {}, "rowid": {}, "rownum": {}, "rows": {},
+	"select": {}, "session": {}, "set": {}, "share": {}, "size": {},
+	"smallint": {}, "start": {}, "successful": {}, "synonym": {},
+	"sysdate": {}, "table": {}, "then": {}, "to": {}, "trigger": {}, "uid": {},
+	"union": {}, "unique": {}, "update": {}, "user": {}, "validate": {},
+	"values": {}, "varchar": {}, "varchar2": {}, "view": {}, "whenever": {},
+	"where": {}, "with": {},
+}
@@ -18,6 +18,7 @@ var (
 	postgresDSN  = flag.String("postgres", "", "")
 	mysqlDSN     = flag.String("mysql", "", "")
 	sqlserverDSN = flag.String("sqlserver", "", "")
+	update       = flag.Bool("update", false, "update golden files")
 )
 
 func TestWritef(t *testing.T) {
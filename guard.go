@@ -0,0 +1,201 @@
+package sq
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// GuardConfig is the config of a Guard.
+type GuardConfig struct {
+	// LargeTables are the tables that may not be SELECTed from without a
+	// LIMIT (or TOP or FETCH NEXT), unless the query is marked with
+	// AllowFullTable. Table names are matched case insensitively, with or
+	// without their schema.
+	LargeTables []string
+}
+
+// GuardError is the error returned for queries rejected by a Guard.
+type GuardError struct {
+	// Op is the kind of the rejected query: "UPDATE", "DELETE" or "SELECT".
+	Op string
+
+	// Table is the table the query would have read or rewritten in full.
+	Table string
+}
+
+// Error implements the error interface.
+func (e *GuardError) Error() string {
+	if e.Op == "SELECT" {
+		return "sq: guard: SELECT from large table " + e.Table + " without LIMIT (use AllowFullTable() to read every row)"
+	}
+	return "sq: guard: " + e.Op + " " + e.Table + " without WHERE (use AllowFullTable() to " + strings.ToLower(e.Op) + " every row)"
+}
+
+// Check returns a *GuardError if query would rewrite or read a whole table
+// without being marked with AllowFullTable: an UpdateQuery or DeleteQuery
+// without a WHERE clause, or a SelectQuery on one of the LargeTables without
+// a LIMIT. Only the query structs are inspected, raw SQL queries (such as
+// Queryf) are never rejected.
+func (config GuardConfig) Check(query Query) error {
+	switch q := query.(type) {
+	case UpdateQuery:
+		return config.checkDML("UPDATE", q.UpdateTable, q.WherePredicate, q.FullTable)
+	case SQLiteUpdateQuery:
+		return config.checkDML("UPDATE", q.UpdateTable, q.WherePredicate, q.FullTable)
+	case PostgresUpdateQuery:
+		return config.checkDML("UPDATE", q.UpdateTable, q.WherePredicate, q.FullTable)
+	case MySQLUpdateQuery:
+		return config.checkDML("UPDATE", q.UpdateTable, q.WherePredicate, q.FullTable)
+	case SQLServerUpdateQuery:
+		return config.checkDML("UPDATE", q.UpdateTable, q.WherePredicate, q.FullTable)
+	case DuckDBUpdateQuery:
+		return config.checkDML("UPDATE", q.UpdateTable, q.WherePredicate, q.FullTable)
+	case OracleUpdateQuery:
+		return config.checkDML("UPDATE", q.UpdateTable, q.WherePredicate, q.FullTable)
+	case DeleteQuery:
+		return config.checkDelete(q)
+	case SQLiteDeleteQuery:
+		return config.checkDelete(DeleteQuery(q))
+	case PostgresDeleteQuery:
+		return config.checkDelete(DeleteQuery(q))
+	case MySQLDeleteQuery:
+		return config.checkDelete(DeleteQuery(q))
+	case SQLServerDeleteQuery:
+		return config.checkDelete(DeleteQuery(q))
+	case DuckDBDeleteQuery:
+		return config.checkDelete(DeleteQuery(q))
+	case OracleDeleteQuery:
+		return config.checkDelete(DeleteQuery(q))
+	case SelectQuery:
+		return config.checkSelect(q)
+	case SQLiteSelectQuery:
+		return config.checkSelect(SelectQuery(q))
+	case PostgresSelectQuery:
+		return config.checkSelect(SelectQuery(q))
+	case MySQLSelectQuery:
+		return config.checkSelect(SelectQuery(q))
+	case SQLServerSelectQuery:
+		return config.checkSelect(SelectQuery(q))
+	case DuckDBSelectQuery:
+		return config.checkSelect(SelectQuery(q))
+	case OracleSelectQuery:
+		return config.checkSelect(SelectQuery(q))
+	case VariadicQuery:
+		for _, query := range q.Queries {
+			err := config.Check(query)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (config GuardConfig) checkDelete(q DeleteQuery) error {
+	table := q.DeleteTable
+	if table == nil && len(q.DeleteTables) > 0 {
+		table = q.DeleteTables[0]
+	}
+	return config.checkDML("DELETE", table, q.WherePredicate, q.FullTable)
+}
+
+func (config GuardConfig) checkDML(op string, table Table, predicate Predicate, fullTable bool) error {
+	if fullTable || hasPredicate(predicate) {
+		return nil
+	}
+	name, _ := guardTableName(table)
+	return &GuardError{Op: op, Table: name}
+}
+
+func (config GuardConfig) checkSelect(q SelectQuery) error {
+	if len(config.LargeTables) == 0 || q.FullTable {
+		return nil
+	}
+	if q.LimitRows != nil || q.LimitTop != nil || q.LimitTopPercent != nil || q.FetchNextRows != nil {
+		return nil
+	}
+	tables := []Table{q.FromTable}
+	for _, joinTable := range q.JoinTables {
+		tables = append(tables, joinTable.Table)
+	}
+	for _, table := range tables {
+		name, schemaName := guardTableName(table)
+		for _, largeTable := range config.LargeTables {
+			if strings.EqualFold(largeTable, name) || (schemaName != "" && strings.EqualFold(largeTable, schemaName)) {
+				return &GuardError{Op: "SELECT", Table: name}
+			}
+		}
+	}
+	return nil
+}
+
+// hasPredicate reports whether predicate is a predicate that is written
+// out, i.e. not nil nor an empty VariadicPredicate.
+func hasPredicate(predicate Predicate) bool {
+	switch p := predicate.(type) {
+	case nil:
+		return false
+	case VariadicPredicate:
+		for _, predicate := range p.Predicates {
+			if hasPredicate(predicate) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// guardTableName returns the name of table, with and without its schema.
+func guardTableName(table Table) (name, schemaName string) {
+	named, ok := table.(interface{ GetName() string })
+	if !ok || named.GetName() == "" {
+		return fmt.Sprintf("%T", table), ""
+	}
+	name = named.GetName()
+	if schema, ok := table.(interface{ GetSchema() string }); ok && schema.GetSchema() != "" {
+		schemaName = schema.GetSchema() + "." + name
+	}
+	return name, schemaName
+}
+
+// Interceptor returns an Interceptor that rejects the queries failing Check
+// with a *GuardError. FetchExists calls are only checked for UPDATE and
+// DELETE, since EXISTS stops at the first row.
+func (config GuardConfig) Interceptor() Interceptor {
+	return func(ctx context.Context, call *QueryCall, next func(context.Context, *QueryCall) error) error {
+		err := config.Check(call.Query)
+		if guardErr, ok := err.(*GuardError); ok && guardErr.Op == "SELECT" && call.Kind == ExistsCall {
+			err = nil
+		}
+		if err != nil {
+			return err
+		}
+		return next(ctx, call)
+	}
+}
+
+// Guard wraps a DB so that the queries run on it with FetchCursor, FetchOne,
+// FetchAll, FetchExists or Exec (or their Context variants) are rejected if
+// they fail config.Check. Like Chain.Then, Guard keeps the optional
+// interfaces of db.
+func Guard(db DB, config GuardConfig) DB {
+	return Intercept(db, config.Interceptor())
+}
+
+// globalGuard is the Interceptor of the GuardConfig set with SetGlobalGuard.
+var globalGuard atomic.Pointer[Interceptor]
+
+// SetGlobalGuard makes every DB behave as if it were wrapped with Guard,
+// with the given config. Queries are checked by the global guard after the
+// interceptors of the DB. A nil config disables the global guard.
+func SetGlobalGuard(config *GuardConfig) {
+	if config == nil {
+		globalGuard.Store(nil)
+		return
+	}
+	interceptor := config.Interceptor()
+	globalGuard.Store(&interceptor)
+}
@@ -0,0 +1,125 @@
+package sq
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/blink-io/sq/internal/testutil"
+)
+
+func TestGuard(t *testing.T) {
+	ctx := context.Background()
+	t.Run("Check", func(t *testing.T) {
+		config := GuardConfig{LargeTables: []string{"ACTOR"}}
+		tests := []struct {
+			description string
+			query       Query
+			wantErr     *GuardError
+		}{{
+			description: "update without where",
+			query:       SQLite.Update(ACTOR).Set(ACTOR.LAST_NAME.SetString("X")),
+			wantErr:     &GuardError{Op: "UPDATE", Table: "actor"},
+		}, {
+			description: "update with empty where",
+			query:       Postgres.Update(ACTOR).Set(ACTOR.LAST_NAME.SetString("X")).Where(),
+			wantErr:     &GuardError{Op: "UPDATE", Table: "actor"},
+		}, {
+			description: "update with where",
+			query:       Update(ACTOR).Set(ACTOR.LAST_NAME.SetString("X")).Where(ACTOR.ACTOR_ID.EqInt(1)),
+		}, {
+			description: "update full table",
+			query:       MySQL.Update(ACTOR).Set(ACTOR.LAST_NAME.SetString("X")).AllowFullTable(),
+		}, {
+			description: "delete without where",
+			query:       SQLServer.DeleteFrom(ACTOR),
+			wantErr:     &GuardError{Op: "DELETE", Table: "actor"},
+		}, {
+			description: "delete full table",
+			query:       SQLite.DeleteFrom(ACTOR).AllowFullTable(),
+		}, {
+			description: "select large table without limit",
+			query:       SQLite.From(ACTOR).Select(ACTOR.ACTOR_ID),
+			wantErr:     &GuardError{Op: "SELECT", Table: "actor"},
+		}, {
+			description: "select large table in union",
+			query:       Union(SQLite.Select(Expr("1")), SQLite.From(ACTOR).Select(ACTOR.ACTOR_ID)),
+			wantErr:     &GuardError{Op: "SELECT", Table: "actor"},
+		}, {
+			description: "select large table with limit",
+			query:       SQLite.From(ACTOR).Select(ACTOR.ACTOR_ID).Limit(10),
+		}, {
+			description: "select large table with top",
+			query:       SQLServer.From(ACTOR).Select(ACTOR.ACTOR_ID).Top(10),
+		}, {
+			description: "select large table full table",
+			query:       SQLite.From(ACTOR).Select(ACTOR.ACTOR_ID).AllowFullTable(),
+		}, {
+			description: "raw query",
+			query:       SQLite.Queryf("DELETE FROM actor"),
+		}}
+		for _, tt := range tests {
+			err := config.Check(tt.query)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Errorf(testutil.Callers()+" %s: unexpected error %v", tt.description, err)
+				}
+				continue
+			}
+			var guardErr *GuardError
+			if !errors.As(err, &guardErr) {
+				t.Errorf(testutil.Callers()+" %s: expected a *GuardError, got %v", tt.description, err)
+				continue
+			}
+			if diff := testutil.Diff(guardErr, tt.wantErr); diff != "" {
+				t.Error(testutil.Callers(), tt.description, diff)
+			}
+		}
+		if err := (GuardConfig{}).Check(SQLite.From(ACTOR).Select(ACTOR.ACTOR_ID)); err != nil {
+			t.Errorf(testutil.Callers()+" unexpected error %v", err)
+		}
+	})
+
+	t.Run("Guard", func(t *testing.T) {
+		db := Guard(newActorDB(t), GuardConfig{LargeTables: []string{"actor"}})
+		_, err := ExecContext(ctx, db, SQLite.DeleteFrom(ACTOR))
+		var guardErr *GuardError
+		if !errors.As(err, &guardErr) {
+			t.Fatalf(testutil.Callers()+" expected a *GuardError, got %v", err)
+		}
+		if diff := testutil.Diff(err.Error(), "sq: guard: DELETE actor without WHERE (use AllowFullTable() to delete every row)"); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		_, err = FetchAllContext(ctx, db, SQLite.From(ACTOR), actorRowMapper)
+		if !errors.As(err, &guardErr) {
+			t.Errorf(testutil.Callers()+" expected a *GuardError, got %v", err)
+		}
+		// EXISTS stops at the first row.
+		exists, err := FetchExistsContext(ctx, db, SQLite.From(ACTOR).Select(ACTOR.ACTOR_ID))
+		if err != nil || !exists {
+			t.Errorf(testutil.Callers()+" expected the actor table to exist, got %v", err)
+		}
+		result, err := ExecContext(ctx, db, SQLite.DeleteFrom(ACTOR).AllowFullTable())
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if result.RowsAffected != 3 {
+			t.Errorf(testutil.Callers()+" expected 3 rows affected, got %d", result.RowsAffected)
+		}
+	})
+
+	t.Run("SetGlobalGuard", func(t *testing.T) {
+		db := newActorDB(t)
+		SetGlobalGuard(&GuardConfig{})
+		_, err := ExecContext(ctx, db, SQLite.Update(ACTOR).Set(ACTOR.LAST_NAME.SetString("X")))
+		SetGlobalGuard(nil)
+		var guardErr *GuardError
+		if !errors.As(err, &guardErr) {
+			t.Fatalf(testutil.Callers()+" expected a *GuardError, got %v", err)
+		}
+		_, err = ExecContext(ctx, db, SQLite.Update(ACTOR).Set(ACTOR.LAST_NAME.SetString("X")))
+		if err != nil {
+			t.Errorf(testutil.Callers()+" expected the global guard to be disabled, got %v", err)
+		}
+	})
+}
@@ -3,22 +3,118 @@ package sq
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strconv"
+	"sync"
 	"time"
+
+	"github.com/bokwoon95/sq"
 )
 
+// HookOp is the kind of database operation that a HookEvent describes.
+type HookOp int
+
+const (
+	HookQuery     HookOp = iota // DB.QueryContext
+	HookExec                    // DB.ExecContext
+	HookPrepare                 // DB.PrepareContext
+	HookStmtQuery               // a PreparedFetch prepared on the DB is run
+	HookStmtExec                // a PreparedExec prepared on the DB is run
+	HookRowsEnd                 // the rows of a HookQuery or HookStmtQuery have been iterated
+	HookBegin                   // a transaction is begun
+	HookCommit                  // a transaction is committed
+	HookRollback                // a transaction is rolled back
+)
+
+// String returns the name of the operation.
+func (op HookOp) String() string {
+	switch op {
+	case HookQuery:
+		return "query"
+	case HookExec:
+		return "exec"
+	case HookPrepare:
+		return "prepare"
+	case HookStmtQuery:
+		return "stmt_query"
+	case HookStmtExec:
+		return "stmt_exec"
+	case HookRowsEnd:
+		return "rows_end"
+	case HookBegin:
+		return "begin"
+	case HookCommit:
+		return "commit"
+	case HookRollback:
+		return "rollback"
+	}
+	return "HookOp(" + strconv.Itoa(int(op)) + ")"
+}
+
 type (
+	// HookEvent describes a database operation seen by a Hook.
 	HookEvent struct {
-		DB *DB
+		// Op is the kind of operation.
+		Op HookOp
+
+		// DB is the DB that was wrapped with Hooks.
+		DB DB
+
+		// Dialect is the dialect of the operation, if known.
+		Dialect string
+
+		// Query and Args are the SQL query and args of the operation. They
+		// are empty for HookBegin, HookCommit and HookRollback.
+		Query string
+		Args  []any
+
+		// Source is the sq Query that Query was rendered from, if the
+		// operation was started by FetchCursor, FetchOne, FetchAll,
+		// FetchExists or Exec (or their Context variants).
+		Source Query
+
+		// TxOptions are the options passed to BeginTx for HookBegin.
+		TxOptions *sql.TxOptions
 
-		Query     string
-		Args      []any
 		StartTime time.Time
-		Result    sql.Result
-		Err       error
 
+		// Duration is the time the operation took. For HookRowsEnd it is
+		// measured from the StartTime of the query.
+		Duration time.Duration
+
+		Result sql.Result
+
+		// RowCount is the number of rows iterated, for HookRowsEnd.
+		RowCount int64
+
+		// Continued reports whether the operation, if it succeeds, is
+		// continued by a later event that shares its context and Stash: a
+		// HookRowsEnd event for HookQuery and HookStmtQuery, and a HookCommit
+		// or HookRollback event for HookBegin. Hooks that keep something open
+		// across the two events, such as a tracing span, can rely on it being
+		// closed.
+		Continued bool
+
+		Err error
+
+		// Stash lets hooks pass values from BeforeQuery to AfterQuery. The
+		// HookRowsEnd event of a query shares the Stash of the query.
 		Stash map[any]any
+
+		fingerprint *eventFingerprint
+	}
+
+	// eventFingerprint holds the fingerprint of a HookEvent's Query, which is
+	// only computed if a hook asks for it.
+	eventFingerprint struct {
+		once  sync.Once
+		value string
 	}
 
+	// Hook is called around the operations run on a DB wrapped with Hooks.
+	// BeforeQuery and AfterQuery are called for every kind of operation (see
+	// HookEvent.Op), except for HookRowsEnd which is only passed to
+	// AfterQuery, with the context returned by BeforeQuery for the query.
 	Hook interface {
 		BeforeQuery(context.Context, *HookEvent) context.Context
 		AfterQuery(context.Context, *HookEvent)
@@ -30,47 +126,201 @@ type (
 	}
 )
 
+// Fingerprint returns the Fingerprint of the event's Query, or an empty
+// string if the event has no Query. It is computed on the first call.
+func (event *HookEvent) Fingerprint() string {
+	if event.fingerprint == nil {
+		return ""
+	}
+	event.fingerprint.once.Do(func() {
+		_, event.fingerprint.value = Fingerprint(event.Dialect, event.Query)
+	})
+	return event.fingerprint.value
+}
+
+// Hooks wraps a DB so that hooks are called around its operations. The
+// BeforeQuery methods are called in order and the AfterQuery methods in
+// reverse order.
+//
+// HookRowsEnd events are only reported for rows fetched through
+// FetchCursor, FetchOne and FetchAll (and their compiled and prepared
+// variants), since the *sql.Rows returned by QueryContext cannot be
+// observed directly. Likewise HookCommit and HookRollback events are only
+// reported for transactions run with RunInTx; the queries made in such a
+// transaction are passed to the hooks as well.
+//
+// Like Chain.Then, Hooks keeps the optional interfaces of db.
 func Hooks(db DB, hooks ...Hook) interface {
 	DB
 } {
-	return hookDB{DB: db, hooks: hooks}
+	return NewChain(func(db sq.DB) sq.DB {
+		return hookDB{DB: db, hooks: hooks}
+	}).Then(db)
+}
+
+// dbHooks returns a hookDB calling the hooks of every hookDB in db, looking
+// through DB wrappers that expose the DB they wrap with an Unwrap() DB method.
+// The hooks of outer hookDBs come first, so that they are called around the
+// hooks of the hookDBs they wrap, as they would be for the calls made through
+// each hookDB.
+func dbHooks(db DB) (hookDB, bool) {
+	var hooks hookDB
+	for db != nil {
+		if db, ok := db.(hookDB); ok {
+			hooks.DB = db.DB
+			hooks.hooks = append(hooks.hooks, db.hooks...)
+		}
+		unwrapper, ok := db.(interface{ Unwrap() DB })
+		if !ok {
+			break
+		}
+		db = unwrapper.Unwrap()
+	}
+	return hooks, len(hooks.hooks) > 0
 }
 
+// Unwrap returns the underlying DB.
+func (db hookDB) Unwrap() DB { return db.DB }
+
+// GetDialect returns the dialect of the underlying DB.
+func (db hookDB) GetDialect() string { return dbDialect(db.DB) }
+
 func (db hookDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
-	ctx, event := db.beforeQuery(ctx, query, args...)
+	ctx, event := db.beforeQuery(ctx, HookQuery, "", query, args...)
 	rows, err := db.DB.QueryContext(ctx, query, args...)
 	db.afterQuery(ctx, event, nil, err)
+	if err == nil {
+		notifier, _ := ctx.Value(rowsEndContextKey{}).(*rowsEndNotifier)
+		db.onRowsEnd(ctx, event, notifier)
+	}
 	return rows, err
 }
 
 func (db hookDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
-	ctx, event := db.beforeQuery(ctx, query, args...)
+	ctx, event := db.beforeQuery(ctx, HookExec, "", query, args...)
 	res, err := db.DB.ExecContext(ctx, query, args...)
 	db.afterQuery(ctx, event, res, err)
 	return res, err
 }
 
 func (db hookDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
-	return db.DB.PrepareContext(ctx, query)
+	ctx, event := db.beforeQuery(ctx, HookPrepare, "", query)
+	stmt, err := db.DB.PrepareContext(ctx, query)
+	db.afterQuery(ctx, event, nil, err)
+	return stmt, err
+}
+
+// Begin implements the Txer interface.
+func (db hookDB) Begin() (*sql.Tx, error) {
+	return db.BeginTx(context.Background(), nil)
+}
+
+// BeginTx implements the Txer interface.
+func (db hookDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	txer, ok := db.DB.(Txer)
+	if !ok {
+		return nil, fmt.Errorf("sq: %T cannot begin transactions", db.DB)
+	}
+	event := db.newEvent(ctx, HookBegin, "", "")
+	// If the transaction is run with RunInTx, the outermost hooks report its
+	// commit or rollback.
+	begin, ok := ctx.Value(txBeginContextKey{}).(*txBegin)
+	if event != nil {
+		event.TxOptions = opts
+		event.Continued = ok && begin.event == nil
+	}
+	ctx = db.before(ctx, event)
+	if event != nil && event.Continued {
+		begin.hooks, begin.ctx, begin.event = db, ctx, event
+	}
+	tx, err := txer.BeginTx(ctx, opts)
+	db.afterQuery(ctx, event, nil, err)
+	return tx, err
 }
 
-func (db hookDB) beforeQuery(ctx context.Context, query string, args ...any) (context.Context, *HookEvent) {
+// txBeginContextKey is the context key under which RunInTx passes a
+// *txBegin to BeginTx.
+type txBeginContextKey struct{}
+
+// txBegin records the HookBegin event of a transaction run with RunInTx, so
+// that its commit or rollback is reported to the same hooks, with the same
+// context and Stash.
+type txBegin struct {
+	hooks hookDB
+	ctx   context.Context
+	event *HookEvent
+}
+
+// end commits or rolls back tx.
+func (begin *txBegin) end(tx *sql.Tx, commit bool) error {
+	op, end := HookRollback, tx.Rollback
+	if commit {
+		op, end = HookCommit, tx.Commit
+	}
+	if begin.event == nil {
+		return end()
+	}
+	event := begin.hooks.newEvent(begin.ctx, op, begin.event.Dialect, "")
+	event.Stash = begin.event.Stash
+	ctx := begin.hooks.before(begin.ctx, event)
+	err := end()
+	begin.hooks.afterQuery(ctx, event, nil, err)
+	return err
+}
+
+// beforeQuery calls the BeforeQuery method of every hook. If dialect is empty,
+// it is determined from the sq Query in ctx or the DB.
+func (db hookDB) beforeQuery(ctx context.Context, op HookOp, dialect string, query string, args ...any) (context.Context, *HookEvent) {
+	event := db.newEvent(ctx, op, dialect, query, args...)
+	return db.before(ctx, event), event
+}
+
+// newEvent returns the HookEvent of an operation, or nil if db has no hooks.
+func (db hookDB) newEvent(ctx context.Context, op HookOp, dialect string, query string, args ...any) *HookEvent {
 	if len(db.hooks) == 0 {
-		return ctx, nil
+		return nil
 	}
 
 	event := &HookEvent{
-		Query: query,
-		Args:  args,
+		Op:      op,
+		DB:      db.DB,
+		Dialect: dialect,
+		Query:   query,
+		Args:    args,
+		Stash:   make(map[any]any),
 
 		StartTime: time.Now(),
 	}
+	if source, ok := QueryFromContext(ctx); ok && (op == HookQuery || op == HookExec) {
+		event.Source = source
+		if event.Dialect == "" {
+			event.Dialect = queryDialect(db.DB, source)
+		}
+	}
+	if event.Dialect == "" {
+		event.Dialect = dbDialect(db.DB)
+	}
+	if query != "" {
+		event.fingerprint = &eventFingerprint{}
+	}
+	switch op {
+	case HookQuery:
+		_, event.Continued = ctx.Value(rowsEndContextKey{}).(*rowsEndNotifier)
+	case HookStmtQuery:
+		event.Continued = true
+	}
+	return event
+}
 
+// before calls the BeforeQuery method of every hook with event.
+func (db hookDB) before(ctx context.Context, event *HookEvent) context.Context {
+	if event == nil {
+		return ctx
+	}
 	for _, hook := range db.hooks {
 		ctx = hook.BeforeQuery(ctx, event)
 	}
-
-	return ctx, event
+	return ctx
 }
 
 func (db hookDB) afterQuery(
@@ -83,12 +333,31 @@ func (db hookDB) afterQuery(
 		return
 	}
 
+	event.Duration = time.Since(event.StartTime)
 	event.Result = res
 	event.Err = err
 
 	db.afterQueryFromIndex(ctx, event, len(db.hooks)-1)
 }
 
+// onRowsEnd arranges for a HookRowsEnd event to be reported once notifier
+// is notified that the rows of the query described by event have been
+// iterated.
+func (db hookDB) onRowsEnd(ctx context.Context, event *HookEvent, notifier *rowsEndNotifier) {
+	if event == nil || notifier == nil {
+		return
+	}
+	notifier.funcs = append(notifier.funcs, func(rowCount int64, err error) {
+		rowsEvent := *event
+		rowsEvent.Op = HookRowsEnd
+		rowsEvent.Duration = time.Since(event.StartTime)
+		rowsEvent.Result = nil
+		rowsEvent.RowCount = rowCount
+		rowsEvent.Err = err
+		db.afterQueryFromIndex(ctx, &rowsEvent, len(db.hooks)-1)
+	})
+}
+
 var _ Hook = logHook{}
 
 func (db hookDB) afterQueryFromIndex(ctx context.Context, event *HookEvent, hookIndex int) {
@@ -97,6 +366,25 @@ func (db hookDB) afterQueryFromIndex(ctx context.Context, event *HookEvent, hook
 	}
 }
 
+// rowsEndContextKey is the context key under which a *rowsEndNotifier is
+// passed to QueryContext, so that DBs wrapped with Hooks can find out when
+// the returned rows have been iterated.
+type rowsEndContextKey struct{}
+
+type rowsEndNotifier struct {
+	funcs []func(rowCount int64, err error)
+}
+
+func (notifier *rowsEndNotifier) notify(rowCount int64, err error) {
+	if notifier == nil {
+		return
+	}
+	for _, fn := range notifier.funcs {
+		fn(rowCount, err)
+	}
+	notifier.funcs = nil
+}
+
 type logHook struct {
 	logf func(ctx context.Context, format string, args ...any)
 }
@@ -108,8 +396,8 @@ func (l logHook) BeforeQuery(ctx context.Context, event *HookEvent) context.Cont
 
 func (l logHook) AfterQuery(ctx context.Context, event *HookEvent) {
 	if event.Err != nil {
-		l.logf(ctx, "[logHook] Query failed: %s\n", event.Err)
+		l.logf(ctx, "[logHook] %s failed: %s\n", event.Op, event.Err)
 	} else {
-		l.logf(ctx, "[logHook] Query completed in %s\n", event.StartTime)
+		l.logf(ctx, "[logHook] %s completed in %s\n", event.Op, event.Duration)
 	}
 }
@@ -0,0 +1,173 @@
+package sq
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/blink-io/sq/internal/testutil"
+	"github.com/bokwoon95/sq"
+)
+
+// recordingHook records the events it sees as "before <op>" and
+// "after <op>" strings.
+type recordingHook struct {
+	events []string
+	last   HookEvent
+}
+
+type recordingHookContextKey struct{}
+
+func (h *recordingHook) BeforeQuery(ctx context.Context, event *HookEvent) context.Context {
+	h.events = append(h.events, "before "+event.Op.String())
+	event.Stash["op"] = event.Op
+	return context.WithValue(ctx, recordingHookContextKey{}, event.Op)
+}
+
+func (h *recordingHook) AfterQuery(ctx context.Context, event *HookEvent) {
+	entry := "after " + event.Op.String()
+	if event.Op == HookRowsEnd {
+		entry += fmt.Sprintf(" (%d rows)", event.RowCount)
+	}
+	if event.Err != nil {
+		entry += " error"
+	}
+	// The context returned by BeforeQuery and the Stash are passed on.
+	if ctx.Value(recordingHookContextKey{}) != event.Stash["op"] {
+		entry += " (lost context)"
+	}
+	h.events = append(h.events, entry)
+	h.last = *event
+}
+
+func TestHooks(t *testing.T) {
+	newHookedDB := func(t *testing.T) (DB, *recordingHook) {
+		hook := &recordingHook{}
+		return Hooks(newActorDB(t), hook), hook
+	}
+	ctx := context.Background()
+
+	t.Run("fetch and exec", func(t *testing.T) {
+		db, hook := newHookedDB(t)
+		query := SQLite.From(ACTOR).Where(ACTOR.ACTOR_ID.GtInt(0))
+		_, err := FetchAll(db, query, actorRowMapper)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if diff := testutil.Diff(hook.events, []string{"before query", "after query", "after rows_end (3 rows)"}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		if hook.last.Dialect != DialectSQLite {
+			t.Errorf(testutil.Callers()+" expected dialect %q, got %q", DialectSQLite, hook.last.Dialect)
+		}
+		if hook.last.Source == nil {
+			t.Error(testutil.Callers(), "expected the source query")
+		}
+		if _, fingerprint := Fingerprint(DialectSQLite, hook.last.Query); hook.last.Fingerprint() != fingerprint {
+			t.Errorf(testutil.Callers()+" expected fingerprint %q, got %q", fingerprint, hook.last.Fingerprint())
+		}
+
+		hook.events = nil
+		_, err = Exec(db, SQLite.DeleteFrom(ACTOR).Where(ACTOR.ACTOR_ID.EqInt(2)))
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		_, err = Exec(db, SQLite.Queryf("DELETE FROM nonexistent"))
+		if err == nil {
+			t.Fatal(testutil.Callers(), "expected an error")
+		}
+		if diff := testutil.Diff(hook.events, []string{"before exec", "after exec", "before exec", "after exec error"}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("prepared statements", func(t *testing.T) {
+		db, hook := newHookedDB(t)
+		preparedFetch, err := PrepareFetch(db, SQLite.From(ACTOR).Where(ACTOR.ACTOR_ID.Eq(IntParam("id", 0))), actorRowMapper)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		defer preparedFetch.Close()
+		_, err = preparedFetch.FetchAll(Params{"id": 1})
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		preparedExec, err := PrepareExec(db, SQLite.DeleteFrom(ACTOR).Where(ACTOR.ACTOR_ID.Eq(IntParam("id", 0))))
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		defer preparedExec.Close()
+		_, err = preparedExec.Exec(Params{"id": 2})
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if diff := testutil.Diff(hook.events, []string{
+			"before prepare", "after prepare",
+			"before stmt_query", "after stmt_query", "after rows_end (1 rows)",
+			"before prepare", "after prepare",
+			"before stmt_exec", "after stmt_exec",
+		}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		if diff := testutil.Diff(hook.last.Args, []any{sql.Named("id", 2)}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("nested hooks", func(t *testing.T) {
+		inner, outer := &recordingHook{}, &recordingHook{}
+		db := Hooks(Hooks(newActorDB(t), inner), outer)
+		preparedFetch, err := PrepareFetch(db, SQLite.From(ACTOR).Where(ACTOR.ACTOR_ID.Eq(IntParam("id", 0))), actorRowMapper)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		defer preparedFetch.Close()
+		_, err = preparedFetch.FetchAll(Params{"id": 1})
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		for _, hook := range []*recordingHook{inner, outer} {
+			if diff := testutil.Diff(hook.events, []string{
+				"before prepare", "after prepare",
+				"before stmt_query", "after stmt_query", "after rows_end (1 rows)",
+			}); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+		}
+	})
+
+	t.Run("transactions", func(t *testing.T) {
+		db, hook := newHookedDB(t)
+		err := RunInTx(ctx, db, nil, func(ctx context.Context, tx sq.DB) error {
+			// fn is passed the context returned by the hooks for the begin.
+			if op := ctx.Value(recordingHookContextKey{}); op != HookBegin {
+				t.Errorf(testutil.Callers()+" expected %v, got %v", HookBegin, op)
+			}
+			_, err := ExecContext(ctx, tx, SQLite.DeleteFrom(ACTOR).Where(ACTOR.ACTOR_ID.EqInt(1)))
+			return err
+		})
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if diff := testutil.Diff(hook.events, []string{"before begin", "after begin", "before exec", "after exec", "before commit", "after commit"}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+
+		hook.events = nil
+		errRollback := errors.New("rollback")
+		err = RunInTx(ctx, db, nil, func(ctx context.Context, tx sq.DB) error {
+			// Nested transactions run in savepoints of the hooked transaction.
+			return RunInTx(context.Background(), tx, nil, func(ctx context.Context, tx sq.DB) error {
+				return errRollback
+			})
+		})
+		if !errors.Is(err, errRollback) {
+			t.Fatalf(testutil.Callers()+" expected %v, got %v", errRollback, err)
+		}
+		if diff := testutil.Diff(hook.events, []string{"before begin", "after begin", "before rollback", "after rollback"}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+}
@@ -25,6 +25,7 @@ type InsertQuery struct {
 	Conflict ConflictClause
 	// RETURNING
 	ReturningFields []Field
+	ReturningInto   []any
 }
 
 var _ Query = (*InsertQuery)(nil)
@@ -43,16 +44,21 @@ func (q InsertQuery) WriteSQL(ctx context.Context, dialect string, buf *bytes.Bu
 		}
 		q.InsertColumns, q.RowValues = col.insertColumns, col.rowValues
 	}
+	q.RowValues = redactRowValues(ctx, q.InsertColumns, q.RowValues)
 	// WITH
 	if len(q.CTEs) > 0 {
-		if dialect == DialectMySQL {
-			return fmt.Errorf("mysql does not support CTEs with INSERT")
+		if dialect == DialectMySQL || dialect == DialectOracle {
+			return fmt.Errorf("%s does not support CTEs with INSERT", dialect)
 		}
 		err = writeCTEs(ctx, dialect, buf, args, params, q.CTEs)
 		if err != nil {
 			return fmt.Errorf("WITH: %w", err)
 		}
 	}
+	// MERGE (oracle)
+	if dialect == DialectOracle && !q.Conflict.isEmpty() {
+		return q.writeOracleMerge(ctx, dialect, buf, args, params)
+	}
 	// INSERT INTO
 	if q.InsertIgnore {
 		if dialect != DialectMySQL {
@@ -73,7 +79,7 @@ func (q InsertQuery) WriteSQL(ctx context.Context, dialect string, buf *bytes.Bu
 		if dialect == DialectMySQL || dialect == DialectSQLServer {
 			return fmt.Errorf("%s does not allow an alias for the INSERT table", dialect)
 		}
-		buf.WriteString(" AS " + QuoteIdentifier(dialect, alias))
+		buf.WriteString(tableAliasAS(dialect) + QuoteIdentifier(dialect, alias))
 	}
 	// Columns
 	if len(q.InsertColumns) > 0 {
@@ -101,7 +107,15 @@ func (q InsertQuery) WriteSQL(ctx context.Context, dialect string, buf *bytes.Bu
 		}
 	}
 	// VALUES
-	if len(q.RowValues) > 0 {
+	if len(q.RowValues) > 1 && dialect == DialectOracle {
+		// Oracle does not support multi-row VALUES, so the rows are inserted
+		// from a UNION ALL of SELECTs instead.
+		buf.WriteString(" ")
+		err = SelectValues{RowValues: rowValuesToSlices(q.RowValues)}.WriteSQL(ctx, dialect, buf, args, params)
+		if err != nil {
+			return fmt.Errorf("VALUES: %w", err)
+		}
+	} else if len(q.RowValues) > 0 {
 		buf.WriteString(" VALUES ")
 		err = RowValues(q.RowValues).WriteSQL(ctx, dialect, buf, args, params)
 		if err != nil {
@@ -129,11 +143,17 @@ func (q InsertQuery) WriteSQL(ctx context.Context, dialect string, buf *bytes.Bu
 	}
 	// RETURNING
 	if len(q.ReturningFields) > 0 && dialect != DialectSQLServer {
-		if dialect != DialectPostgres && dialect != DialectSQLite && dialect != DialectMySQL {
+		// MariaDB supports RETURNING even though MySQL does not, so it is
+		// still rendered for MySQL. Validate reports it.
+		if dialect != DialectMySQL && !Supports(dialect, CapabilityInsertReturning) {
 			return fmt.Errorf("%s INSERT does not support RETURNING", dialect)
 		}
 		buf.WriteString(" RETURNING ")
-		err = writeFields(ctx, dialect, buf, args, params, q.ReturningFields, true)
+		if dialect == DialectOracle {
+			err = writeReturningInto(ctx, dialect, buf, args, params, q.ReturningFields, q.ReturningInto)
+		} else {
+			err = writeFields(ctx, dialect, buf, args, params, q.ReturningFields, true)
+		}
 		if err != nil {
 			return fmt.Errorf("RETURNING: %w", err)
 		}
@@ -184,10 +204,10 @@ type ConflictClause struct {
 // WriteSQL implements the SQLWriter interface.
 func (c ConflictClause) WriteSQL(ctx context.Context, dialect string, buf *bytes.Buffer, args *[]any, params map[string][]int) error {
 	var err error
-	if c.ConstraintName == "" && len(c.Fields) == 0 && len(c.Resolution) == 0 && !c.DoNothing {
+	if c.isEmpty() {
 		return nil
 	}
-	if dialect != DialectSQLite && dialect != DialectPostgres && dialect != DialectMySQL {
+	if dialect != DialectMySQL && !Supports(dialect, CapabilityOnConflict) {
 		return nil
 	}
 	if dialect == DialectMySQL {
@@ -255,28 +275,153 @@ func (c ConflictClause) WriteSQL(ctx context.Context, dialect string, buf *bytes
 	return nil
 }
 
+func (c ConflictClause) isEmpty() bool {
+	return c.ConstraintName == "" && len(c.Fields) == 0 && len(c.Resolution) == 0 && !c.DoNothing
+}
+
+// writeOracleMerge writes an upsert as an Oracle MERGE statement. The rows
+// being inserted are aliased as EXCLUDED so that DoUpdateSet assignments can
+// refer to them the same way as in a Postgres or SQLite upsert.
+func (q InsertQuery) writeOracleMerge(ctx context.Context, dialect string, buf *bytes.Buffer, args *[]any, params map[string][]int) error {
+	var err error
+	c := q.Conflict
+	if c.ConstraintName != "" {
+		return fmt.Errorf("oracle MERGE does not support ON CONFLICT ON CONSTRAINT")
+	}
+	if len(c.Fields) == 0 {
+		return fmt.Errorf("oracle MERGE requires ON CONFLICT fields")
+	}
+	if c.Predicate != nil {
+		return fmt.Errorf("oracle MERGE does not support ON CONFLICT ... WHERE")
+	}
+	if len(q.ReturningFields) > 0 {
+		return fmt.Errorf("oracle MERGE does not support RETURNING")
+	}
+	if len(q.InsertColumns) == 0 {
+		return fmt.Errorf("oracle MERGE requires INSERT columns")
+	}
+	columns := make([]string, len(q.InsertColumns))
+	for i, field := range q.InsertColumns {
+		if field, ok := field.(interface{ GetName() string }); ok {
+			columns[i] = field.GetName()
+		}
+		if columns[i] == "" {
+			return fmt.Errorf("oracle MERGE: column #%d has no name", i+1)
+		}
+	}
+	// MERGE INTO
+	buf.WriteString("MERGE INTO ")
+	if q.InsertTable == nil {
+		return fmt.Errorf("no table provided to INSERT")
+	}
+	err = q.InsertTable.WriteSQL(ctx, dialect, buf, args, params)
+	if err != nil {
+		return fmt.Errorf("MERGE INTO: %w", err)
+	}
+	target := getAlias(q.InsertTable)
+	if target != "" {
+		buf.WriteString(" " + QuoteIdentifier(dialect, target))
+	} else if table, ok := q.InsertTable.(interface{ GetName() string }); ok {
+		target = table.GetName()
+	}
+	// USING
+	buf.WriteString(" USING (")
+	if len(q.RowValues) > 0 {
+		err = SelectValues{Columns: columns, RowValues: rowValuesToSlices(q.RowValues)}.WriteSQL(ctx, dialect, buf, args, params)
+		if err != nil {
+			return fmt.Errorf("USING: %w", err)
+		}
+	} else if q.SelectQuery != nil {
+		err = q.SelectQuery.WriteSQL(ctx, dialect, buf, args, params)
+		if err != nil {
+			return fmt.Errorf("USING: %w", err)
+		}
+	} else {
+		return fmt.Errorf("InsertQuery missing RowValues and SelectQuery (either one is required)")
+	}
+	buf.WriteString(") EXCLUDED")
+	// ON
+	buf.WriteString(" ON (")
+	for i, field := range c.Fields {
+		if i > 0 {
+			buf.WriteString(" AND ")
+		}
+		err = withPrefix(field, target).WriteSQL(ctx, dialect, buf, args, params)
+		if err != nil {
+			return fmt.Errorf("ON: %w", err)
+		}
+		buf.WriteString(" = ")
+		err = withPrefix(field, "EXCLUDED").WriteSQL(ctx, dialect, buf, args, params)
+		if err != nil {
+			return fmt.Errorf("ON: %w", err)
+		}
+	}
+	buf.WriteString(")")
+	// WHEN MATCHED
+	if len(c.Resolution) > 0 && !c.DoNothing {
+		buf.WriteString(" WHEN MATCHED THEN UPDATE SET ")
+		err = Assignments(c.Resolution).WriteSQL(ctx, dialect, buf, args, params)
+		if err != nil {
+			return fmt.Errorf("WHEN MATCHED THEN UPDATE SET: %w", err)
+		}
+		if c.ResolutionPredicate != nil {
+			buf.WriteString(" WHERE ")
+			switch predicate := c.ResolutionPredicate.(type) {
+			case VariadicPredicate:
+				predicate.Toplevel = true
+				err = predicate.WriteSQL(ctx, dialect, buf, args, params)
+				if err != nil {
+					return fmt.Errorf("WHEN MATCHED THEN UPDATE SET ... WHERE: %w", err)
+				}
+			default:
+				err = c.ResolutionPredicate.WriteSQL(ctx, dialect, buf, args, params)
+				if err != nil {
+					return fmt.Errorf("WHEN MATCHED THEN UPDATE SET ... WHERE: %w", err)
+				}
+			}
+		}
+	}
+	// WHEN NOT MATCHED
+	buf.WriteString(" WHEN NOT MATCHED THEN INSERT (")
+	err = writeFieldsWithPrefix(ctx, dialect, buf, args, params, q.InsertColumns, "", false)
+	if err != nil {
+		return fmt.Errorf("WHEN NOT MATCHED THEN INSERT: %w", err)
+	}
+	buf.WriteString(") VALUES (")
+	err = writeFieldsWithPrefix(ctx, dialect, buf, args, params, q.InsertColumns, "EXCLUDED", false)
+	if err != nil {
+		return fmt.Errorf("WHEN NOT MATCHED THEN INSERT: %w", err)
+	}
+	buf.WriteString(")")
+	return nil
+}
+
+func rowValuesToSlices(rowValues []RowValue) [][]any {
+	slices := make([][]any, len(rowValues))
+	for i, rowValue := range rowValues {
+		slices[i] = rowValue
+	}
+	return slices
+}
+
 // SetFetchableFields implements the Query interface.
 func (q InsertQuery) SetFetchableFields(fields []Field) (query Query, ok bool) {
-	switch q.Dialect {
-	case DialectPostgres, DialectSQLite:
-		if len(q.ReturningFields) == 0 {
-			q.ReturningFields = fields
-			return q, true
-		}
-		return q, false
-	default:
+	if !supportsFetchableReturning(q.Dialect, CapabilityInsertReturning) {
 		return q, false
 	}
+	if len(q.ReturningFields) == 0 {
+		q.ReturningFields = fields
+		return q, true
+	}
+	return q, false
 }
 
 // GetFetchableFields returns the fetchable fields of the query.
 func (q InsertQuery) GetFetchableFields() []Field {
-	switch q.Dialect {
-	case DialectPostgres, DialectSQLite:
-		return q.ReturningFields
-	default:
+	if !supportsFetchableReturning(q.Dialect, CapabilityInsertReturning) {
 		return nil
 	}
+	return q.ReturningFields
 }
 
 // GetDialect implements the Query interface.
@@ -650,3 +795,216 @@ func (q SQLServerInsertQuery) SetDialect(dialect string) SQLServerInsertQuery {
 	q.Dialect = dialect
 	return q
 }
+
+// DuckDBInsertQuery represents a DuckDB INSERT query.
+type DuckDBInsertQuery InsertQuery
+
+var _ Query = (*DuckDBInsertQuery)(nil)
+
+// WriteSQL implements the SQLWriter interface.
+func (q DuckDBInsertQuery) WriteSQL(ctx context.Context, dialect string, buf *bytes.Buffer, args *[]any, params map[string][]int) error {
+	return InsertQuery(q).WriteSQL(ctx, dialect, buf, args, params)
+}
+
+// InsertInto creates a new DuckDBInsertQuery.
+func (b duckdbQueryBuilder) InsertInto(table Table) DuckDBInsertQuery {
+	return DuckDBInsertQuery{
+		Dialect:     DialectDuckDB,
+		CTEs:        b.ctes,
+		InsertTable: table,
+	}
+}
+
+// Columns sets the InsertColumns field of the DuckDBInsertQuery.
+func (q DuckDBInsertQuery) Columns(fields ...Field) DuckDBInsertQuery {
+	q.InsertColumns = fields
+	return q
+}
+
+// Values sets the RowValues field of the DuckDBInsertQuery.
+func (q DuckDBInsertQuery) Values(values ...any) DuckDBInsertQuery {
+	q.RowValues = append(q.RowValues, values)
+	return q
+}
+
+// ColumnValues sets the ColumnMapper field of the DuckDBInsertQuery.
+func (q DuckDBInsertQuery) ColumnValues(columnMapper ColumnMapper) DuckDBInsertQuery {
+	q.ColumnMapper = columnMapper
+	return q
+}
+
+// Select sets the SelectQuery field of the DuckDBInsertQuery.
+func (q DuckDBInsertQuery) Select(query Query) DuckDBInsertQuery {
+	q.SelectQuery = query
+	return q
+}
+
+type duckdbInsertConflict struct{ q *DuckDBInsertQuery }
+
+// OnConflict starts the ON CONFLICT clause of the DuckDBInsertQuery.
+func (q DuckDBInsertQuery) OnConflict(fields ...Field) duckdbInsertConflict {
+	q.Conflict.Fields = fields
+	return duckdbInsertConflict{q: &q}
+}
+
+// OnConflictOnConstraint starts the ON CONFLICT clause of the DuckDBInsertQuery.
+func (q DuckDBInsertQuery) OnConflictOnConstraint(constraintName string) duckdbInsertConflict {
+	q.Conflict.ConstraintName = constraintName
+	return duckdbInsertConflict{q: &q}
+}
+
+// Where adds predicates to the ON CONFLICT clause of the DuckDBInsertQuery.
+func (c duckdbInsertConflict) Where(predicates ...Predicate) duckdbInsertConflict {
+	c.q.Conflict.Predicate = appendPredicates(c.q.Conflict.Predicate, predicates)
+	return c
+}
+
+// DoNothing resolves the ON CONFLICT clause of the DuckDBInsertQuery with DO
+// NOTHING.
+func (c duckdbInsertConflict) DoNothing() DuckDBInsertQuery {
+	c.q.Conflict.DoNothing = true
+	return *c.q
+}
+
+// DoUpdateSet resolves the ON CONFLICT CLAUSE of the DuckDBInsertQuery with DO UPDATE SET.
+func (c duckdbInsertConflict) DoUpdateSet(assignments ...Assignment) DuckDBInsertQuery {
+	c.q.Conflict.Resolution = assignments
+	return *c.q
+}
+
+// Where adds predicates to the DO UPDATE SET clause of the DuckDBInsertQuery.
+func (q DuckDBInsertQuery) Where(predicates ...Predicate) DuckDBInsertQuery {
+	q.Conflict.ResolutionPredicate = appendPredicates(q.Conflict.ResolutionPredicate, predicates)
+	return q
+}
+
+// Returning adds fields to the RETURNING clause of the DuckDBInsertQuery.
+func (q DuckDBInsertQuery) Returning(fields ...Field) DuckDBInsertQuery {
+	q.ReturningFields = append(q.ReturningFields, fields...)
+	return q
+}
+
+// SetFetchableFields implements the Query interface.
+func (q DuckDBInsertQuery) SetFetchableFields(fields []Field) (query Query, ok bool) {
+	return InsertQuery(q).SetFetchableFields(fields)
+}
+
+// GetFetchableFields returns the fetchable fields of the query.
+func (q DuckDBInsertQuery) GetFetchableFields() []Field {
+	return InsertQuery(q).GetFetchableFields()
+}
+
+// GetDialect implements the Query interface.
+func (q DuckDBInsertQuery) GetDialect() string { return q.Dialect }
+
+// SetDialect returns the dialect of the query.
+func (q DuckDBInsertQuery) SetDialect(dialect string) DuckDBInsertQuery {
+	q.Dialect = dialect
+	return q
+}
+
+// OracleInsertQuery represents an Oracle INSERT query.
+type OracleInsertQuery InsertQuery
+
+var _ Query = (*OracleInsertQuery)(nil)
+
+// WriteSQL implements the SQLWriter interface.
+func (q OracleInsertQuery) WriteSQL(ctx context.Context, dialect string, buf *bytes.Buffer, args *[]any, params map[string][]int) error {
+	return InsertQuery(q).WriteSQL(ctx, dialect, buf, args, params)
+}
+
+// InsertInto creates a new OracleInsertQuery.
+func (b oracleQueryBuilder) InsertInto(table Table) OracleInsertQuery {
+	return OracleInsertQuery{
+		Dialect:     DialectOracle,
+		CTEs:        b.ctes,
+		InsertTable: table,
+	}
+}
+
+// Columns sets the InsertColumns field of the OracleInsertQuery.
+func (q OracleInsertQuery) Columns(fields ...Field) OracleInsertQuery {
+	q.InsertColumns = fields
+	return q
+}
+
+// Values sets the RowValues field of the OracleInsertQuery.
+func (q OracleInsertQuery) Values(values ...any) OracleInsertQuery {
+	q.RowValues = append(q.RowValues, values)
+	return q
+}
+
+// ColumnValues sets the ColumnMapper field of the OracleInsertQuery.
+func (q OracleInsertQuery) ColumnValues(columnMapper ColumnMapper) OracleInsertQuery {
+	q.ColumnMapper = columnMapper
+	return q
+}
+
+// Select sets the SelectQuery field of the OracleInsertQuery.
+func (q OracleInsertQuery) Select(query Query) OracleInsertQuery {
+	q.SelectQuery = query
+	return q
+}
+
+type oracleInsertConflict struct{ q *OracleInsertQuery }
+
+// OnConflict starts the ON CONFLICT clause of the OracleInsertQuery. Oracle
+// has no ON CONFLICT clause, so the query is written as a MERGE statement
+// that matches rows on the conflict fields. The rows being inserted are
+// aliased as EXCLUDED.
+func (q OracleInsertQuery) OnConflict(fields ...Field) oracleInsertConflict {
+	q.Conflict.Fields = fields
+	return oracleInsertConflict{q: &q}
+}
+
+// DoNothing resolves the ON CONFLICT clause of the OracleInsertQuery with DO
+// NOTHING (a MERGE without a WHEN MATCHED clause).
+func (c oracleInsertConflict) DoNothing() OracleInsertQuery {
+	c.q.Conflict.DoNothing = true
+	return *c.q
+}
+
+// DoUpdateSet resolves the ON CONFLICT CLAUSE of the OracleInsertQuery with DO
+// UPDATE SET (WHEN MATCHED THEN UPDATE SET).
+func (c oracleInsertConflict) DoUpdateSet(assignments ...Assignment) OracleInsertQuery {
+	c.q.Conflict.Resolution = assignments
+	return *c.q
+}
+
+// Where adds predicates to the DO UPDATE SET clause of the OracleInsertQuery.
+func (q OracleInsertQuery) Where(predicates ...Predicate) OracleInsertQuery {
+	q.Conflict.ResolutionPredicate = appendPredicates(q.Conflict.ResolutionPredicate, predicates)
+	return q
+}
+
+// Returning adds fields to the RETURNING clause of the OracleInsertQuery.
+func (q OracleInsertQuery) Returning(fields ...Field) OracleInsertQuery {
+	q.ReturningFields = append(q.ReturningFields, fields...)
+	return q
+}
+
+// Into adds destinations to the INTO clause of the OracleInsertQuery's
+// RETURNING clause. There must be one destination for every RETURNING field.
+func (q OracleInsertQuery) Into(dest ...any) OracleInsertQuery {
+	q.ReturningInto = append(q.ReturningInto, dest...)
+	return q
+}
+
+// SetFetchableFields implements the Query interface.
+func (q OracleInsertQuery) SetFetchableFields(fields []Field) (query Query, ok bool) {
+	return InsertQuery(q).SetFetchableFields(fields)
+}
+
+// GetFetchableFields returns the fetchable fields of the query.
+func (q OracleInsertQuery) GetFetchableFields() []Field {
+	return InsertQuery(q).GetFetchableFields()
+}
+
+// GetDialect implements the Query interface.
+func (q OracleInsertQuery) GetDialect() string { return q.Dialect }
+
+// SetDialect returns the dialect of the query.
+func (q OracleInsertQuery) SetDialect(dialect string) OracleInsertQuery {
+	q.Dialect = dialect
+	return q
+}
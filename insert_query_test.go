@@ -485,6 +485,76 @@ func TestSQLServerInsertQuery(t *testing.T) {
 	})
 }
 
+func TestDuckDBInsertQuery(t *testing.T) {
+	type ACTOR struct {
+		TableStruct
+		ACTOR_ID    NumberField
+		FIRST_NAME  StringField
+		LAST_NAME   StringField
+		LAST_UPDATE TimeField
+	}
+	a := New[ACTOR]("a")
+
+	t.Run("basic", func(t *testing.T) {
+		t.Parallel()
+		q1 := DuckDB.InsertInto(a).Returning(a.FIRST_NAME).SetDialect("lorem ipsum")
+		if diff := testutil.Diff(q1.GetDialect(), "lorem ipsum"); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		q1 = q1.SetDialect(DialectDuckDB)
+		fields := q1.GetFetchableFields()
+		if diff := testutil.Diff(fields, []Field{a.FIRST_NAME}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		_, ok := q1.SetFetchableFields([]Field{a.LAST_NAME})
+		if ok {
+			t.Fatal(testutil.Callers(), "field should not have been set")
+		}
+		q1.ReturningFields = q1.ReturningFields[:0]
+		_, ok = q1.SetFetchableFields([]Field{a.LAST_NAME})
+		if !ok {
+			t.Fatal(testutil.Callers(), "field should have been set")
+		}
+	})
+
+	t.Run("upsert", func(t *testing.T) {
+		t.Parallel()
+		var tt TestTable
+		tt.item = DuckDB.
+			InsertInto(a).
+			Columns(a.ACTOR_ID, a.FIRST_NAME, a.LAST_NAME).
+			Values(1, "bob", "the builder").
+			Values(2, "alice", "in wonderland").
+			OnConflict(a.ACTOR_ID).
+			DoUpdateSet(
+				a.FIRST_NAME.Set(a.FIRST_NAME.WithPrefix("EXCLUDED")),
+				a.LAST_NAME.Set(a.LAST_NAME.WithPrefix("EXCLUDED")),
+			).
+			Where(a.LAST_NAME.NeString("")).
+			Returning(a.ACTOR_ID)
+		tt.wantQuery = "INSERT INTO actor AS a (actor_id, first_name, last_name)" +
+			" VALUES ($1, $2, $3), ($4, $5, $6)" +
+			" ON CONFLICT (actor_id) DO UPDATE SET" +
+			" first_name = EXCLUDED.first_name, last_name = EXCLUDED.last_name" +
+			" WHERE a.last_name <> $7" +
+			" RETURNING a.actor_id"
+		tt.wantArgs = []any{1, "bob", "the builder", 2, "alice", "in wonderland", ""}
+		tt.assert(t)
+	})
+
+	t.Run("array", func(t *testing.T) {
+		t.Parallel()
+		var tt TestTable
+		tt.item = DuckDB.
+			InsertInto(a).
+			Columns(a.ACTOR_ID, a.FIRST_NAME).
+			Values(1, ArrayValue([]string{"a", "b"}))
+		tt.wantQuery = "INSERT INTO actor AS a (actor_id, first_name) VALUES ($1, $2::JSON)"
+		tt.wantArgs = []any{1, `["a","b"]`}
+		tt.assert(t)
+	})
+}
+
 func TestInsertQuery(t *testing.T) {
 	t.Run("basic", func(t *testing.T) {
 		t.Parallel()
@@ -0,0 +1,189 @@
+package sq
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/bokwoon95/sq"
+)
+
+// QueryKind is the kind of call that a QueryCall describes.
+type QueryKind int
+
+const (
+	FetchCall  QueryKind = iota // FetchCursor, FetchOne and FetchAll
+	ExecCall                    // Exec
+	ExistsCall                  // FetchExists
+)
+
+// String returns the name of the kind.
+func (kind QueryKind) String() string {
+	switch kind {
+	case FetchCall:
+		return "fetch"
+	case ExecCall:
+		return "exec"
+	case ExistsCall:
+		return "exists"
+	}
+	return "QueryKind(" + strconv.Itoa(int(kind)) + ")"
+}
+
+// QueryCall is a call to FetchCursor, FetchOne, FetchAll, FetchExists or Exec
+// (or their Context variants) as seen by an Interceptor, before the query is
+// rendered.
+type QueryCall struct {
+	// Kind is the kind of call.
+	Kind QueryKind
+
+	// Dialect is the dialect that the query will be rendered in.
+	// Interceptors may change it before calling next.
+	Dialect string
+
+	// Query is the query to be run. Interceptors may replace it before
+	// calling next, for example to add predicates or cap the LIMIT.
+	Query Query
+
+	// Results lets an interceptor short-circuit the call: instead of calling
+	// next, it sets Results and returns nil. For a FetchCall, Results must be
+	// a []T of the row mapper's result type; for an ExistsCall, a bool; and
+	// for an ExecCall, a Result.
+	Results any
+
+	callerFile     string
+	callerLine     int
+	callerFunction string
+	onComplete     []func(QueryStats)
+	onResults      []func(results any)
+}
+
+// OnComplete registers fn to be called with the QueryStats of the call once
+// it is complete. For a FetchCall that is once the cursor has been iterated
+// or closed. The StartedAt and TimeTaken of the QueryStats are always
+// filled in.
+func (call *QueryCall) OnComplete(fn func(stats QueryStats)) {
+	call.onComplete = append(call.onComplete, fn)
+}
+
+// OnResults registers fn to be called with the results of the call if it
+// succeeds: a []T for a FetchCall, a bool for an ExistsCall and a Result for
+// an ExecCall. For a FetchCall, the fetched results are buffered and fn is
+// only called if every row was iterated. fn is not called if the call was
+// short-circuited.
+func (call *QueryCall) OnResults(fn func(results any)) {
+	call.onResults = append(call.onResults, fn)
+}
+
+// caller returns the caller of the call, if call is non-nil, or the caller
+// skip frames up otherwise.
+func (call *QueryCall) caller(skip int) (file string, line int, function string) {
+	if call != nil {
+		return call.callerFile, call.callerLine, call.callerFunction
+	}
+	return caller(skip + 1)
+}
+
+// wantsStats reports whether QueryStats must be collected for the call.
+func (call *QueryCall) wantsStats() bool {
+	return call != nil && len(call.onComplete) > 0
+}
+
+// wantsResults reports whether results must be collected for the call.
+func (call *QueryCall) wantsResults() bool {
+	return call != nil && len(call.onResults) > 0
+}
+
+func (call *QueryCall) complete(stats QueryStats) {
+	if call == nil {
+		return
+	}
+	for _, fn := range call.onComplete {
+		fn(stats)
+	}
+}
+
+func (call *QueryCall) deliverResults(results any) {
+	if call == nil {
+		return
+	}
+	for _, fn := range call.onResults {
+		fn(results)
+	}
+}
+
+// Interceptor intercepts the calls to FetchCursor, FetchOne, FetchAll,
+// FetchExists and Exec (and their Context variants) made on a DB wrapped with
+// Intercept. It may inspect or modify call and must either call next to
+// proceed with the call or set call.Results to short-circuit it.
+//
+// Unlike a Hook, which only sees the rendered SQL, an Interceptor sees the
+// sq Query before it is rendered.
+type Interceptor func(ctx context.Context, call *QueryCall, next func(context.Context, *QueryCall) error) error
+
+type interceptDB struct {
+	DB
+	interceptors []Interceptor
+}
+
+// Intercept wraps a DB so that the given interceptors are called around the
+// queries run on it. The first interceptor is the outermost one. If db is
+// itself wrapped with Intercept, its interceptors are called after these
+// ones. Like Chain.Then, Intercept keeps the optional interfaces of db.
+func Intercept(db DB, interceptors ...Interceptor) DB {
+	return NewChain(func(db sq.DB) sq.DB {
+		return interceptDB{DB: db, interceptors: interceptors}
+	}).Then(db)
+}
+
+// dbInterceptors returns the interceptors of db, looking through DB wrappers
+// that expose the DB they wrap with an Unwrap() DB method.
+func dbInterceptors(db DB) []Interceptor {
+	var interceptors []Interceptor
+	for db != nil {
+		if db, ok := db.(interceptDB); ok {
+			interceptors = append(interceptors, db.interceptors...)
+		}
+		unwrapper, ok := db.(interface{ Unwrap() DB })
+		if !ok {
+			break
+		}
+		db = unwrapper.Unwrap()
+	}
+	if guard := globalGuard.Load(); guard != nil {
+		interceptors = append(interceptors, *guard)
+	}
+	return interceptors
+}
+
+// Unwrap returns the underlying DB.
+func (db interceptDB) Unwrap() DB { return db.DB }
+
+// newQueryCall returns a new QueryCall made by the caller skip frames up.
+func newQueryCall(kind QueryKind, dialect string, query Query, skip int) *QueryCall {
+	call := &QueryCall{Kind: kind, Dialect: dialect, Query: query}
+	// The depth of the interceptor chain is unknown, so the caller has to be
+	// determined up front.
+	call.callerFile, call.callerLine, call.callerFunction = caller(skip + 1)
+	return call
+}
+
+// intercept runs call through the interceptors and then run. It reports
+// whether run was called.
+func intercept(ctx context.Context, interceptors []Interceptor, call *QueryCall, run func(context.Context, *QueryCall) error) (ran bool, err error) {
+	handler := func(ctx context.Context, call *QueryCall) error {
+		ran = true
+		return run(ctx, call)
+	}
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], handler
+		handler = func(ctx context.Context, call *QueryCall) error {
+			return interceptor(ctx, call, next)
+		}
+	}
+	err = handler(ctx, call)
+	if err == nil && !ran && call.Results == nil {
+		return false, fmt.Errorf("sq: interceptor neither called next nor set the results of the %s call", call.Kind)
+	}
+	return ran, err
+}
@@ -0,0 +1,109 @@
+package sq
+
+import (
+	"context"
+	"testing"
+
+	"github.com/blink-io/sq/internal/testutil"
+)
+
+func TestIntercept(t *testing.T) {
+	newInterceptedDB := func(t *testing.T, interceptors ...Interceptor) DB {
+		return Intercept(newActorDB(t), interceptors...)
+	}
+	firstNameMapper := func(ctx context.Context, row *Row) string {
+		return row.StringField(ACTOR.FIRST_NAME)
+	}
+	selectActors := SQLite.From(ACTOR).OrderBy(ACTOR.ACTOR_ID)
+
+	t.Run("rewrite query", func(t *testing.T) {
+		var order []string
+		var stats QueryStats
+		db := newInterceptedDB(t,
+			func(ctx context.Context, call *QueryCall, next func(context.Context, *QueryCall) error) error {
+				order = append(order, "outer")
+				call.OnComplete(func(queryStats QueryStats) { stats = queryStats })
+				return next(ctx, call)
+			},
+			func(ctx context.Context, call *QueryCall, next func(context.Context, *QueryCall) error) error {
+				order = append(order, "inner")
+				if q, ok := call.Query.(SQLiteSelectQuery); ok && q.LimitRows == nil {
+					call.Query = q.Limit(2)
+				}
+				return next(ctx, call)
+			},
+		)
+		firstNames, err := FetchAll(db, selectActors, firstNameMapper)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if diff := testutil.Diff(firstNames, []string{"PENELOPE", "NICK"}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		if diff := testutil.Diff(order, []string{"outer", "inner"}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		if stats.RowCount.Int64 != 2 || stats.StartedAt.IsZero() {
+			t.Errorf(testutil.Callers()+" unexpected stats %+v", stats)
+		}
+		wantQuery := "SELECT actor.first_name FROM actor ORDER BY actor.actor_id LIMIT $1"
+		if stats.Query != wantQuery {
+			t.Errorf(testutil.Callers()+" expected %q, got %q", wantQuery, stats.Query)
+		}
+	})
+
+	t.Run("cached results", func(t *testing.T) {
+		cache := make(map[QueryKind]any)
+		var queries int
+		db := newInterceptedDB(t, func(ctx context.Context, call *QueryCall, next func(context.Context, *QueryCall) error) error {
+			if results, ok := cache[call.Kind]; ok {
+				call.Results = results
+				return nil
+			}
+			queries++
+			call.OnResults(func(results any) { cache[call.Kind] = results })
+			return next(ctx, call)
+		})
+		for i := 0; i < 2; i++ {
+			firstNames, err := FetchAll(db, selectActors, firstNameMapper)
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			if diff := testutil.Diff(firstNames, []string{"PENELOPE", "NICK", "ED"}); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+			exists, err := FetchExists(db, SQLite.Select(Expr("1")).From(ACTOR).Where(ACTOR.ACTOR_ID.EqInt(1)))
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			if !exists {
+				t.Error(testutil.Callers(), "expected the actor to exist")
+			}
+			result, err := Exec(db, SQLite.Update(ACTOR).Set(ACTOR.LAST_NAME.SetString("X")).Where(ACTOR.ACTOR_ID.GtInt(1)))
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			if result.RowsAffected != 2 {
+				t.Errorf(testutil.Callers()+" expected 2 rows affected, got %d", result.RowsAffected)
+			}
+		}
+		if queries != 3 {
+			t.Errorf(testutil.Callers()+" expected 3 queries, got %d", queries)
+		}
+		// Cached results of the wrong type are rejected.
+		_, err := FetchAll(db, selectActors, actorRowMapper)
+		if err == nil {
+			t.Error(testutil.Callers(), "expected an error")
+		}
+	})
+
+	t.Run("missing next", func(t *testing.T) {
+		db := newInterceptedDB(t, func(ctx context.Context, call *QueryCall, next func(context.Context, *QueryCall) error) error {
+			return nil
+		})
+		_, err := Exec(db, SQLite.DeleteFrom(ACTOR).Where(ACTOR.ACTOR_ID.EqInt(1)))
+		if err == nil {
+			t.Error(testutil.Callers(), "expected an error")
+		}
+	})
+}
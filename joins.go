@@ -100,7 +100,7 @@ func (join JoinTable) WriteSQL(ctx context.Context, dialect string, buf *bytes.B
 
 	// AS
 	if tableAlias := getAlias(join.Table); tableAlias != "" {
-		buf.WriteString(" AS " + QuoteIdentifier(dialect, tableAlias) + quoteTableColumns(dialect, join.Table))
+		buf.WriteString(tableAliasAS(dialect) + QuoteIdentifier(dialect, tableAlias) + quoteTableColumns(dialect, join.Table))
 	} else if isQuery && dialect != DialectSQLite {
 		return fmt.Errorf("%s %s subquery must have alias", dialect, join.JoinOperator)
 	}
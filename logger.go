@@ -4,10 +4,12 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"log/slog"
+	"math/rand/v2"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -36,6 +38,14 @@ type QueryStats struct {
 	// Query string.
 	Query string
 
+	// NormalizedQuery is the query string with its literal values and
+	// placeholders stripped out (see Fingerprint).
+	NormalizedQuery string
+
+	// Fingerprint is the hash of NormalizedQuery. Queries with the same shape
+	// share the same Fingerprint.
+	Fingerprint string
+
 	// Args slice provided with the query string.
 	Args []any
 
@@ -91,6 +101,13 @@ type LogSettings struct {
 
 	// Include fetched results.
 	IncludeResults int
+
+	// Include the normalized query and its fingerprint.
+	IncludeFingerprint bool
+
+	// Redaction policy applied to the QueryStats. If nil, the policy set
+	// with SetRedactionPolicy is used.
+	Redaction *RedactionPolicy
 }
 
 // Logger represents a logger for the sq package.
@@ -103,6 +120,22 @@ type Logger interface {
 	LogQuery(context.Context, QueryStats)
 }
 
+// dbLogger returns the Logger of db, looking through DB wrappers that expose
+// the DB they wrap with an Unwrap() DB method.
+func dbLogger(db DB) (Logger, bool) {
+	for db != nil {
+		if logger, ok := db.(Logger); ok {
+			return logger, true
+		}
+		unwrapper, ok := db.(interface{ Unwrap() DB })
+		if !ok {
+			break
+		}
+		db = unwrapper.Unwrap()
+	}
+	return nil, false
+}
+
 type logger struct {
 	logger *log.Logger
 	config LoggerConfig
@@ -122,6 +155,9 @@ type LoggerConfig struct {
 	// Show fetched results.
 	ShowResults int
 
+	// Show the query fingerprint.
+	ShowFingerprint bool
+
 	// If true, logs are shown as plaintext (no color).
 	NoColor bool
 
@@ -135,6 +171,52 @@ type LoggerConfig struct {
 	// Explicitly hides arguments when logging the query (only the query
 	// placeholders will be shown).
 	HideArgs bool
+
+	// Redaction policy for the logged arguments and results. If nil, the
+	// policy set with SetRedactionPolicy is used.
+	Redaction *RedactionPolicy
+
+	// If true, each query is logged as a single line JSON object (JSON
+	// lines) instead of colored or plain text. The object always has the
+	// query, fingerprint, args (unless HideArgs), duration, row count,
+	// caller and error of the query, the prefix and flag passed to
+	// NewLogger are ignored.
+	JSON bool
+
+	// Sampling decides which queries are logged and at which level. If nil,
+	// every query is logged.
+	Sampling *LogSampling
+}
+
+// LogSampling are the rules deciding which queries are logged by a Logger
+// created with NewLogger or NewSlogger: failed queries are always logged
+// (at level error), slow queries are always logged (at level warn) and only
+// a fraction of the other queries are logged (at level info, or at the level
+// of the slog.Logger).
+type LogSampling struct {
+	// Queries that take at least SlowThreshold are slow. If zero, no query
+	// is slow.
+	SlowThreshold time.Duration
+
+	// Fraction (between 0 and 1) of the queries that are neither failed nor
+	// slow that are logged, e.g. 0.01 to log 1% of them.
+	SampleRate float64
+}
+
+// sample reports whether the query of queryStats should be logged and at
+// which level. A nil LogSampling logs every query at level info.
+func (sampling *LogSampling) sample(queryStats QueryStats) (level slog.Level, ok bool) {
+	switch {
+	case queryStats.Err != nil:
+		return slog.LevelError, true
+	case sampling == nil:
+		return slog.LevelInfo, true
+	case sampling.SlowThreshold > 0 && queryStats.TimeTaken >= sampling.SlowThreshold:
+		return slog.LevelWarn, true
+	case sampling.SampleRate >= 1 || (sampling.SampleRate > 0 && rand.Float64() < sampling.SampleRate):
+		return slog.LevelInfo, true
+	}
+	return slog.LevelInfo, false
 }
 
 var _ Logger = (*logger)(nil)
@@ -153,6 +235,9 @@ var verboseLogger = NewLogger(os.Stdout, "", log.LstdFlags, LoggerConfig{
 
 // NewLogger returns a new Logger.
 func NewLogger(w io.Writer, prefix string, flag int, config LoggerConfig) Logger {
+	if config.JSON {
+		prefix, flag = "", 0
+	}
 	return &logger{
 		logger: log.New(w, prefix, flag),
 		config: config,
@@ -165,10 +250,28 @@ func (l *logger) LogSettings(ctx context.Context, settings *LogSettings) {
 	settings.IncludeTime = l.config.ShowTimeTaken
 	settings.IncludeCaller = l.config.ShowCaller
 	settings.IncludeResults = l.config.ShowResults
+	settings.IncludeFingerprint = l.config.ShowFingerprint
+	settings.Redaction = l.config.Redaction
+	if l.config.Sampling != nil {
+		settings.IncludeTime = true
+	}
+	if l.config.JSON {
+		settings.IncludeTime = true
+		settings.IncludeCaller = true
+		settings.IncludeFingerprint = true
+	}
 }
 
 // LogQuery implements the Logger interface.
 func (l *logger) LogQuery(ctx context.Context, queryStats QueryStats) {
+	level, ok := l.config.Sampling.sample(queryStats)
+	if !ok {
+		return
+	}
+	if l.config.JSON {
+		l.logJSON(level, queryStats)
+		return
+	}
 	var reset, red, green, blue, purple string
 	envNoColor, _ := strconv.ParseBool(os.Getenv("NO_COLOR"))
 	if !l.config.NoColor && !envNoColor {
@@ -232,6 +335,9 @@ func (l *logger) LogQuery(ctx context.Context, queryStats QueryStats) {
 	if queryStats.Exists.Valid {
 		buf.WriteString(blue + " exists" + reset + "=" + strconv.FormatBool(queryStats.Exists.Bool))
 	}
+	if l.config.ShowFingerprint && queryStats.Fingerprint != "" {
+		buf.WriteString(blue + " fingerprint" + reset + "=" + queryStats.Fingerprint)
+	}
 	if l.config.ShowCaller {
 		buf.WriteString(blue + " caller" + reset + "=" + queryStats.CallerFile + ":" + strconv.Itoa(queryStats.CallerLine) + ":" + filepath.Base(queryStats.CallerFunction))
 	}
@@ -258,6 +364,87 @@ func (l *logger) LogQuery(ctx context.Context, queryStats QueryStats) {
 	}
 }
 
+// jsonLogEntry is the JSON object logged for a query in JSON mode.
+type jsonLogEntry struct {
+	Time            string          `json:"time"`
+	Level           string          `json:"level"`
+	Dialect         string          `json:"dialect"`
+	Query           string          `json:"query"`
+	Args            json.RawMessage `json:"args,omitempty"`
+	NormalizedQuery string          `json:"normalized_query,omitempty"`
+	Fingerprint     string          `json:"fingerprint,omitempty"`
+	DurationMs      float64         `json:"duration_ms"`
+	Rows            *int64          `json:"rows,omitempty"`
+	RowsAffected    *int64          `json:"rows_affected,omitempty"`
+	LastInsertId    *int64          `json:"last_insert_id,omitempty"`
+	Exists          *bool           `json:"exists,omitempty"`
+	Caller          string          `json:"caller,omitempty"`
+	CallerFunction  string          `json:"caller_function,omitempty"`
+	Error           string          `json:"error,omitempty"`
+	Results         string          `json:"results,omitempty"`
+}
+
+// logJSON logs queryStats as a single line JSON object.
+func (l *logger) logJSON(level slog.Level, queryStats QueryStats) {
+	entry := jsonLogEntry{
+		Time:            queryStats.StartedAt.UTC().Format(time.RFC3339Nano),
+		Level:           level.String(),
+		Dialect:         queryStats.Dialect,
+		Query:           queryStats.Query,
+		NormalizedQuery: queryStats.NormalizedQuery,
+		Fingerprint:     queryStats.Fingerprint,
+		DurationMs:      float64(queryStats.TimeTaken) / float64(time.Millisecond),
+	}
+	if !l.config.HideArgs && len(queryStats.Args) > 0 {
+		entry.Args = jsonArgs(queryStats.Args)
+	}
+	if queryStats.RowCount.Valid {
+		entry.Rows = &queryStats.RowCount.Int64
+	}
+	if queryStats.RowsAffected.Valid {
+		entry.RowsAffected = &queryStats.RowsAffected.Int64
+	}
+	if queryStats.LastInsertId.Valid {
+		entry.LastInsertId = &queryStats.LastInsertId.Int64
+	}
+	if queryStats.Exists.Valid {
+		entry.Exists = &queryStats.Exists.Bool
+	}
+	if queryStats.CallerFile != "" {
+		entry.Caller = queryStats.CallerFile + ":" + strconv.Itoa(queryStats.CallerLine)
+		entry.CallerFunction = queryStats.CallerFunction
+	}
+	if queryStats.Err != nil {
+		entry.Error = queryStats.Err.Error()
+	}
+	if l.config.ShowResults > 0 {
+		entry.Results = strings.TrimPrefix(queryStats.Results, "\n")
+	}
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	l.logger.Println(string(b))
+}
+
+// jsonArgs marshals args into a JSON array. Args that cannot be marshalled
+// are written as their Go syntax representation.
+func jsonArgs(args []any) json.RawMessage {
+	if b, err := json.Marshal(args); err == nil {
+		return b
+	}
+	values := make([]json.RawMessage, len(args))
+	for i, arg := range args {
+		b, err := json.Marshal(arg)
+		if err != nil {
+			b, _ = json.Marshal(fmt.Sprintf("%#v", arg))
+		}
+		values[i] = b
+	}
+	b, _ := json.Marshal(values)
+	return b
+}
+
 // Log wraps a DB and adds logging to it.
 func Log(db DB) interface {
 	DB
@@ -355,9 +542,24 @@ func (l *slogger) LogSettings(ctx context.Context, settings *LogSettings) {
 	settings.IncludeTime = l.cfg.ShowTimeTaken
 	settings.IncludeCaller = l.cfg.ShowCaller
 	settings.IncludeResults = l.cfg.ShowResults
+	settings.IncludeFingerprint = l.cfg.ShowFingerprint
+	settings.Redaction = l.cfg.Redaction
+	if l.cfg.Sampling != nil {
+		settings.IncludeTime = true
+	}
 }
 
 func (l *slogger) LogQuery(ctx context.Context, stats QueryStats) {
+	level := l.lv.Level()
+	if l.cfg.Sampling != nil {
+		sampledLevel, ok := l.cfg.Sampling.sample(stats)
+		if !ok {
+			return
+		}
+		if sampledLevel > level {
+			level = sampledLevel
+		}
+	}
 	var execution string = "[OK]"
 	if stats.Err != nil {
 		execution = "[FAIL]"
@@ -372,6 +574,12 @@ func (l *slogger) LogQuery(ctx context.Context, stats QueryStats) {
 	if l.cfg.ShowTimeTaken {
 		attrs = append(attrs, slog.Duration("time_taken", stats.TimeTaken))
 	}
+	if l.cfg.ShowFingerprint {
+		attrs = append(attrs,
+			slog.String("normalized_query", stats.NormalizedQuery),
+			slog.String("fingerprint", stats.Fingerprint),
+		)
+	}
 	if l.cfg.ShowCaller {
 		attrs = append(attrs,
 			slog.String("caller_file", stats.CallerFile),
@@ -379,5 +587,5 @@ func (l *slogger) LogQuery(ctx context.Context, stats QueryStats) {
 			slog.String("caller_function", stats.CallerFunction),
 		)
 	}
-	l.sl.LogAttrs(l.ctx, l.lv.Level(), "Query stats", attrs...)
+	l.sl.LogAttrs(l.ctx, level, "Query stats", attrs...)
 }
@@ -6,6 +6,8 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"log/slog"
+	"strings"
 	"testing"
 	"time"
 
@@ -121,6 +123,14 @@ func TestLogger(t *testing.T) {
 			CallerFunction: "someFunc",
 		},
 		wantOutput: "\x1b[92m[OK]\x1b[0m SELECT 1;\x1b[94m caller\x1b[0m=file.go:22:someFunc\n",
+	}, {
+		description: "ShowFingerprint",
+		config:      LoggerConfig{ShowFingerprint: true},
+		stats: QueryStats{
+			Query:       "SELECT 1",
+			Fingerprint: "af63bd4c8601b7be",
+		},
+		wantOutput: "\x1b[92m[OK]\x1b[0m SELECT 1;\x1b[94m fingerprint\x1b[0m=af63bd4c8601b7be\n",
 	}, {
 		description: "Verbose",
 		config:      LoggerConfig{InterpolateVerbose: true, ShowTimeTaken: true},
@@ -143,6 +153,68 @@ func TestLogger(t *testing.T) {
 		wantOutput: "\x1b[92m[OK]\x1b[0m SELECT 1;" +
 			"\n\x1b[95m----[ Fetched result ]----\x1b[0m" +
 			"\nlorem ipsum dolor sit amet\n",
+	}, {
+		description: "JSON",
+		config:      LoggerConfig{JSON: true},
+		stats: QueryStats{
+			Dialect:         DialectPostgres,
+			Query:           "SELECT * FROM actor WHERE actor_id = $1",
+			Args:            []any{1},
+			NormalizedQuery: "SELECT * FROM actor WHERE actor_id = ?",
+			Fingerprint:     "0123456789abcdef",
+			TimeTaken:       1500 * time.Microsecond,
+			RowCount:        sql.NullInt64{Valid: true, Int64: 1},
+			CallerFile:      "/app/actor.go",
+			CallerLine:      42,
+			CallerFunction:  "app.GetActor",
+		},
+		wantOutput: `{"time":"0001-01-01T00:00:00Z","level":"INFO","dialect":"postgres",` +
+			`"query":"SELECT * FROM actor WHERE actor_id = $1","args":[1],` +
+			`"normalized_query":"SELECT * FROM actor WHERE actor_id = ?","fingerprint":"0123456789abcdef",` +
+			`"duration_ms":1.5,"rows":1,"caller":"/app/actor.go:42","caller_function":"app.GetActor"}` + "\n",
+	}, {
+		description: "JSON err",
+		config:      LoggerConfig{JSON: true, HideArgs: true},
+		stats: QueryStats{
+			Dialect: DialectSQLite,
+			Query:   "DELETE FROM actor WHERE actor_id = ?",
+			Args:    []any{1},
+			Err:     fmt.Errorf("lorem ipsum"),
+		},
+		wantOutput: `{"time":"0001-01-01T00:00:00Z","level":"ERROR","dialect":"sqlite",` +
+			`"query":"DELETE FROM actor WHERE actor_id = ?","duration_ms":0,"error":"lorem ipsum"}` + "\n",
+	}, {
+		description: "Sampling fast",
+		config:      LoggerConfig{Sampling: &LogSampling{SlowThreshold: time.Second}},
+		stats: QueryStats{
+			Query:     "SELECT 1",
+			TimeTaken: time.Millisecond,
+		},
+		wantOutput: "",
+	}, {
+		description: "Sampling err",
+		config:      LoggerConfig{NoColor: true, Sampling: &LogSampling{SlowThreshold: time.Second}},
+		stats: QueryStats{
+			Query: "SELECT 1",
+			Err:   fmt.Errorf("lorem ipsum"),
+		},
+		wantOutput: "[FAIL] SELECT 1; err={lorem ipsum}\n",
+	}, {
+		description: "Sampling slow",
+		config:      LoggerConfig{JSON: true, Sampling: &LogSampling{SlowThreshold: time.Second}},
+		stats: QueryStats{
+			Query:     "SELECT 1",
+			TimeTaken: 2 * time.Second,
+		},
+		wantOutput: `{"time":"0001-01-01T00:00:00Z","level":"WARN","dialect":"","query":"SELECT 1","duration_ms":2000}` + "\n",
+	}, {
+		description: "Sampling SampleRate",
+		config:      LoggerConfig{NoColor: true, Sampling: &LogSampling{SampleRate: 1}},
+		stats: QueryStats{
+			Query:     "SELECT 1",
+			TimeTaken: time.Millisecond,
+		},
+		wantOutput: "[OK] SELECT 1;\n",
 	}}
 
 	for _, tt := range tests {
@@ -151,4 +223,39 @@ func TestLogger(t *testing.T) {
 			assert(t, tt)
 		})
 	}
+
+	t.Run("Slogger Sampling", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		handler := slog.NewTextHandler(buf, &slog.HandlerOptions{
+			ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+				if attr.Key == slog.TimeKey {
+					return slog.Attr{}
+				}
+				return attr
+			},
+		})
+		logger := NewSlogger(slog.New(handler), slog.LevelDebug, LoggerConfig{
+			Sampling: &LogSampling{SlowThreshold: time.Second},
+		})
+		var logSettings LogSettings
+		logger.LogSettings(context.Background(), &logSettings)
+		if !logSettings.IncludeTime {
+			t.Error(testutil.Callers(), "expected IncludeTime to be set")
+		}
+		for _, stats := range []QueryStats{
+			{Query: "SELECT 1", TimeTaken: time.Millisecond},
+			{Query: "SELECT 2", TimeTaken: 2 * time.Second},
+			{Query: "SELECT 3", Err: fmt.Errorf("lorem ipsum")},
+		} {
+			logger.LogQuery(context.Background(), stats)
+		}
+		var levels []string
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			level, _, _ := strings.Cut(line, " ")
+			levels = append(levels, level)
+		}
+		if diff := testutil.Diff(levels, []string{"level=WARN", "level=ERROR"}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
 }
@@ -0,0 +1,401 @@
+package sq
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bokwoon95/sq"
+)
+
+// DefaultMetricsBuckets are the default upper bounds of the latency
+// histogram buckets of a Metrics.
+var DefaultMetricsBuckets = []time.Duration{
+	500 * time.Microsecond,
+	time.Millisecond,
+	2500 * time.Microsecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// MetricsConfig is the config used for a Metrics.
+type MetricsConfig struct {
+	// Upper bounds of the latency histogram buckets, in increasing order. If
+	// empty, DefaultMetricsBuckets is used.
+	Buckets []time.Duration
+
+	// Maximum number of query shapes (fingerprint and caller pairs) tracked.
+	// Queries of further shapes are aggregated into a single shape whose
+	// Fingerprint is "other". If zero, 1000 is used.
+	MaxShapes int
+
+	// Prefix of the metric names served by ServeHTTP. If empty, "sq" is
+	// used.
+	Namespace string
+}
+
+// Metrics aggregates the QueryStats of queries by query shape: the
+// fingerprint of the query (see Fingerprint) and the function and file that
+// ran it. For each shape it keeps a latency histogram, an error counter and
+// a row count.
+//
+// A Metrics is a Logger, and is usually attached to a DB with Measure. It
+// can be published with expvar, since its String method returns its shapes
+// as JSON, and serves its metrics in the Prometheus text format with
+// ServeHTTP:
+//
+//	metrics := sq.NewMetrics(sq.MetricsConfig{})
+//	db := sq.Measure(sqlDB, metrics)
+//	expvar.Publish("sq", metrics)
+//	http.Handle("/metrics", metrics)
+type Metrics struct {
+	config MetricsConfig
+	mu     sync.Mutex
+	shapes map[queryShape]*QueryMetrics
+}
+
+// queryShape is the key under which a Metrics aggregates queries.
+type queryShape struct {
+	dialect        string
+	fingerprint    string
+	callerFile     string
+	callerFunction string
+}
+
+// QueryMetrics are the aggregated metrics of a query shape.
+type QueryMetrics struct {
+	Dialect string
+
+	// Fingerprint and NormalizedQuery are the fingerprint and normalized
+	// query of the shape (see Fingerprint).
+	Fingerprint     string
+	NormalizedQuery string
+
+	// The caller that ran the query. CallerLine is the line of the first
+	// query seen for the shape.
+	CallerFile     string
+	CallerLine     int
+	CallerFunction string
+
+	// Number of times the query was run.
+	Calls int64
+
+	// Number of times the query failed.
+	Errors int64
+
+	// Total number of rows fetched or affected.
+	Rows int64
+
+	// Latencies of the query.
+	TotalTime time.Duration
+	MinTime   time.Duration
+	MaxTime   time.Duration
+
+	// Latency histogram. Buckets[i] is the number of calls that took at
+	// most the i-th bucket bound, and not more than the previous one; the
+	// last element counts the calls above every bound.
+	Buckets []int64
+}
+
+// MeanTime returns the mean latency of the query.
+func (m QueryMetrics) MeanTime() time.Duration {
+	if m.Calls == 0 {
+		return 0
+	}
+	return m.TotalTime / time.Duration(m.Calls)
+}
+
+// Caller returns the caller of the query as "function (file:line)".
+func (m QueryMetrics) Caller() string {
+	return filepath.Base(m.CallerFunction) + " (" + filepath.Base(m.CallerFile) + ":" + strconv.Itoa(m.CallerLine) + ")"
+}
+
+var _ Logger = (*Metrics)(nil)
+
+// NewMetrics returns a new Metrics.
+func NewMetrics(config MetricsConfig) *Metrics {
+	if len(config.Buckets) == 0 {
+		config.Buckets = DefaultMetricsBuckets
+	}
+	if config.MaxShapes == 0 {
+		config.MaxShapes = 1000
+	}
+	if config.Namespace == "" {
+		config.Namespace = "sq"
+	}
+	return &Metrics{
+		config: config,
+		shapes: make(map[queryShape]*QueryMetrics),
+	}
+}
+
+// LogSettings implements the Logger interface. It asks for the time taken,
+// caller and fingerprint of queries.
+func (m *Metrics) LogSettings(ctx context.Context, settings *LogSettings) {
+	settings.IncludeTime = true
+	settings.IncludeCaller = true
+	settings.IncludeFingerprint = true
+}
+
+// LogQuery implements the Logger interface. It records queryStats in the
+// metrics of its shape.
+func (m *Metrics) LogQuery(ctx context.Context, queryStats QueryStats) {
+	shape := queryShape{
+		dialect:        queryStats.Dialect,
+		fingerprint:    queryStats.Fingerprint,
+		callerFile:     queryStats.CallerFile,
+		callerFunction: queryStats.CallerFunction,
+	}
+	if shape.fingerprint == "" {
+		queryStats.NormalizedQuery, shape.fingerprint = Fingerprint(queryStats.Dialect, queryStats.Query)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	metrics := m.shapes[shape]
+	if metrics == nil {
+		if len(m.shapes) >= m.config.MaxShapes {
+			shape = queryShape{fingerprint: "other"}
+			queryStats = QueryStats{Err: queryStats.Err, RowCount: queryStats.RowCount, RowsAffected: queryStats.RowsAffected, TimeTaken: queryStats.TimeTaken}
+			metrics = m.shapes[shape]
+		}
+		if metrics == nil {
+			metrics = &QueryMetrics{
+				Dialect:         queryStats.Dialect,
+				Fingerprint:     shape.fingerprint,
+				NormalizedQuery: queryStats.NormalizedQuery,
+				CallerFile:      queryStats.CallerFile,
+				CallerLine:      queryStats.CallerLine,
+				CallerFunction:  queryStats.CallerFunction,
+				MinTime:         queryStats.TimeTaken,
+				Buckets:         make([]int64, len(m.config.Buckets)+1),
+			}
+			m.shapes[shape] = metrics
+		}
+	}
+	metrics.Calls++
+	if queryStats.Err != nil {
+		metrics.Errors++
+	}
+	metrics.Rows += queryStats.RowCount.Int64 + queryStats.RowsAffected.Int64
+	metrics.TotalTime += queryStats.TimeTaken
+	metrics.MinTime = min(metrics.MinTime, queryStats.TimeTaken)
+	metrics.MaxTime = max(metrics.MaxTime, queryStats.TimeTaken)
+	i := sort.Search(len(m.config.Buckets), func(i int) bool {
+		return queryStats.TimeTaken <= m.config.Buckets[i]
+	})
+	metrics.Buckets[i]++
+}
+
+// Snapshot returns a copy of the metrics of every query shape, in no
+// particular order.
+func (m *Metrics) Snapshot() []QueryMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	snapshot := make([]QueryMetrics, 0, len(m.shapes))
+	for _, metrics := range m.shapes {
+		metricsCopy := *metrics
+		metricsCopy.Buckets = append([]int64(nil), metrics.Buckets...)
+		snapshot = append(snapshot, metricsCopy)
+	}
+	return snapshot
+}
+
+// Reset discards every metric.
+func (m *Metrics) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	clear(m.shapes)
+}
+
+// MetricsOrder is the order of the query shapes returned by TopQueries.
+type MetricsOrder int
+
+const (
+	ByTotalTime MetricsOrder = iota // highest total time first
+	ByMeanTime                      // highest mean time first
+	ByCalls                         // most calls first
+	ByErrors                        // most errors first
+	ByRows                          // most rows first
+)
+
+// TopQueries returns the metrics of the top n query shapes in the given
+// order, like the pg_stat_statements view of Postgres. If n <= 0, every
+// shape is returned.
+func (m *Metrics) TopQueries(n int, order MetricsOrder) []QueryMetrics {
+	snapshot := m.Snapshot()
+	key := func(metrics QueryMetrics) int64 {
+		switch order {
+		case ByMeanTime:
+			return int64(metrics.MeanTime())
+		case ByCalls:
+			return metrics.Calls
+		case ByErrors:
+			return metrics.Errors
+		case ByRows:
+			return metrics.Rows
+		}
+		return int64(metrics.TotalTime)
+	}
+	sort.Slice(snapshot, func(i, j int) bool {
+		ki, kj := key(snapshot[i]), key(snapshot[j])
+		if ki != kj {
+			return ki > kj
+		}
+		// Keep the order stable across calls.
+		if snapshot[i].Fingerprint != snapshot[j].Fingerprint {
+			return snapshot[i].Fingerprint < snapshot[j].Fingerprint
+		}
+		return snapshot[i].Caller() < snapshot[j].Caller()
+	})
+	if n > 0 && n < len(snapshot) {
+		snapshot = snapshot[:n]
+	}
+	return snapshot
+}
+
+// WriteReport writes the top n query shapes by total time to w as a table.
+func (m *Metrics) WriteReport(w io.Writer, n int) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "CALLS\tTOTAL\tMEAN\tMAX\tROWS\tERRORS\tCALLER\tQUERY")
+	for _, metrics := range m.TopQueries(n, ByTotalTime) {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%d\t%d\t%s\t%s\n",
+			metrics.Calls,
+			metrics.TotalTime,
+			metrics.MeanTime(),
+			metrics.MaxTime,
+			metrics.Rows,
+			metrics.Errors,
+			metrics.Caller(),
+			metrics.NormalizedQuery,
+		)
+	}
+	return tw.Flush()
+}
+
+// String returns the metrics of every query shape as a JSON array, ordered
+// by total time. It implements the expvar.Var interface.
+func (m *Metrics) String() string {
+	b, err := json.Marshal(m.TopQueries(0, ByTotalTime))
+	if err != nil {
+		return "null"
+	}
+	return string(b)
+}
+
+// ServeHTTP serves the metrics in the Prometheus text exposition format.
+// Every metric is labeled with the dialect, fingerprint, caller file and
+// caller function of its query shape, which are what the shapes are keyed by.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = m.WritePrometheus(w)
+}
+
+// WritePrometheus writes the metrics to w in the Prometheus text exposition
+// format.
+func (m *Metrics) WritePrometheus(w io.Writer) error {
+	snapshot := m.TopQueries(0, ByTotalTime)
+	namespace := m.config.Namespace
+	buf := &strings.Builder{}
+	labels := make([]string, len(snapshot))
+	for i, metrics := range snapshot {
+		labels[i] = `dialect="` + escapeLabelValue(metrics.Dialect) +
+			`",fingerprint="` + escapeLabelValue(metrics.Fingerprint) +
+			`",file="` + escapeLabelValue(metrics.CallerFile) +
+			`",function="` + escapeLabelValue(metrics.CallerFunction) + `"`
+	}
+
+	name := namespace + "_query_duration_seconds"
+	buf.WriteString("# HELP " + name + " Latency of queries by query shape.\n")
+	buf.WriteString("# TYPE " + name + " histogram\n")
+	for i, metrics := range snapshot {
+		var count int64
+		for j, bound := range m.config.Buckets {
+			count += metrics.Buckets[j]
+			buf.WriteString(name + "_bucket{" + labels[i] + `,le="` + formatFloat(bound.Seconds()) + `"} ` + strconv.FormatInt(count, 10) + "\n")
+		}
+		buf.WriteString(name + "_bucket{" + labels[i] + `,le="+Inf"} ` + strconv.FormatInt(metrics.Calls, 10) + "\n")
+		buf.WriteString(name + "_sum{" + labels[i] + "} " + formatFloat(metrics.TotalTime.Seconds()) + "\n")
+		buf.WriteString(name + "_count{" + labels[i] + "} " + strconv.FormatInt(metrics.Calls, 10) + "\n")
+	}
+	for _, counter := range []struct {
+		name, help string
+		value      func(QueryMetrics) int64
+	}{
+		{"_query_errors_total", "Number of failed queries by query shape.", func(m QueryMetrics) int64 { return m.Errors }},
+		{"_query_rows_total", "Number of rows fetched or affected by query shape.", func(m QueryMetrics) int64 { return m.Rows }},
+	} {
+		name := namespace + counter.name
+		buf.WriteString("# HELP " + name + " " + counter.help + "\n")
+		buf.WriteString("# TYPE " + name + " counter\n")
+		for i, metrics := range snapshot {
+			buf.WriteString(name + "{" + labels[i] + "} " + strconv.FormatInt(counter.value(metrics), 10) + "\n")
+		}
+	}
+	_, err := io.WriteString(w, buf.String())
+	return err
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// escapeLabelValue escapes a Prometheus label value.
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// Measure wraps a DB so that the queries run on it are recorded in metrics.
+// If db has a Logger, queries are still logged with it. Like Chain.Then,
+// Measure keeps the optional interfaces of db.
+func Measure(db DB, metrics *Metrics) DB {
+	return NewChain(func(db sq.DB) sq.DB {
+		mdb := metricsDB{DB: db, metrics: metrics}
+		mdb.logger, _ = dbLogger(db)
+		return mdb
+	}).Then(db)
+}
+
+type metricsDB struct {
+	DB
+	metrics *Metrics
+	logger  Logger
+}
+
+var _ Logger = metricsDB{}
+
+// Unwrap returns the underlying DB.
+func (db metricsDB) Unwrap() DB { return db.DB }
+
+// LogSettings implements the Logger interface.
+func (db metricsDB) LogSettings(ctx context.Context, settings *LogSettings) {
+	if db.logger != nil {
+		db.logger.LogSettings(ctx, settings)
+	}
+	db.metrics.LogSettings(ctx, settings)
+}
+
+// LogQuery implements the Logger interface.
+func (db metricsDB) LogQuery(ctx context.Context, queryStats QueryStats) {
+	db.metrics.LogQuery(ctx, queryStats)
+	if db.logger != nil {
+		db.logger.LogQuery(ctx, queryStats)
+	}
+}
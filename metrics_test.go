@@ -0,0 +1,167 @@
+package sq
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blink-io/sq/internal/testutil"
+)
+
+func TestMetrics(t *testing.T) {
+	ctx := context.Background()
+	newMeasuredDB := func(t *testing.T, config MetricsConfig) (DB, *Metrics) {
+		metrics := NewMetrics(config)
+		return Measure(newActorDB(t), metrics), metrics
+	}
+
+	t.Run("aggregation", func(t *testing.T) {
+		db, metrics := newMeasuredDB(t, MetricsConfig{})
+		for i := 1; i <= 3; i++ {
+			_, err := FetchAllContext(ctx, db, SQLite.From(ACTOR).Where(ACTOR.ACTOR_ID.LeInt(i)), actorRowMapper)
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+		}
+		_, err := ExecContext(ctx, db, SQLite.Update(ACTOR).Set(ACTOR.LAST_NAME.SetString("X")).Where(ACTOR.ACTOR_ID.GtInt(1)))
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		_, err = ExecContext(ctx, db, SQLite.Queryf("DELETE FROM nonexistent"))
+		if err == nil {
+			t.Fatal(testutil.Callers(), "expected an error")
+		}
+
+		top := metrics.TopQueries(0, ByCalls)
+		if len(top) != 3 {
+			t.Fatalf(testutil.Callers()+" expected 3 query shapes, got %d", len(top))
+		}
+		fetch := top[0]
+		if fetch.Calls != 3 || fetch.Rows != 6 || fetch.Errors != 0 {
+			t.Errorf(testutil.Callers()+" unexpected metrics %+v", fetch)
+		}
+		if !strings.HasSuffix(fetch.CallerFunction, "TestMetrics.func2") {
+			t.Errorf(testutil.Callers()+" unexpected caller %q", fetch.CallerFunction)
+		}
+		wantQuery := "SELECT actor.actor_id, actor.first_name, actor.last_name, actor.last_update FROM actor WHERE actor.actor_id <= ?"
+		if fetch.NormalizedQuery != wantQuery {
+			t.Errorf(testutil.Callers()+" expected %q, got %q", wantQuery, fetch.NormalizedQuery)
+		}
+		var buckets int64
+		for _, count := range fetch.Buckets {
+			buckets += count
+		}
+		if buckets != 3 || fetch.MinTime > fetch.MaxTime || fetch.MeanTime() == 0 {
+			t.Errorf(testutil.Callers()+" unexpected latencies %+v", fetch)
+		}
+		if top := metrics.TopQueries(1, ByErrors); len(top) != 1 || top[0].Errors != 1 {
+			t.Errorf(testutil.Callers()+" unexpected top query by errors %+v", top)
+		}
+		if top := metrics.TopQueries(1, ByRows); len(top) != 1 || top[0].Rows != 6 {
+			t.Errorf(testutil.Callers()+" unexpected top query by rows %+v", top)
+		}
+
+		var report strings.Builder
+		err = metrics.WriteReport(&report, 2)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if lines := strings.Split(strings.TrimSpace(report.String()), "\n"); len(lines) != 3 {
+			t.Errorf(testutil.Callers()+" expected a header and 2 rows, got %q", report.String())
+		}
+
+		var shapes []QueryMetrics
+		err = json.Unmarshal([]byte(metrics.String()), &shapes)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if len(shapes) != 3 {
+			t.Errorf(testutil.Callers()+" expected 3 query shapes, got %d", len(shapes))
+		}
+
+		metrics.Reset()
+		if snapshot := metrics.Snapshot(); len(snapshot) != 0 {
+			t.Errorf(testutil.Callers()+" expected no query shapes, got %d", len(snapshot))
+		}
+	})
+
+	t.Run("prometheus", func(t *testing.T) {
+		db, metrics := newMeasuredDB(t, MetricsConfig{
+			Buckets:   []time.Duration{time.Hour},
+			Namespace: "app",
+		})
+		_, err := FetchAllContext(ctx, db, SQLite.From(ACTOR), actorRowMapper)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		recorder := httptest.NewRecorder()
+		metrics.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+		body := recorder.Body.String()
+		top := metrics.TopQueries(1, ByTotalTime)[0]
+		labels := `{dialect="sqlite",fingerprint="` + top.Fingerprint + `",file="` + top.CallerFile + `",function="github.com/blink-io/sq.TestMetrics.func3"`
+		for _, line := range []string{
+			"# TYPE app_query_duration_seconds histogram",
+			"app_query_duration_seconds_bucket" + labels + `,le="3600"} 1`,
+			"app_query_duration_seconds_bucket" + labels + `,le="+Inf"} 1`,
+			"app_query_duration_seconds_count" + labels + "} 1",
+			"# TYPE app_query_errors_total counter",
+			"app_query_errors_total" + labels + "} 0",
+			"app_query_rows_total" + labels + "} 3",
+		} {
+			if !strings.Contains(body, line+"\n") {
+				t.Errorf(testutil.Callers()+" expected %q in:\n%s", line, body)
+			}
+		}
+		// The same query run from another function is another series.
+		func() {
+			_, err = FetchAllContext(ctx, db, SQLite.From(ACTOR), actorRowMapper)
+		}()
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		recorder = httptest.NewRecorder()
+		metrics.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+		if count := strings.Count(recorder.Body.String(), "app_query_duration_seconds_count{"); count != 2 {
+			t.Errorf(testutil.Callers()+" expected 2 series, got %d in:\n%s", count, recorder.Body.String())
+		}
+	})
+
+	t.Run("max shapes", func(t *testing.T) {
+		db, metrics := newMeasuredDB(t, MetricsConfig{MaxShapes: 1})
+		for _, query := range []Query{
+			SQLite.From(ACTOR),
+			SQLite.From(ACTOR).Where(ACTOR.ACTOR_ID.EqInt(1)),
+			SQLite.From(ACTOR).Where(ACTOR.ACTOR_ID.GtInt(1)),
+		} {
+			_, err := FetchAllContext(ctx, db, query, actorRowMapper)
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+		}
+		top := metrics.TopQueries(0, ByCalls)
+		if len(top) != 2 || top[0].Fingerprint != "other" || top[0].Calls != 2 {
+			t.Errorf(testutil.Callers()+" unexpected query shapes %+v", top)
+		}
+	})
+
+	t.Run("logger", func(t *testing.T) {
+		var logged int
+		db := newActorDB(t)
+		logger := &loggerStruct{logQuery: func(context.Context, QueryStats) { logged++ }}
+		metrics := NewMetrics(MetricsConfig{})
+		mdb := Measure(struct {
+			DB
+			Logger
+		}{db, logger}, metrics)
+		_, err := FetchExistsContext(ctx, mdb, SQLite.Queryf("SELECT 1"))
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if logged != 1 || len(metrics.Snapshot()) != 1 {
+			t.Errorf(testutil.Callers()+" expected the query to be logged and measured, got %d logs", logged)
+		}
+	})
+}
@@ -541,6 +541,9 @@ func (vs SelectValues) WriteSQL(ctx context.Context, dialect string, buf *bytes.
 				buf.WriteString(" AS " + QuoteIdentifier(dialect, vs.Columns[j]))
 			}
 		}
+		if dialect == DialectOracle {
+			buf.WriteString(" FROM DUAL")
+		}
 	}
 	return nil
 }
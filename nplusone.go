@@ -0,0 +1,235 @@
+package sq
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bokwoon95/sq"
+)
+
+// DefaultNPlusOneThreshold is the threshold of an NPlusOneScope created with
+// a threshold of zero.
+const DefaultNPlusOneThreshold = 5
+
+// NPlusOneReport reports a query that was run more times than the threshold
+// of an NPlusOneScope from the same call site, which usually means it is run
+// once per row of an earlier query (the N+1 queries problem) and should be
+// replaced by a single query fetching all the rows at once.
+type NPlusOneReport struct {
+	Dialect         string
+	Fingerprint     string
+	NormalizedQuery string
+	CallerFile      string
+	CallerLine      int
+	CallerFunction  string
+
+	// Count is the number of times the query was run from the call site.
+	Count int
+}
+
+// String returns a one-line description of the report.
+func (report NPlusOneReport) String() string {
+	return "N+1 queries: " + report.NormalizedQuery + " ran " + strconv.Itoa(report.Count) + " times from " +
+		filepath.Base(report.CallerFunction) + " (" + report.CallerFile + ":" + strconv.Itoa(report.CallerLine) + ")"
+}
+
+// NPlusOneScope counts the queries run with a context, by fingerprint and call
+// site, on DBs wrapped with DetectNPlusOne.
+type NPlusOneScope struct {
+	threshold int
+	parent    *NPlusOneScope
+
+	mu     sync.Mutex
+	counts map[nPlusOneKey]*NPlusOneReport
+}
+
+type nPlusOneKey struct {
+	fingerprint string
+	callerFile  string
+	callerLine  int
+}
+
+// nPlusOneScopeContextKey is the context key under which an *NPlusOneScope
+// is stored.
+type nPlusOneScopeContextKey struct{}
+
+// NewNPlusOneScope returns a copy of ctx that carries a new NPlusOneScope,
+// typically for the duration of an HTTP request or a test. Queries run with
+// the returned context from the same call site more than threshold times are
+// reported by the scope (if threshold is zero, DefaultNPlusOneThreshold is
+// used). Scopes can be nested, queries are counted by every enclosing scope.
+func NewNPlusOneScope(ctx context.Context, threshold int) (context.Context, *NPlusOneScope) {
+	if threshold <= 0 {
+		threshold = DefaultNPlusOneThreshold
+	}
+	scope := &NPlusOneScope{
+		threshold: threshold,
+		counts:    make(map[nPlusOneKey]*NPlusOneReport),
+	}
+	scope.parent, _ = ctx.Value(nPlusOneScopeContextKey{}).(*NPlusOneScope)
+	return context.WithValue(ctx, nPlusOneScopeContextKey{}, scope), scope
+}
+
+// record counts a query run from the given call site.
+func (scope *NPlusOneScope) record(event *HookEvent, callerFile string, callerLine int, callerFunction string) {
+	for ; scope != nil; scope = scope.parent {
+		key := nPlusOneKey{fingerprint: event.Fingerprint(), callerFile: callerFile, callerLine: callerLine}
+		scope.mu.Lock()
+		report := scope.counts[key]
+		if report == nil {
+			normalizedQuery, _ := Fingerprint(event.Dialect, event.Query)
+			report = &NPlusOneReport{
+				Dialect:         event.Dialect,
+				Fingerprint:     event.Fingerprint(),
+				NormalizedQuery: normalizedQuery,
+				CallerFile:      callerFile,
+				CallerLine:      callerLine,
+				CallerFunction:  callerFunction,
+			}
+			scope.counts[key] = report
+		}
+		report.Count++
+		scope.mu.Unlock()
+	}
+}
+
+// Reports returns the queries run more times than the threshold of the scope
+// from the same call site so far, the most frequent first.
+func (scope *NPlusOneScope) Reports() []NPlusOneReport {
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+	var reports []NPlusOneReport
+	for _, report := range scope.counts {
+		if report.Count > scope.threshold {
+			reports = append(reports, *report)
+		}
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].Count != reports[j].Count {
+			return reports[i].Count > reports[j].Count
+		}
+		if reports[i].CallerFile != reports[j].CallerFile {
+			return reports[i].CallerFile < reports[j].CallerFile
+		}
+		return reports[i].CallerLine < reports[j].CallerLine
+	})
+	return reports
+}
+
+// DetectNPlusOne wraps a DB so that the queries run on it are counted by the
+// NPlusOneScope of their context, if any. The call site of a query is the
+// first function on the stack outside of sq and database/sql (test files of
+// sq count as call sites). Like Chain.Then, DetectNPlusOne keeps the optional
+// interfaces of db.
+func DetectNPlusOne(db DB) DB {
+	return Hooks(db, nPlusOneHook{})
+}
+
+type nPlusOneHook struct{}
+
+var _ Hook = nPlusOneHook{}
+
+func (nPlusOneHook) BeforeQuery(ctx context.Context, event *HookEvent) context.Context {
+	switch event.Op {
+	case HookQuery, HookExec, HookStmtQuery, HookStmtExec:
+	default:
+		return ctx
+	}
+	scope, _ := ctx.Value(nPlusOneScopeContextKey{}).(*NPlusOneScope)
+	if scope == nil {
+		return ctx
+	}
+	callerFile, callerLine, callerFunction := callSite()
+	scope.record(event, callerFile, callerLine, callerFunction)
+	return ctx
+}
+
+func (nPlusOneHook) AfterQuery(context.Context, *HookEvent) {}
+
+var (
+	blinkPkgPath   = reflect.TypeOf(hookDB{}).PkgPath()
+	bokwoonPkgPath = reflect.TypeOf((*sq.DB)(nil)).Elem().PkgPath()
+)
+
+// callSite returns the first caller on the stack outside of sq and
+// database/sql.
+func callSite() (file string, line int, function string) {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		pkgPath := framePkgPath(frame.Function)
+		internal := pkgPath == "database/sql" || pkgPath == bokwoonPkgPath ||
+			(pkgPath == blinkPkgPath && !strings.HasSuffix(frame.File, "_test.go"))
+		if !internal {
+			return frame.File, frame.Line, frame.Function
+		}
+		if !more {
+			return frame.File, frame.Line, frame.Function
+		}
+	}
+}
+
+// framePkgPath returns the package path of a function name as reported by
+// runtime.Frame, e.g. "github.com/bokwoon95/sq" for
+// "github.com/bokwoon95/sq.FetchAll[...]".
+func framePkgPath(function string) string {
+	slash := strings.LastIndex(function, "/")
+	if slash < 0 {
+		slash = 0
+	}
+	if dot := strings.Index(function[slash:], "."); dot >= 0 {
+		return function[:slash+dot]
+	}
+	return function
+}
+
+// NPlusOneMiddleware returns an HTTP middleware that runs each request in a
+// new NPlusOneScope with the given threshold, and passes the reports of the
+// scope to report once the request has been handled (report is not called
+// if there are none).
+func NPlusOneMiddleware(threshold int, report func(r *http.Request, reports []NPlusOneReport)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, scope := NewNPlusOneScope(r.Context(), threshold)
+			r = r.WithContext(ctx)
+			next.ServeHTTP(w, r)
+			if reports := scope.Reports(); len(reports) > 0 {
+				report(r, reports)
+			}
+		})
+	}
+}
+
+// NPlusOneT is the subset of testing.TB used by CheckNPlusOne.
+type NPlusOneT interface {
+	Helper()
+	Cleanup(func())
+	Errorf(format string, args ...any)
+}
+
+// CheckNPlusOne returns a copy of ctx that carries a new NPlusOneScope with
+// the given threshold, and fails the test once it has finished if any query
+// run with it on a DB wrapped with DetectNPlusOne exceeded the threshold.
+//
+//	ctx := sq.CheckNPlusOne(t, context.Background(), 3)
+//	films, err := listFilmsWithActors(ctx, db)
+func CheckNPlusOne(t NPlusOneT, ctx context.Context, threshold int) context.Context {
+	t.Helper()
+	ctx, scope := NewNPlusOneScope(ctx, threshold)
+	t.Cleanup(func() {
+		t.Helper()
+		for _, report := range scope.Reports() {
+			t.Errorf("%s", report)
+		}
+	})
+	return ctx
+}
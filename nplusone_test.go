@@ -0,0 +1,113 @@
+package sq
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/blink-io/sq/internal/testutil"
+)
+
+type nPlusOneT struct {
+	cleanups []func()
+	errors   []string
+}
+
+func (t *nPlusOneT) Helper()          {}
+func (t *nPlusOneT) Cleanup(f func()) { t.cleanups = append(t.cleanups, f) }
+func (t *nPlusOneT) Errorf(format string, args ...any) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestNPlusOne(t *testing.T) {
+	db := DetectNPlusOne(newActorDB(t))
+	fetchActor := func(ctx context.Context, actorID int) {
+		_, err := FetchOneContext(ctx, db, SQLite.From(ACTOR).Where(ACTOR.ACTOR_ID.EqInt(actorID)), actorRowMapper)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+	}
+
+	t.Run("scope", func(t *testing.T) {
+		ctx, scope := NewNPlusOneScope(context.Background(), 2)
+		for actorID := 1; actorID <= 3; actorID++ {
+			_, err := FetchOneContext(ctx, db, SQLite.From(ACTOR).Where(ACTOR.ACTOR_ID.EqInt(actorID)), actorRowMapper)
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+		}
+		// Below the threshold.
+		for i := 0; i < 2; i++ {
+			_, err := ExecContext(ctx, db, SQLite.Update(ACTOR).Set(ACTOR.LAST_NAME.SetString("X")).Where(ACTOR.ACTOR_ID.EqInt(1)))
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+		}
+		// Same query, different call site.
+		fetchActor(ctx, 1)
+		reports := scope.Reports()
+		if len(reports) != 1 {
+			t.Fatalf(testutil.Callers()+" expected 1 report, got %+v", reports)
+		}
+		report := reports[0]
+		if report.Count != 3 || !strings.HasSuffix(report.CallerFile, "nplusone_test.go") || !strings.HasSuffix(report.CallerFunction, "TestNPlusOne.func2") {
+			t.Errorf(testutil.Callers()+" unexpected report %+v", report)
+		}
+		wantQuery := "SELECT actor.actor_id, actor.first_name, actor.last_name, actor.last_update FROM actor WHERE actor.actor_id = ?"
+		if report.NormalizedQuery != wantQuery {
+			t.Errorf(testutil.Callers()+" expected %q, got %q", wantQuery, report.NormalizedQuery)
+		}
+	})
+
+	t.Run("nested scopes", func(t *testing.T) {
+		ctx, outer := NewNPlusOneScope(context.Background(), 1)
+		ctx, inner := NewNPlusOneScope(ctx, 5)
+		for actorID := 1; actorID <= 3; actorID++ {
+			fetchActor(ctx, actorID)
+		}
+		if reports := outer.Reports(); len(reports) != 1 || reports[0].Count != 3 {
+			t.Errorf(testutil.Callers()+" unexpected outer reports %+v", reports)
+		}
+		if reports := inner.Reports(); len(reports) != 0 {
+			t.Errorf(testutil.Callers()+" unexpected inner reports %+v", reports)
+		}
+	})
+
+	t.Run("no scope", func(t *testing.T) {
+		for actorID := 1; actorID <= 3; actorID++ {
+			fetchActor(context.Background(), actorID)
+		}
+	})
+
+	t.Run("CheckNPlusOne", func(t *testing.T) {
+		fakeT := &nPlusOneT{}
+		ctx := CheckNPlusOne(fakeT, context.Background(), 2)
+		for actorID := 1; actorID <= 3; actorID++ {
+			fetchActor(ctx, actorID)
+		}
+		for _, cleanup := range fakeT.cleanups {
+			cleanup()
+		}
+		if len(fakeT.errors) != 1 || !strings.HasPrefix(fakeT.errors[0], "N+1 queries: SELECT actor.actor_id") {
+			t.Errorf(testutil.Callers()+" unexpected errors %q", fakeT.errors)
+		}
+	})
+
+	t.Run("NPlusOneMiddleware", func(t *testing.T) {
+		var reported []NPlusOneReport
+		handler := NPlusOneMiddleware(2, func(r *http.Request, reports []NPlusOneReport) {
+			reported = append(reported, reports...)
+		})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for actorID := 1; actorID <= 3; actorID++ {
+				fetchActor(r.Context(), actorID)
+			}
+		}))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+		if len(reported) != 1 || reported[0].Count != 3 {
+			t.Errorf(testutil.Callers()+" unexpected reports %+v", reported)
+		}
+	})
+}
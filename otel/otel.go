@@ -0,0 +1,274 @@
+// Package otel traces the queries run with sq using OpenTelemetry.
+//
+// NewHook returns an sq.Hook that starts a span per query, following the
+// OpenTelemetry semantic conventions for database client calls:
+//
+//	db, err := sql.Open("sqlite3", "file.db")
+//	...
+//	tdb := otel.Trace(db, otel.Config{FingerprintStatement: true})
+//	actors, err := sq.FetchAll(tdb, sq.From(ACTOR), actorRowMapper)
+package otel
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"github.com/blink-io/sq"
+	global "go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope name of the tracer.
+const ScopeName = "github.com/blink-io/sq/otel"
+
+// Attribute keys that are not part of the semantic conventions used.
+const (
+	// RowsAffectedKey is the number of rows affected by an exec.
+	RowsAffectedKey = attribute.Key("db.rows_affected")
+
+	// ReturnedRowsKey is the number of rows returned by a query. It is only
+	// recorded for queries whose rows are iterated by sq (FetchCursor,
+	// FetchOne, FetchAll and their variants).
+	ReturnedRowsKey = attribute.Key("db.response.returned_rows")
+
+	// TablesKey lists the tables of a query that references more than one
+	// table. A query referencing a single table records it as db.sql.table.
+	TablesKey = attribute.Key("db.sql.tables")
+
+	// FingerprintKey is the hash of the normalized query (see
+	// sq.Fingerprint), which groups queries of the same shape.
+	FingerprintKey = attribute.Key("db.sq.fingerprint")
+
+	// OpKey is the sq.HookOp of the span, such as "query", "exec" or
+	// "stmt_query".
+	OpKey = attribute.Key("db.sq.op")
+)
+
+// Config configures the Hook returned by NewHook.
+type Config struct {
+	// TracerProvider provides the tracer. If nil, the global TracerProvider
+	// is used.
+	TracerProvider trace.TracerProvider
+
+	// FingerprintStatement records the normalized query (see
+	// sq.Fingerprint), with its literals replaced by placeholders, as
+	// db.statement instead of the query itself. Use it when literals may
+	// contain sensitive data.
+	FingerprintStatement bool
+
+	// OmitStatement leaves out db.statement altogether.
+	OmitStatement bool
+
+	// Attributes are added to every span, for example db.name or
+	// server.address.
+	Attributes []attribute.KeyValue
+}
+
+// Hook is an sq.Hook that traces the operations of a DB.
+//
+// Every query, exec and prepare gets a span of kind client. The span of a
+// query whose rows are iterated by sq is ended once the rows have been
+// iterated, so that it records the number of rows returned.
+//
+// A transaction run with sq.RunInTx gets a span from its begin until its
+// commit or rollback. The context passed to the function run in the
+// transaction carries that span, so the statements run with it become its
+// children.
+type Hook struct {
+	tracer trace.Tracer
+	config Config
+}
+
+var _ sq.Hook = (*Hook)(nil)
+
+// NewHook returns a new Hook.
+func NewHook(config Config) *Hook {
+	provider := config.TracerProvider
+	if provider == nil {
+		provider = global.GetTracerProvider()
+	}
+	return &Hook{
+		tracer: provider.Tracer(ScopeName, trace.WithSchemaURL(semconv.SchemaURL)),
+		config: config,
+	}
+}
+
+// Trace wraps db with a Hook created with config.
+func Trace(db sq.DB, config Config) sq.DB {
+	return sq.Hooks(db, NewHook(config))
+}
+
+// spanKey is the key under which the span of an event is stashed.
+type spanKey struct{}
+
+// BeforeQuery implements the sq.Hook interface.
+func (h *Hook) BeforeQuery(ctx context.Context, event *sq.HookEvent) context.Context {
+	var name string
+	attrs := make([]attribute.KeyValue, 0, len(h.config.Attributes)+6)
+	attrs = append(attrs, dbSystem(event.Dialect), OpKey.String(event.Op.String()))
+	switch event.Op {
+	case sq.HookQuery, sq.HookExec, sq.HookPrepare, sq.HookStmtQuery, sq.HookStmtExec:
+		normalized, _ := sq.Fingerprint(event.Dialect, event.Query)
+		operation := Operation(normalized)
+		if operation != "" {
+			attrs = append(attrs, semconv.DBOperation(operation))
+		}
+		if !h.config.OmitStatement {
+			if h.config.FingerprintStatement {
+				attrs = append(attrs, semconv.DBStatement(normalized))
+			} else {
+				attrs = append(attrs, semconv.DBStatement(event.Query))
+			}
+		}
+		attrs = append(attrs, FingerprintKey.String(event.Fingerprint()))
+		var tables []string
+		if event.Source != nil {
+			tables, _ = sq.QueryTables(event.Source)
+		}
+		switch len(tables) {
+		case 0:
+		case 1:
+			attrs = append(attrs, semconv.DBSQLTable(tables[0]))
+		default:
+			attrs = append(attrs, TablesKey.StringSlice(tables))
+		}
+		name = spanName(operation, tables, event.Dialect)
+	case sq.HookBegin:
+		name = "transaction"
+	default:
+		// HookCommit and HookRollback end the span of the transaction.
+		return ctx
+	}
+	attrs = append(attrs, h.config.Attributes...)
+	ctx, span := h.tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(event.StartTime),
+		trace.WithAttributes(attrs...),
+	)
+	event.Stash[spanKey{}] = span
+	return ctx
+}
+
+// AfterQuery implements the sq.Hook interface.
+func (h *Hook) AfterQuery(ctx context.Context, event *sq.HookEvent) {
+	span, ok := event.Stash[spanKey{}].(trace.Span)
+	if !ok {
+		return
+	}
+	switch event.Op {
+	case sq.HookQuery, sq.HookStmtQuery, sq.HookBegin:
+		if event.Err == nil && event.Continued {
+			// The span is ended by the HookRowsEnd, HookCommit or
+			// HookRollback event.
+			return
+		}
+	case sq.HookExec, sq.HookStmtExec:
+		if event.Result != nil {
+			if rowsAffected, err := event.Result.RowsAffected(); err == nil {
+				span.SetAttributes(RowsAffectedKey.Int64(rowsAffected))
+			}
+		}
+	case sq.HookRowsEnd:
+		span.SetAttributes(ReturnedRowsKey.Int64(event.RowCount))
+	case sq.HookCommit, sq.HookRollback:
+		span.AddEvent(event.Op.String())
+	}
+	if event.Err != nil {
+		span.RecordError(event.Err)
+		span.SetStatus(codes.Error, event.Err.Error())
+	}
+	span.End()
+}
+
+// Operation returns the operation of a normalized query (see
+// sq.Fingerprint), such as SELECT or INSERT. For a query that starts with a
+// WITH clause it is the operation of the main statement. It returns an empty
+// string if the operation cannot be determined.
+func Operation(normalized string) string {
+	var operation string
+	var depth int
+	for i := 0; i < len(normalized); {
+		char := rune(normalized[i])
+		switch {
+		case char == '(':
+			depth++
+			i++
+		case char == ')':
+			depth--
+			i++
+		case unicode.IsLetter(char):
+			j := i
+			for j < len(normalized) && (unicode.IsLetter(rune(normalized[j])) || normalized[j] == '_') {
+				j++
+			}
+			word := strings.ToUpper(normalized[i:j])
+			i = j
+			if operation == "" {
+				// The first word may be inside parentheses, as in a
+				// compound query of parenthesized SELECTs.
+				if word != "WITH" {
+					return word
+				}
+				operation = word
+				continue
+			}
+			if depth != 0 {
+				continue
+			}
+			switch word {
+			case "SELECT", "INSERT", "UPDATE", "DELETE", "MERGE":
+				return word
+			}
+		case char == '"' || char == '`' || char == '[':
+			// Skip quoted identifiers, which may contain parentheses.
+			closing := byte(char)
+			if char == '[' {
+				closing = ']'
+			}
+			i++
+			for i < len(normalized) && normalized[i] != closing {
+				i++
+			}
+			i++
+		default:
+			i++
+		}
+	}
+	return ""
+}
+
+// spanName returns the name of the span of a query, following the semantic
+// conventions: the operation and the table if there is a single one, or
+// just the operation, or the database system if neither is known.
+func spanName(operation string, tables []string, dialect string) string {
+	if operation == "" {
+		return dbSystem(dialect).Value.AsString()
+	}
+	if len(tables) == 1 {
+		return operation + " " + tables[0]
+	}
+	return operation
+}
+
+// dbSystem returns the db.system attribute of dialect.
+func dbSystem(dialect string) attribute.KeyValue {
+	switch dialect {
+	case sq.DialectSQLite:
+		return semconv.DBSystemSqlite
+	case sq.DialectPostgres:
+		return semconv.DBSystemPostgreSQL
+	case sq.DialectMySQL:
+		return semconv.DBSystemMySQL
+	case sq.DialectSQLServer:
+		return semconv.DBSystemMSSQL
+	case sq.DialectOracle:
+		return semconv.DBSystemOracle
+	case sq.DialectDuckDB:
+		return semconv.DBSystemKey.String("duckdb")
+	}
+	return semconv.DBSystemOtherSQL
+}
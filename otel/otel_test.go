@@ -0,0 +1,189 @@
+package otel
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/blink-io/sq"
+	"github.com/blink-io/sq/internal/testutil"
+	bsq "github.com/bokwoon95/sq"
+	_ "github.com/mattn/go-sqlite3"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+)
+
+var ACTOR = sq.New[struct {
+	sq.TableStruct `sq:"actor"`
+	ACTOR_ID       sq.NumberField
+	FIRST_NAME     sq.StringField
+}]("")
+
+func firstNameMapper(ctx context.Context, row *sq.Row) string {
+	return row.StringField(ACTOR.FIRST_NAME)
+}
+
+func TestHook(t *testing.T) {
+	newTracedDB := func(t *testing.T, config Config) (sq.DB, *tracetest.InMemoryExporter) {
+		db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "TestHook.db"))
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		t.Cleanup(func() { db.Close() })
+		_, err = db.Exec(`CREATE TABLE actor (actor_id INTEGER PRIMARY KEY, first_name TEXT);
+INSERT INTO actor (actor_id, first_name) VALUES (1, 'PENELOPE'), (2, 'NICK'), (3, 'ED')`)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		exporter := tracetest.NewInMemoryExporter()
+		config.TracerProvider = sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+		return Trace(db, config), exporter
+	}
+	attributes := func(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+		attrs := make(map[attribute.Key]attribute.Value)
+		for _, attr := range span.Attributes {
+			attrs[attr.Key] = attr.Value
+		}
+		return attrs
+	}
+	ctx := context.Background()
+
+	t.Run("fetch and exec", func(t *testing.T) {
+		db, exporter := newTracedDB(t, Config{FingerprintStatement: true})
+		firstNames, err := sq.FetchAll(db, sq.SQLite.From(ACTOR).Where(ACTOR.ACTOR_ID.LtInt(3)), firstNameMapper)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if len(firstNames) != 2 {
+			t.Fatalf(testutil.Callers()+" expected 2 rows, got %d", len(firstNames))
+		}
+		_, err = sq.Exec(db, sq.SQLite.DeleteFrom(ACTOR).Where(ACTOR.ACTOR_ID.GtInt(1)))
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		_, err = sq.Exec(db, sq.SQLite.Queryf("DELETE FROM nonexistent"))
+		if err == nil {
+			t.Fatal(testutil.Callers(), "expected an error")
+		}
+
+		spans := exporter.GetSpans()
+		if len(spans) != 3 {
+			t.Fatalf(testutil.Callers()+" expected 3 spans, got %d", len(spans))
+		}
+		if diff := testutil.Diff(spans[0].Name, "SELECT actor"); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		attrs := attributes(spans[0])
+		for key, want := range map[attribute.Key]attribute.Value{
+			semconv.DBSystemKey:    attribute.StringValue("sqlite"),
+			semconv.DBOperationKey: attribute.StringValue("SELECT"),
+			semconv.DBStatementKey: attribute.StringValue("SELECT actor.first_name FROM actor WHERE actor.actor_id < ?"),
+			semconv.DBSQLTableKey:  attribute.StringValue("actor"),
+			ReturnedRowsKey:        attribute.Int64Value(2),
+			OpKey:                  attribute.StringValue("query"),
+		} {
+			if diff := testutil.Diff(attrs[key], want); diff != "" {
+				t.Error(testutil.Callers(), key, diff)
+			}
+		}
+		if attrs[FingerprintKey].AsString() == "" {
+			t.Error(testutil.Callers(), "expected a fingerprint")
+		}
+		if diff := testutil.Diff(spans[1].Name, "DELETE actor"); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		if diff := testutil.Diff(attributes(spans[1])[RowsAffectedKey], attribute.Int64Value(2)); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		if spans[2].Status.Code != codes.Error || len(spans[2].Events) != 1 {
+			t.Errorf(testutil.Callers()+" expected an error, got %+v", spans[2].Status)
+		}
+	})
+
+	t.Run("transactions", func(t *testing.T) {
+		db, exporter := newTracedDB(t, Config{})
+		errRollback := errors.New("rollback")
+		for _, wantErr := range []error{nil, errRollback} {
+			err := sq.RunInTx(ctx, db, nil, func(ctx context.Context, tx bsq.DB) error {
+				_, err := sq.FetchAllContext(ctx, tx, sq.SQLite.Queryf("SELECT {*} FROM actor"), firstNameMapper)
+				if err != nil {
+					return err
+				}
+				return wantErr
+			})
+			if !errors.Is(err, wantErr) {
+				t.Fatalf(testutil.Callers()+" expected %v, got %v", wantErr, err)
+			}
+		}
+
+		spans := exporter.GetSpans()
+		if len(spans) != 4 {
+			t.Fatalf(testutil.Callers()+" expected 4 spans, got %d", len(spans))
+		}
+		for i, outcome := range []string{"commit", "rollback"} {
+			stmt, tx := spans[2*i], spans[2*i+1]
+			if tx.Name != "transaction" || len(tx.Events) != 1 || tx.Events[0].Name != outcome {
+				t.Errorf(testutil.Callers()+" expected a transaction span ending with %s, got %+v", outcome, tx)
+			}
+			if stmt.Parent.SpanID() != tx.SpanContext.SpanID() {
+				t.Error(testutil.Callers(), "expected the statement to be a child of the transaction")
+			}
+			if diff := testutil.Diff(attributes(stmt)[semconv.DBStatementKey], attribute.StringValue("SELECT actor.first_name FROM actor")); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+		}
+	})
+
+	t.Run("prepared statements", func(t *testing.T) {
+		db, exporter := newTracedDB(t, Config{OmitStatement: true})
+		preparedFetch, err := sq.PrepareFetch(db, sq.SQLite.From(ACTOR).Where(ACTOR.ACTOR_ID.Eq(sq.IntParam("id", 0))), firstNameMapper)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		defer preparedFetch.Close()
+		_, err = preparedFetch.FetchAll(sq.Params{"id": 1})
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		spans := exporter.GetSpans()
+		if len(spans) != 2 {
+			t.Fatalf(testutil.Callers()+" expected 2 spans, got %d", len(spans))
+		}
+		for i, op := range []string{"prepare", "stmt_query"} {
+			attrs := attributes(spans[i])
+			if diff := testutil.Diff(attrs[OpKey], attribute.StringValue(op)); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+			if _, ok := attrs[semconv.DBStatementKey]; ok {
+				t.Error(testutil.Callers(), "expected no statement")
+			}
+		}
+		if diff := testutil.Diff(attributes(spans[1])[ReturnedRowsKey], attribute.Int64Value(1)); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+}
+
+func TestOperation(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"SELECT 1", "SELECT"},
+		{"  insert INTO actor VALUES (?)", "INSERT"},
+		{"WITH cte AS (SELECT 1) DELETE FROM actor WHERE actor_id IN (SELECT * FROM cte)", "DELETE"},
+		{`WITH "a(" AS (SELECT 1), b AS (INSERT INTO t VALUES (1) RETURNING *) SELECT * FROM b`, "SELECT"},
+		{"(SELECT 1) UNION (SELECT 2)", "SELECT"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Operation(tt.query); got != tt.want {
+			t.Errorf(testutil.Callers()+" %q: expected %q, got %q", tt.query, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,382 @@
+package sq
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultRedactionMask is the mask that replaces redacted values if a
+// RedactionPolicy does not specify one.
+const DefaultRedactionMask = "[REDACTED]"
+
+// RedactionPolicy decides which values are masked before a query is passed
+// to a Logger, in QueryStats.Args (and thus in the interpolated query) and
+// QueryStats.Results.
+//
+// The values written for fields marked as sensitive (see MarkSensitive) and
+// the values fetched from them are always masked. A RedactionPolicy can
+// additionally mask params by name.
+type RedactionPolicy struct {
+	// Mask replaces the redacted values. Defaults to DefaultRedactionMask.
+	Mask string
+
+	// Params are path.Match patterns matched case insensitively against
+	// the names of params (Param, sql.Named, ...), e.g. "*password*" or
+	// "*token*". The args of matching params are masked.
+	Params []string
+}
+
+// mask returns the mask of the policy.
+func (policy *RedactionPolicy) mask() string {
+	if policy == nil || policy.Mask == "" {
+		return DefaultRedactionMask
+	}
+	return policy.Mask
+}
+
+// matchParam reports whether the param name matches one of the Params
+// patterns of the policy.
+func (policy *RedactionPolicy) matchParam(name string) bool {
+	if policy == nil || name == "" {
+		return false
+	}
+	name = strings.ToLower(name)
+	for _, pattern := range policy.Params {
+		if ok, _ := path.Match(strings.ToLower(pattern), name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+var defaultRedactionPolicy atomic.Pointer[RedactionPolicy]
+
+// SetRedactionPolicy sets the RedactionPolicy used for loggers that do not
+// set LogSettings.Redaction. A nil policy resets it, so that only sensitive
+// fields are masked.
+func SetRedactionPolicy(policy *RedactionPolicy) {
+	defaultRedactionPolicy.Store(policy)
+}
+
+// redactionPolicy returns the RedactionPolicy in effect for logSettings.
+func redactionPolicy(logSettings LogSettings) *RedactionPolicy {
+	if logSettings.Redaction != nil {
+		return logSettings.Redaction
+	}
+	return defaultRedactionPolicy.Load()
+}
+
+// sensitiveFields is the registry of the fields marked as sensitive, by
+// "table.column" and by column (for the results of static queries, which
+// have no table).
+var sensitiveFields struct {
+	sync.RWMutex
+	marked  atomic.Bool
+	fields  map[string]bool
+	columns map[string]bool
+}
+
+// MarkSensitive marks fields as sensitive, so that the values written for
+// them (compared to them, assigned or inserted into them) and the values
+// fetched from them are masked in logs. Fields are identified by their table
+// and column names, so marking a field marks it under every alias of its
+// table.
+//
+// Table struct fields can also be marked with the sensitive option of their
+// sq struct tag, which New honors:
+//
+//	type USERS struct {
+//		sq.TableStruct
+//		USER_ID       sq.NumberField
+//		PASSWORD_HASH sq.StringField `sq:"password_hash,sensitive"`
+//	}
+//
+// There is no per-field option (such as a Sensitive method returning a
+// marked copy of a field): sensitivity belongs to the column, and a marked
+// copy would only cover the expressions built from it, leaving the column
+// unmasked wherever the field is taken from its table struct again.
+func MarkSensitive(fields ...Field) {
+	sensitiveFields.Lock()
+	defer sensitiveFields.Unlock()
+	if sensitiveFields.fields == nil {
+		sensitiveFields.fields = make(map[string]bool)
+		sensitiveFields.columns = make(map[string]bool)
+	}
+	for _, field := range fields {
+		table, column := fieldTableColumn(field)
+		if column == "" {
+			continue
+		}
+		sensitiveFields.fields[table+"."+column] = true
+		sensitiveFields.columns[column] = true
+		sensitiveFields.marked.Store(true)
+	}
+}
+
+// isSensitiveField reports whether field was marked as sensitive.
+func isSensitiveField(field any) bool {
+	if !sensitiveFields.marked.Load() {
+		return false
+	}
+	table, column := fieldTableColumn(field)
+	if column == "" {
+		return false
+	}
+	sensitiveFields.RLock()
+	defer sensitiveFields.RUnlock()
+	return sensitiveFields.fields[table+"."+column]
+}
+
+// isSensitiveColumn reports whether a field with the column name was marked
+// as sensitive, in any table.
+func isSensitiveColumn(column string) bool {
+	if !sensitiveFields.marked.Load() {
+		return false
+	}
+	sensitiveFields.RLock()
+	defer sensitiveFields.RUnlock()
+	return sensitiveFields.columns[strings.ToLower(column)]
+}
+
+// fieldTableColumn returns the lowercased table and column names of field,
+// if it is a field of a table struct.
+func fieldTableColumn(field any) (table, column string) {
+	var tbl TableStruct
+	switch field := field.(type) {
+	case AnyField:
+		tbl, column = field.table, field.name
+	case ArrayField:
+		tbl, column = field.table, field.name
+	case BinaryField:
+		tbl, column = field.table, field.name
+	case BooleanField:
+		tbl, column = field.table, field.name
+	case EnumField:
+		tbl, column = field.table, field.name
+	case JSONField:
+		tbl, column = field.table, field.name
+	case NumberField:
+		tbl, column = field.table, field.name
+	case StringField:
+		tbl, column = field.table, field.name
+	case TimeField:
+		tbl, column = field.table, field.name
+	case UUIDField:
+		tbl, column = field.table, field.name
+	default:
+		return "", ""
+	}
+	return strings.ToLower(tbl.name), strings.ToLower(column)
+}
+
+// redactionContextKey is the context key under which a *redactionRecorder
+// is passed to WriteSQL.
+type redactionContextKey struct{}
+
+// redactionRecorder records the indexes of the args written for sensitive
+// fields while a query is rendered.
+type redactionRecorder struct {
+	args map[int]bool
+}
+
+// withRedactionRecorder returns a copy of ctx carrying a new
+// redactionRecorder, or ctx and nil if no field was marked as sensitive.
+func withRedactionRecorder(ctx context.Context) (context.Context, *redactionRecorder) {
+	if !sensitiveFields.marked.Load() {
+		return ctx, nil
+	}
+	recorder := &redactionRecorder{}
+	return context.WithValue(ctx, redactionContextKey{}, recorder), recorder
+}
+
+// sensitiveArgs returns the indexes of the args recorded by recorder.
+func (recorder *redactionRecorder) sensitiveArgs() map[int]bool {
+	if recorder == nil {
+		return nil
+	}
+	return recorder.args
+}
+
+// mark records the args from start (inclusive) to end (exclusive).
+func (recorder *redactionRecorder) mark(start, end int) {
+	if start >= end {
+		return
+	}
+	if recorder.args == nil {
+		recorder.args = make(map[int]bool)
+	}
+	for i := start; i < end; i++ {
+		recorder.args[i] = true
+	}
+}
+
+// sensitiveValue is a value written for a sensitive field. Its args are
+// recorded by the redactionRecorder of the context, if any.
+type sensitiveValue struct {
+	value any
+}
+
+// WriteSQL implements the SQLWriter interface.
+func (v sensitiveValue) WriteSQL(ctx context.Context, dialect string, buf *bytes.Buffer, args *[]any, params map[string][]int) error {
+	start := len(*args)
+	err := WriteValue(ctx, dialect, buf, args, params, v.value)
+	if recorder, ok := ctx.Value(redactionContextKey{}).(*redactionRecorder); ok {
+		recorder.mark(start, len(*args))
+	}
+	return err
+}
+
+// redactValue wraps the value written for field in a sensitiveValue if a
+// redactionRecorder is recording in ctx and field is sensitive. Subqueries
+// are left as they are.
+func redactValue(ctx context.Context, field any, value any) any {
+	if _, ok := ctx.Value(redactionContextKey{}).(*redactionRecorder); !ok {
+		return value
+	}
+	if _, ok := value.(Query); ok || !isSensitiveField(field) {
+		return value
+	}
+	return sensitiveValue{value: value}
+}
+
+// redactRowValues wraps the values of the sensitive columns of rowValues in
+// sensitiveValues, if a redactionRecorder is recording in ctx.
+func redactRowValues(ctx context.Context, columns []Field, rowValues []RowValue) []RowValue {
+	if _, ok := ctx.Value(redactionContextKey{}).(*redactionRecorder); !ok {
+		return rowValues
+	}
+	var sensitive []int
+	for i, column := range columns {
+		if isSensitiveField(column) {
+			sensitive = append(sensitive, i)
+		}
+	}
+	if len(sensitive) == 0 {
+		return rowValues
+	}
+	redacted := make([]RowValue, len(rowValues))
+	for i, rowValue := range rowValues {
+		redacted[i] = append(RowValue(nil), rowValue...)
+		for _, j := range sensitive {
+			if j < len(redacted[i]) {
+				redacted[i][j] = redactValue(ctx, columns[j], redacted[i][j])
+			}
+		}
+	}
+	return redacted
+}
+
+// redactQueryStats masks the args of queryStats recorded in sensitiveArgs
+// and those of the params matched by policy, and reports whether any were.
+// Args are copied before being masked, the args passed to the database are
+// left untouched.
+func redactQueryStats(queryStats *QueryStats, policy *RedactionPolicy, sensitiveArgs map[int]bool) (redacted bool) {
+	var args []any
+	maskArg := func(i int) {
+		if i < 0 || i >= len(queryStats.Args) {
+			return
+		}
+		if args == nil {
+			args = append([]any(nil), queryStats.Args...)
+		}
+		if namedArg, ok := args[i].(sql.NamedArg); ok {
+			namedArg.Value = policy.mask()
+			args[i] = namedArg
+			return
+		}
+		args[i] = policy.mask()
+	}
+	for i := range sensitiveArgs {
+		maskArg(i)
+	}
+	if policy != nil && len(policy.Params) > 0 {
+		for name, indexes := range queryStats.Params {
+			if policy.matchParam(name) {
+				for _, i := range indexes {
+					maskArg(i)
+				}
+			}
+		}
+		for i, arg := range queryStats.Args {
+			if namedArg, ok := arg.(sql.NamedArg); ok && policy.matchParam(namedArg.Name) {
+				maskArg(i)
+			}
+		}
+	}
+	if args == nil {
+		return false
+	}
+	queryStats.Args = args
+	return true
+}
+
+// sensitiveResultColumns reports which of the columns fetched into row are
+// sensitive: the sensitive fields of a dynamic query, or the columns named
+// like a sensitive field for a static query.
+func sensitiveResultColumns(row *Row) []bool {
+	if !sensitiveFields.marked.Load() {
+		return nil
+	}
+	var sensitive []bool
+	if len(row.fields) == 0 {
+		columns, _ := row.sqlRows.Columns()
+		sensitive = make([]bool, len(columns))
+		for i, column := range columns {
+			sensitive[i] = isSensitiveColumn(column)
+		}
+		return sensitive
+	}
+	sensitive = make([]bool, len(row.fields))
+	for i, field := range row.fields {
+		sensitive[i] = isSensitiveField(field)
+	}
+	return sensitive
+}
+
+// unredactedArgsContextKey is the context key under which logQueryStats
+// passes the original args of a redacted query to LogQuery, for the loggers
+// of this package that run the query again (see slowQueryDB).
+type unredactedArgsContextKey struct{}
+
+// unredactedArgs returns the args of queryStats as they were passed to the
+// database.
+func unredactedArgs(ctx context.Context, queryStats QueryStats) []any {
+	if args, ok := ctx.Value(unredactedArgsContextKey{}).([]any); ok {
+		return args
+	}
+	return queryStats.Args
+}
+
+// logQueryStats passes queryStats to logger, with the args recorded in
+// sensitiveArgs and those matched by the redaction policy masked.
+func logQueryStats(ctx context.Context, logger Logger, logSettings LogSettings, queryStats QueryStats, sensitiveArgs map[int]bool) {
+	args := queryStats.Args
+	if redactQueryStats(&queryStats, redactionPolicy(logSettings), sensitiveArgs) {
+		ctx = context.WithValue(ctx, unredactedArgsContextKey{}, args)
+	}
+	if logSettings.LogAsynchronously {
+		go logger.LogQuery(ctx, queryStats)
+	} else {
+		logger.LogQuery(ctx, queryStats)
+	}
+}
+
+// redactExpression records the args written from start for an Expression
+// with the given values, if one of them is a sensitive field.
+func redactExpression(ctx context.Context, values []any, args []any, start int) {
+	recorder, ok := ctx.Value(redactionContextKey{}).(*redactionRecorder)
+	if !ok {
+		return
+	}
+	for _, value := range values {
+		if isSensitiveField(value) {
+			recorder.mark(start, len(args))
+			return
+		}
+	}
+}
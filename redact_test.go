@@ -0,0 +1,195 @@
+package sq
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blink-io/sq/internal/testutil"
+)
+
+type REDACT_ACCOUNT struct {
+	TableStruct
+	ACCOUNT_ID    NumberField
+	EMAIL         StringField
+	PASSWORD_HASH StringField `sq:"password_hash,sensitive"`
+}
+
+func TestRedaction(t *testing.T) {
+	type account struct {
+		accountID    int
+		email        string
+		passwordHash string
+	}
+	a := New[REDACT_ACCOUNT]("")
+	accountRowMapper := func(ctx context.Context, row *Row) account {
+		return account{
+			accountID:    row.IntField(a.ACCOUNT_ID),
+			email:        row.StringField(a.EMAIL),
+			passwordHash: row.StringField(a.PASSWORD_HASH),
+		}
+	}
+	newSQLDB := func(t *testing.T) *sql.DB {
+		return newSQLiteDB(t, `CREATE TABLE redact_account (account_id INTEGER PRIMARY KEY, email TEXT, password_hash TEXT)`)
+	}
+	withLogger := func(db DB, logger Logger) DB {
+		return struct {
+			DB
+			Logger
+		}{db, logger}
+	}
+	ctx := context.Background()
+
+	t.Run("args and results", func(t *testing.T) {
+		var stats []QueryStats
+		db := withLogger(newSQLDB(t), &loggerStruct{
+			logSettings: func(_ context.Context, logSettings *LogSettings) {
+				logSettings.IncludeResults = 5
+			},
+			logQuery: func(_ context.Context, queryStats QueryStats) {
+				stats = append(stats, queryStats)
+			},
+		})
+		_, err := ExecContext(ctx, db, SQLite.
+			InsertInto(a).
+			Columns(a.ACCOUNT_ID, a.EMAIL, a.PASSWORD_HASH).
+			Values(1, "alice@example.com", "hunter2"),
+		)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		_, err = ExecContext(ctx, db, SQLite.
+			Update(a).
+			Set(a.PASSWORD_HASH.SetString("hunter3")).
+			Where(a.ACCOUNT_ID.EqInt(1)),
+		)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		accounts, err := FetchAllContext(ctx, db, SQLite.
+			From(a).
+			Where(a.PASSWORD_HASH.EqString("hunter3")),
+			accountRowMapper,
+		)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		// The values passed to and fetched from the database are untouched.
+		if diff := testutil.Diff(accounts, []account{{1, "alice@example.com", "hunter3"}}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		if len(stats) != 3 {
+			t.Fatalf(testutil.Callers()+" expected 3 logs, got %d", len(stats))
+		}
+		if diff := testutil.Diff(stats[0].Args, []any{1, "alice@example.com", DefaultRedactionMask}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		if diff := testutil.Diff(stats[1].Args, []any{DefaultRedactionMask, 1}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		if diff := testutil.Diff(stats[2].Args, []any{DefaultRedactionMask}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		if strings.Contains(stats[2].Results, "hunter3") || !strings.Contains(stats[2].Results, "redact_account.password_hash: "+DefaultRedactionMask) {
+			t.Errorf(testutil.Callers()+" unexpected results %q", stats[2].Results)
+		}
+		if !strings.Contains(stats[2].Results, "alice@example.com") {
+			t.Errorf(testutil.Callers()+" expected non-sensitive results to be logged, got %q", stats[2].Results)
+		}
+	})
+
+	t.Run("params", func(t *testing.T) {
+		var stats QueryStats
+		db := withLogger(newSQLDB(t), &loggerStruct{
+			logSettings: func(_ context.Context, logSettings *LogSettings) {
+				logSettings.Redaction = &RedactionPolicy{Mask: "***", Params: []string{"*token*"}}
+			},
+			logQuery: func(_ context.Context, queryStats QueryStats) {
+				stats = queryStats
+			},
+		})
+		_, err := ExecContext(ctx, db, SQLite.Queryf(
+			"UPDATE redact_account SET email = {email} WHERE email = {ResetToken}",
+			Param("email", "bob@example.com"),
+			sql.Named("ResetToken", "abc123"),
+		))
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if diff := testutil.Diff(stats.Args, []any{sql.Named("email", "bob@example.com"), sql.Named("ResetToken", "***")}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("logger output", func(t *testing.T) {
+		sqlDB := newSQLDB(t)
+		buf := &strings.Builder{}
+		db := withLogger(sqlDB, NewLogger(buf, "", 0, LoggerConfig{
+			ShowResults: 5,
+			NoColor:     true,
+		}))
+		_, err := ExecContext(ctx, db, SQLite.
+			InsertInto(a).
+			Columns(a.ACCOUNT_ID, a.EMAIL, a.PASSWORD_HASH).
+			Values(1, "alice@example.com", "hunter2"),
+		)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		_, err = FetchAllContext(ctx, db, SQLite.From(a), accountRowMapper)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		sb := &strings.Builder{}
+		db = withLogger(sqlDB, NewSlogger(slog.New(slog.NewTextHandler(sb, nil)), slog.LevelInfo, LoggerConfig{
+			ShowResults: 5,
+		}))
+		_, err = FetchAllContext(ctx, db, SQLite.From(a), accountRowMapper)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		for _, output := range []string{buf.String(), sb.String()} {
+			if strings.Contains(output, "hunter2") || !strings.Contains(output, DefaultRedactionMask) {
+				t.Errorf(testutil.Callers()+" unexpected output %q", output)
+			}
+		}
+	})
+
+	t.Run("slow query plan", func(t *testing.T) {
+		// The plan is captured with the original args, while the logged
+		// query is redacted.
+		var explainArgs []any
+		buf := &strings.Builder{}
+		db := LogSlowQueries(explainArgsDB{DB: newSQLDB(t), args: &explainArgs}, NewSlowQueryLogger(buf, "", 0, SlowQueryConfig{
+			Threshold: time.Nanosecond,
+			Explain:   true,
+			NoColor:   true,
+		}))
+		_, err := FetchAllContext(ctx, db, SQLite.From(a).Where(a.PASSWORD_HASH.EqString("hunter2")), accountRowMapper)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if diff := testutil.Diff(explainArgs, []any{"hunter2"}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		if output := buf.String(); strings.Contains(output, "hunter2") || !strings.Contains(output, "Query plan") {
+			t.Errorf(testutil.Callers()+" unexpected output %q", output)
+		}
+	})
+}
+
+// explainArgsDB records the args of the EXPLAIN statements run on it.
+type explainArgsDB struct {
+	DB
+	args *[]any
+}
+
+func (db explainArgsDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	if strings.HasPrefix(query, "EXPLAIN") {
+		*db.args = args
+	}
+	return db.DB.QueryContext(ctx, query, args...)
+}
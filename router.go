@@ -0,0 +1,296 @@
+package sq
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ReplicaPolicy decides which replica a Router sends a read to.
+type ReplicaPolicy int
+
+// Replica policies.
+const (
+	RoundRobin   ReplicaPolicy = iota // cycle through the replicas in order
+	LeastLatency                      // pick the replica with the lowest recent latency
+)
+
+// Router is a DB that splits reads and writes between a primary and a pool of
+// replicas. Reads are sent to the replicas and everything else is sent to
+// the primary.
+//
+// When a query is run through sq (FetchAll, Exec, CompiledFetch etc) the
+// Router decides using the Query itself: SELECT queries (and UNIONs etc of
+// SELECT queries) without a lock clause are reads, anything else (INSERT ...
+// RETURNING, SELECT ... FOR UPDATE, raw queries) goes to the primary.
+// QueryContext calls that carry no Query, such as those made directly on the
+// Router, go to the primary too unless their context was returned by
+// ReadFromReplica. ExecContext, PrepareContext and transactions always go to
+// the primary.
+//
+// A Router must not be copied after first use.
+type Router struct {
+	// Primary is the DB that writes are sent to.
+	Primary DB
+
+	// Replicas are the DBs that reads are sent to. If there are no replicas,
+	// reads are sent to the primary.
+	Replicas []DB
+
+	// Policy decides which replica a read is sent to.
+	Policy ReplicaPolicy
+
+	// FailureBackoff is how long the LeastLatency policy avoids a replica
+	// after a query on it failed, unless every replica has failed. Defaults
+	// to 5s.
+	FailureBackoff time.Duration
+
+	// StickyWindow is how long reads stay on the primary after a write made
+	// within a session (see WithRouterSession), so that the session reads its
+	// own writes despite replication lag. Zero disables stickiness.
+	StickyWindow time.Duration
+
+	next         atomic.Uint64
+	replicasOnce sync.Once
+	replicas     []replicaStats // allocated lazily, see stats
+}
+
+type replicaStats struct {
+	latency  atomic.Int64 // moving average of the latency in nanoseconds
+	failedAt atomic.Int64 // unix nanoseconds of the last failed query
+}
+
+var _ interface {
+	DB
+	Txer
+} = (*Router)(nil)
+
+// NewRouter creates a new Router that writes to primary and reads from
+// replicas.
+func NewRouter(primary DB, replicas ...DB) *Router {
+	return &Router{
+		Primary:  primary,
+		Replicas: replicas,
+	}
+}
+
+type forcePrimaryContextKey struct{}
+
+// ForcePrimary returns a copy of ctx that makes a Router send every query run
+// with it to the primary.
+func ForcePrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forcePrimaryContextKey{}, true)
+}
+
+type readFromReplicaContextKey struct{}
+
+// ReadFromReplica returns a copy of ctx that lets a Router send QueryContext
+// calls that carry no Query (such as raw QueryContext calls made directly on
+// the Router) to a replica. Without it the Router cannot tell whether they
+// are reads, and sends them to the primary.
+func ReadFromReplica(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readFromReplicaContextKey{}, true)
+}
+
+type routerSessionContextKey struct{}
+
+type routerSession struct {
+	lastWrite atomic.Int64 // unix nanoseconds
+}
+
+// WithRouterSession returns a copy of ctx that starts a new session (e.g. for
+// a single request). After a write is sent to the primary with the session's
+// context (or a context derived from it), reads with the session's context
+// are also sent to the primary for the Router's StickyWindow.
+func WithRouterSession(ctx context.Context) context.Context {
+	return context.WithValue(ctx, routerSessionContextKey{}, &routerSession{})
+}
+
+// QueryContext implements the DB interface.
+func (r *Router) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	var readOnly bool
+	if q, ok := QueryFromContext(ctx); ok {
+		readOnly = isReadOnlyQuery(q)
+	} else {
+		readOnly, _ = ctx.Value(readFromReplicaContextKey{}).(bool)
+	}
+	if !readOnly || r.usePrimary(ctx) {
+		r.markWrite(ctx, readOnly)
+		return r.Primary.QueryContext(ctx, query, args...)
+	}
+	i := r.pickReplica()
+	startedAt := time.Now()
+	rows, err := r.Replicas[i].QueryContext(ctx, query, args...)
+	if err == nil {
+		r.recordLatency(i, time.Since(startedAt))
+	} else if ctx.Err() == nil {
+		r.recordFailure(i)
+	}
+	return rows, err
+}
+
+// ExecContext implements the DB interface.
+func (r *Router) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	r.markWrite(ctx, false)
+	return r.Primary.ExecContext(ctx, query, args...)
+}
+
+// PrepareContext implements the DB interface. Statements are always prepared
+// on the primary.
+func (r *Router) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	r.markWrite(ctx, false)
+	return r.Primary.PrepareContext(ctx, query)
+}
+
+// Begin implements the Txer interface.
+func (r *Router) Begin() (*sql.Tx, error) {
+	return r.BeginTx(context.Background(), nil)
+}
+
+// BeginTx implements the Txer interface. Transactions are always started on
+// the primary.
+func (r *Router) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	txer, ok := r.Primary.(Txer)
+	if !ok {
+		return nil, fmt.Errorf("sq: %T cannot begin transactions", r.Primary)
+	}
+	r.markWrite(ctx, false)
+	return txer.BeginTx(ctx, opts)
+}
+
+// GetDialect returns the dialect of the primary.
+func (r *Router) GetDialect() string { return dbDialect(r.Primary) }
+
+func (r *Router) usePrimary(ctx context.Context) bool {
+	if len(r.Replicas) == 0 {
+		return true
+	}
+	if force, _ := ctx.Value(forcePrimaryContextKey{}).(bool); force {
+		return true
+	}
+	if r.StickyWindow > 0 {
+		if session, ok := ctx.Value(routerSessionContextKey{}).(*routerSession); ok {
+			lastWrite := session.lastWrite.Load()
+			if lastWrite != 0 && time.Since(time.Unix(0, lastWrite)) < r.StickyWindow {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// markWrite records a write in the session of ctx, if there is one. Reads do
+// not extend the sticky window.
+func (r *Router) markWrite(ctx context.Context, readOnly bool) {
+	if readOnly || r.StickyWindow <= 0 {
+		return
+	}
+	if session, ok := ctx.Value(routerSessionContextKey{}).(*routerSession); ok {
+		session.lastWrite.Store(time.Now().UnixNano())
+	}
+}
+
+// stats returns the stats of the replicas, allocating them on first use so
+// that a Router created without NewRouter works too.
+func (r *Router) stats() []replicaStats {
+	r.replicasOnce.Do(func() {
+		r.replicas = make([]replicaStats, len(r.Replicas))
+	})
+	return r.replicas
+}
+
+func (r *Router) pickReplica() int {
+	if stats := r.stats(); r.Policy == LeastLatency && len(stats) == len(r.Replicas) {
+		failureBackoff := r.FailureBackoff
+		if failureBackoff <= 0 {
+			failureBackoff = 5 * time.Second
+		}
+		now := time.Now().UnixNano()
+		best, bestFailed := -1, false
+		for i := range stats {
+			failedAt := stats[i].failedAt.Load()
+			failed := failedAt != 0 && now-failedAt < int64(failureBackoff)
+			switch {
+			case best < 0,
+				bestFailed && !failed,
+				bestFailed == failed && stats[i].latency.Load() < stats[best].latency.Load():
+				best, bestFailed = i, failed
+			}
+		}
+		return best
+	}
+	return int((r.next.Add(1) - 1) % uint64(len(r.Replicas)))
+}
+
+// recordLatency folds latency into the replica's exponentially weighted moving
+// average. Replicas that have not been measured yet have an average of zero,
+// so that LeastLatency tries every replica at least once.
+func (r *Router) recordLatency(i int, latency time.Duration) {
+	stats := r.stats()
+	if i >= len(stats) {
+		return
+	}
+	for {
+		old := stats[i].latency.Load()
+		avg := int64(latency)
+		if old != 0 {
+			avg = old + (int64(latency)-old)/5
+		}
+		if stats[i].latency.CompareAndSwap(old, avg) {
+			return
+		}
+	}
+}
+
+// recordFailure records that a query on the replica failed, so that
+// LeastLatency avoids it for the FailureBackoff.
+func (r *Router) recordFailure(i int) {
+	stats := r.stats()
+	if i >= len(stats) {
+		return
+	}
+	stats[i].failedAt.Store(time.Now().UnixNano())
+}
+
+// isReadOnlyQuery reports whether query is a SELECT (or a compound query of
+// SELECTs) that neither locks rows nor contains a data-modifying CTE.
+func isReadOnlyQuery(query Query) bool {
+	var q SelectQuery
+	switch query := query.(type) {
+	case SelectQuery:
+		q = query
+	case SQLiteSelectQuery:
+		q = SelectQuery(query)
+	case PostgresSelectQuery:
+		q = SelectQuery(query)
+	case MySQLSelectQuery:
+		q = SelectQuery(query)
+	case SQLServerSelectQuery:
+		q = SelectQuery(query)
+	case DuckDBSelectQuery:
+		q = SelectQuery(query)
+	case OracleSelectQuery:
+		q = SelectQuery(query)
+	case VariadicQuery:
+		for _, query := range query.Queries {
+			if !isReadOnlyQuery(query) {
+				return false
+			}
+		}
+		return len(query.Queries) > 0
+	default:
+		return false
+	}
+	if q.LockClause != "" {
+		return false
+	}
+	for _, cte := range q.CTEs {
+		if !isReadOnlyQuery(cte.query) {
+			return false
+		}
+	}
+	return true
+}
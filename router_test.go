@@ -0,0 +1,301 @@
+package sq
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/blink-io/sq/internal/testutil"
+	"github.com/bokwoon95/sq"
+)
+
+func TestRouter(t *testing.T) {
+	NODE := New[struct {
+		TableStruct `sq:"node"`
+		NAME        StringField
+	}]("")
+	newNode := func(t *testing.T, name string) *sql.DB {
+		return newSQLiteDB(t, "CREATE TABLE node (name TEXT); INSERT INTO node (name) VALUES ('"+name+"')")
+	}
+	newRouter := func(t *testing.T) *Router {
+		return NewRouter(newNode(t, "primary"), newNode(t, "replica1"), newNode(t, "replica2"))
+	}
+	// fetchNodes returns the name of the node that each query ran on.
+	fetchNodes := func(t *testing.T, ctx context.Context, db DB, query Query, n int) []string {
+		t.Helper()
+		var names []string
+		for i := 0; i < n; i++ {
+			name, err := FetchOneContext(ctx, db, query, func(ctx context.Context, row *Row) string {
+				return row.StringField(NODE.NAME)
+			})
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			names = append(names, name)
+		}
+		return names
+	}
+	ctx := context.Background()
+	selectNode := SQLite.From(NODE).Limit(1)
+
+	t.Run("round robin", func(t *testing.T) {
+		router := newRouter(t)
+		gotNodes := fetchNodes(t, ctx, router, selectNode, 3)
+		if diff := testutil.Diff(gotNodes, []string{"replica1", "replica2", "replica1"}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("writes go to the primary", func(t *testing.T) {
+		router := newRouter(t)
+		insertNode := SQLite.InsertInto(NODE).Columns(NODE.NAME).Values("new")
+		gotNodes := fetchNodes(t, ctx, router, insertNode, 1)
+		if diff := testutil.Diff(gotNodes, []string{"new"}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		_, err := Exec(router, SQLite.DeleteFrom(NODE).Where(NODE.NAME.EqString("primary")))
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		// The replicas are untouched.
+		gotNodes = fetchNodes(t, ctx, router, selectNode, 2)
+		if diff := testutil.Diff(gotNodes, []string{"replica1", "replica2"}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		gotNodes = fetchNodes(t, ForcePrimary(ctx), router, selectNode, 1)
+		if diff := testutil.Diff(gotNodes, []string{"new"}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("raw queries go to the primary", func(t *testing.T) {
+		router := newRouter(t)
+		gotNodes := fetchNodes(t, ctx, router, SQLite.Queryf("SELECT {*} FROM node"), 1)
+		if diff := testutil.Diff(gotNodes, []string{"primary"}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("wrapped router", func(t *testing.T) {
+		router := newRouter(t)
+		gotNodes := fetchNodes(t, ctx, ContextDB(router), selectNode, 2)
+		if diff := testutil.Diff(gotNodes, []string{"replica1", "replica2"}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		gotNodes = fetchNodes(t, ctx, ContextDB(router), SQLite.Queryf("SELECT {*} FROM node"), 1)
+		if diff := testutil.Diff(gotNodes, []string{"primary"}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		if dialect := dbDialect(router); dialect != DialectSQLite {
+			t.Errorf(testutil.Callers()+" expected %q, got %q", DialectSQLite, dialect)
+		}
+	})
+
+	t.Run("sticky primary", func(t *testing.T) {
+		router := newRouter(t)
+		router.StickyWindow = time.Hour
+		session := WithRouterSession(ctx)
+		// Reads before a write go to the replicas.
+		gotNodes := fetchNodes(t, session, router, selectNode, 1)
+		if diff := testutil.Diff(gotNodes, []string{"replica1"}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		_, err := ExecContext(session, router, SQLite.InsertInto(NODE).Columns(NODE.NAME).Values("new"))
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		gotNodes = fetchNodes(t, session, router, selectNode, 2)
+		if diff := testutil.Diff(gotNodes, []string{"primary", "primary"}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		// Other sessions are unaffected.
+		gotNodes = fetchNodes(t, WithRouterSession(ctx), router, selectNode, 1)
+		if diff := testutil.Diff(gotNodes, []string{"replica2"}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		// Once the window has passed, reads go back to the replicas.
+		session.Value(routerSessionContextKey{}).(*routerSession).lastWrite.Add(-int64(2 * time.Hour))
+		gotNodes = fetchNodes(t, session, router, selectNode, 1)
+		if diff := testutil.Diff(gotNodes, []string{"replica1"}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("least latency", func(t *testing.T) {
+		router := newRouter(t)
+		router.Policy = LeastLatency
+		router.stats()[0].latency.Store(int64(time.Second))
+		router.stats()[1].latency.Store(int64(time.Millisecond))
+		gotNodes := fetchNodes(t, ctx, router, selectNode, 1)
+		if diff := testutil.Diff(gotNodes, []string{"replica2"}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("least latency without NewRouter", func(t *testing.T) {
+		router := &Router{
+			Primary:  newNode(t, "primary"),
+			Replicas: []DB{newNode(t, "replica1"), newNode(t, "replica2")},
+			Policy:   LeastLatency,
+		}
+		router.stats()[0].latency.Store(int64(time.Second))
+		router.stats()[1].latency.Store(int64(time.Millisecond))
+		gotNodes := fetchNodes(t, ctx, router, selectNode, 2)
+		if diff := testutil.Diff(gotNodes, []string{"replica2", "replica2"}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("least latency avoids failing replicas", func(t *testing.T) {
+		replica1 := newNode(t, "replica1")
+		router := NewRouter(newNode(t, "primary"), replica1, newNode(t, "replica2"))
+		router.Policy = LeastLatency
+		router.stats()[1].latency.Store(int64(time.Millisecond))
+		replica1.Close()
+		_, err := FetchOne(router, selectNode, func(ctx context.Context, row *Row) string {
+			return row.StringField(NODE.NAME)
+		})
+		if err == nil {
+			t.Fatal(testutil.Callers(), "expected error from closed replica")
+		}
+		gotNodes := fetchNodes(t, ctx, router, selectNode, 2)
+		if diff := testutil.Diff(gotNodes, []string{"replica2", "replica2"}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("compiled and prepared fetches", func(t *testing.T) {
+		router := newRouter(t)
+		nodeName := func(ctx context.Context, row *Row) string {
+			return row.StringField(NODE.NAME)
+		}
+		compiledSelect, err := CompileFetch(selectNode, nodeName)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		gotNode, err := compiledSelect.FetchOne(router, nil)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if diff := testutil.Diff(gotNode, "replica1"); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		compiledInsert, err := CompileFetch(SQLite.InsertInto(NODE).Columns(NODE.NAME).Values("new"), nodeName)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		gotNode, err = compiledInsert.FetchOne(router, nil)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if diff := testutil.Diff(gotNode, "new"); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		preparedInsert, err := compiledInsert.Prepare(router)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		defer preparedInsert.Close()
+		gotNode, err = preparedInsert.FetchOne(nil)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if diff := testutil.Diff(gotNode, "new"); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		// Both inserts went to the primary.
+		for i, db := range []DB{router.Primary, router.Replicas[0], router.Replicas[1]} {
+			count, err := FetchOne(db, SQLite.Select(Expr("COUNT(*)")).From(NODE).Where(NODE.NAME.EqString("new")), func(ctx context.Context, row *Row) int {
+				return row.Int("COUNT(*)")
+			})
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			want := 0
+			if i == 0 {
+				want = 2
+			}
+			if count != want {
+				t.Errorf(testutil.Callers()+" node #%d: expected %d new rows, got %d", i, want, count)
+			}
+		}
+	})
+
+	t.Run("raw QueryContext", func(t *testing.T) {
+		router := newRouter(t)
+		for _, tt := range []struct {
+			ctx  context.Context
+			want string
+		}{{ctx, "primary"}, {ReadFromReplica(ctx), "replica1"}} {
+			rows, err := router.QueryContext(tt.ctx, "SELECT name FROM node")
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			var name string
+			for rows.Next() {
+				err = rows.Scan(&name)
+				if err != nil {
+					t.Fatal(testutil.Callers(), err)
+				}
+			}
+			rows.Close()
+			if diff := testutil.Diff(name, tt.want); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+		}
+	})
+
+	t.Run("transactions", func(t *testing.T) {
+		router := newRouter(t)
+		err := RunInTx(ctx, router, nil, func(ctx context.Context, tx sq.DB) error {
+			_, err := Exec(tx, SQLite.InsertInto(NODE).Columns(NODE.NAME).Values("new"))
+			return err
+		})
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		for _, tt := range []struct {
+			ctx  context.Context
+			want bool
+		}{{ForcePrimary(ctx), true}, {ctx, false}} {
+			exists, err := FetchExistsContext(tt.ctx, router, SQLite.Select(Expr("1")).From(NODE).Where(NODE.NAME.EqString("new")))
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			if exists != tt.want {
+				t.Errorf(testutil.Callers()+" expected %v, got %v", tt.want, exists)
+			}
+		}
+	})
+}
+
+func Test_isReadOnlyQuery(t *testing.T) {
+	TABLE := New[struct {
+		TableStruct `sq:"tbl"`
+		ID          NumberField
+	}]("")
+	type TT struct {
+		description string
+		query       Query
+		want        bool
+	}
+	tests := []TT{
+		{"select", Select(TABLE.ID).From(TABLE), true},
+		{"postgres select", Postgres.Select(TABLE.ID).From(TABLE), true},
+		{"locking select", Postgres.Select(TABLE.ID).From(TABLE).LockRows("FOR UPDATE"), false},
+		{"union", Union(Select(TABLE.ID).From(TABLE), SQLite.Select(TABLE.ID).From(TABLE)), true},
+		{"union with locking select", Union(Select(TABLE.ID).From(TABLE), MySQL.Select(TABLE.ID).From(TABLE).LockRows("FOR UPDATE")), false},
+		{"data-modifying CTE", Postgres.With(NewCTE("deleted", nil, Postgres.DeleteFrom(TABLE).Returning(TABLE.ID))).Select(TABLE.ID).From(TABLE), false},
+		{"read-only CTE", SQLite.With(NewCTE("ids", nil, Select(TABLE.ID).From(TABLE))).Select(TABLE.ID).From(TABLE), true},
+		{"insert", InsertInto(TABLE).Columns(TABLE.ID).Values(1), false},
+		{"update", Update(TABLE).Set(TABLE.ID.SetInt(1)), false},
+		{"delete", DeleteFrom(TABLE), false},
+		{"raw", Queryf("SELECT 1"), false},
+	}
+	for _, tt := range tests {
+		if got := isReadOnlyQuery(tt.query); got != tt.want {
+			t.Errorf(testutil.Callers()+" %s: expected %v, got %v", tt.description, tt.want, got)
+		}
+	}
+}
@@ -480,6 +480,14 @@ func (row *Row) NullArray(destPtr any, valid *bool, format string, values ...any
 			data = value.([]byte)
 		case string:
 			data = []byte(value.(string))
+		case []any:
+			// DuckDB lists are returned as a []any, convert it to a JSON
+			// array.
+			var err error
+			data, err = json.Marshal(value)
+			if err != nil {
+				panic(fmt.Errorf(callsite(skip+1)+"marshaling %#v into json: %w", value, err))
+			}
 		default:
 			panic(fmt.Errorf(callsite(skip+1)+"%[1]v is %[1]T, not []byte or string", value))
 		}
@@ -1710,6 +1718,14 @@ func (n *nullBytes) Scan(value any) error {
 		n.bytes = []byte(value)
 	case []byte:
 		n.bytes = value
+	case []any, map[string]any:
+		// DuckDB returns LIST values as a []any and STRUCT values as a
+		// map[string]any, store them as JSON.
+		b, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("unable to convert %#v to json: %w", value, err)
+		}
+		n.bytes = b
 	default:
 		return fmt.Errorf("unable to convert %#v to bytes", value)
 	}
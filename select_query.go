@@ -30,6 +30,8 @@ type SelectQuery struct {
 	HavingPredicate Predicate
 	// WINDOW
 	NamedWindows []NamedWindow
+	// QUALIFY
+	QualifyPredicate Predicate
 	// ORDER BY
 	OrderByFields []Field
 	// LIMIT
@@ -45,6 +47,9 @@ type SelectQuery struct {
 	// AS
 	Alias   string
 	Columns []string
+	// FullTable marks the query as meant to read every row of a large table,
+	// see Guard.
+	FullTable bool
 }
 
 var _ interface {
@@ -88,7 +93,7 @@ func (q SelectQuery) WriteSQL(ctx context.Context, dialect string, buf *bytes.Bu
 	// SELECT
 	buf.WriteString("SELECT ")
 	if q.LimitTop != nil || q.LimitTopPercent != nil { // TOP
-		if dialect != DialectSQLServer {
+		if !Supports(dialect, CapabilitySelectTop) {
 			return fmt.Errorf("%s does not support SELECT TOP n", dialect)
 		}
 		if len(q.OrderByFields) == 0 {
@@ -100,7 +105,7 @@ func (q SelectQuery) WriteSQL(ctx context.Context, dialect string, buf *bytes.Bu
 		}
 	}
 	if len(q.DistinctOnFields) > 0 {
-		if dialect != DialectPostgres {
+		if !Supports(dialect, CapabilityDistinctOn) {
 			return fmt.Errorf("%s does not support SELECT DISTINCT ON", dialect)
 		}
 		if q.Distinct {
@@ -134,10 +139,12 @@ func (q SelectQuery) WriteSQL(ctx context.Context, dialect string, buf *bytes.Bu
 			buf.WriteString(")")
 		}
 		if alias := getAlias(q.FromTable); alias != "" {
-			buf.WriteString(" AS " + QuoteIdentifier(dialect, alias) + quoteTableColumns(dialect, q.FromTable))
+			buf.WriteString(tableAliasAS(dialect) + QuoteIdentifier(dialect, alias) + quoteTableColumns(dialect, q.FromTable))
 		} else if isQuery && dialect != DialectSQLite {
 			return fmt.Errorf("%s FROM subquery must have alias", dialect)
 		}
+	} else if dialect == DialectOracle {
+		buf.WriteString(" FROM DUAL")
 	}
 	// JOIN
 	if len(q.JoinTables) > 0 {
@@ -200,6 +207,26 @@ func (q SelectQuery) WriteSQL(ctx context.Context, dialect string, buf *bytes.Bu
 			return fmt.Errorf("WINDOW: %w", err)
 		}
 	}
+	// QUALIFY
+	if q.QualifyPredicate != nil {
+		if !Supports(dialect, CapabilityQualify) {
+			return fmt.Errorf("%s does not support QUALIFY", dialect)
+		}
+		buf.WriteString(" QUALIFY ")
+		switch predicate := q.QualifyPredicate.(type) {
+		case VariadicPredicate:
+			predicate.Toplevel = true
+			err = predicate.WriteSQL(ctx, dialect, buf, args, params)
+			if err != nil {
+				return fmt.Errorf("QUALIFY: %w", err)
+			}
+		default:
+			err = q.QualifyPredicate.WriteSQL(ctx, dialect, buf, args, params)
+			if err != nil {
+				return fmt.Errorf("QUALIFY: %w", err)
+			}
+		}
+	}
 	// ORDER BY
 	if len(q.OrderByFields) > 0 {
 		buf.WriteString(" ORDER BY ")
@@ -208,20 +235,9 @@ func (q SelectQuery) WriteSQL(ctx context.Context, dialect string, buf *bytes.Bu
 			return fmt.Errorf("ORDER BY: %w", err)
 		}
 	}
-	// LIMIT
-	if q.LimitRows != nil {
-		if dialect == DialectSQLServer {
-			return fmt.Errorf("sqlserver does not support LIMIT")
-		}
-		buf.WriteString(" LIMIT ")
-		err = WriteValue(ctx, dialect, buf, args, params, q.LimitRows)
-		if err != nil {
-			return fmt.Errorf("LIMIT: %w", err)
-		}
-	}
-	// OFFSET
-	if q.OffsetRows != nil {
-		if dialect == DialectSQLServer {
+	// LIMIT, OFFSET
+	if q.LimitRows != nil || q.OffsetRows != nil {
+		if dialect == DialectSQLServer && q.LimitRows == nil {
 			if len(q.OrderByFields) == 0 {
 				return fmt.Errorf("sqlserver does not support OFFSET without ORDER BY")
 			}
@@ -229,28 +245,30 @@ func (q SelectQuery) WriteSQL(ctx context.Context, dialect string, buf *bytes.Bu
 				return fmt.Errorf("sqlserver does not support OFFSET with TOP")
 			}
 		}
-		buf.WriteString(" OFFSET ")
-		err = WriteValue(ctx, dialect, buf, args, params, q.OffsetRows)
-		if err != nil {
-			return fmt.Errorf("OFFSET: %w", err)
+		if d := customDialect(dialect); d != nil {
+			err = d.WriteLimitOffset(ctx, buf, args, params, q.LimitRows, q.OffsetRows)
+		} else {
+			err = writeLimitOffset(ctx, dialect, buf, args, params, q.LimitRows, q.OffsetRows)
 		}
-		if dialect == DialectSQLServer {
-			buf.WriteString(" ROWS")
+		if err != nil {
+			return err
 		}
 	}
 	// FETCH NEXT
 	if q.FetchNextRows != nil {
 		switch dialect {
-		case DialectPostgres:
+		case DialectPostgres, DialectOracle:
 			if q.LimitRows != nil {
-				return fmt.Errorf("postgres does not allow FETCH NEXT with LIMIT")
+				return fmt.Errorf("%s does not allow FETCH NEXT with LIMIT", dialect)
 			}
 		case DialectSQLServer:
 			if q.LimitTop != nil || q.LimitTopPercent != nil {
 				return fmt.Errorf("sqlserver does not allow FETCH NEXT with TOP")
 			}
 		default:
-			return fmt.Errorf("%s does not support FETCH NEXT", dialect)
+			if !Supports(dialect, CapabilityFetchNext) {
+				return fmt.Errorf("%s does not support FETCH NEXT", dialect)
+			}
 		}
 		buf.WriteString(" FETCH NEXT ")
 		err = WriteValue(ctx, dialect, buf, args, params, q.FetchNextRows)
@@ -262,6 +280,9 @@ func (q SelectQuery) WriteSQL(ctx context.Context, dialect string, buf *bytes.Bu
 			if dialect == DialectSQLServer {
 				return fmt.Errorf("sqlserver WITH TIES only works with TOP")
 			}
+			if !Supports(dialect, CapabilityFetchWithTies) {
+				return fmt.Errorf("%s does not support FETCH NEXT ... WITH TIES", dialect)
+			}
 			if len(q.OrderByFields) == 0 {
 				return fmt.Errorf("%s WITH TIES cannot be used without ORDER BY", dialect)
 			}
@@ -365,6 +386,13 @@ func (q SelectQuery) Where(predicates ...Predicate) SelectQuery {
 	return q
 }
 
+// AllowFullTable marks the SelectQuery as meant to read every row of a large
+// table without a LIMIT, so that it is not rejected by a Guard.
+func (q SelectQuery) AllowFullTable() SelectQuery {
+	q.FullTable = true
+	return q
+}
+
 // GroupBy appends to the GroupByFields field in the SelectQuery.
 func (q SelectQuery) GroupBy(fields ...Field) SelectQuery {
 	q.GroupByFields = append(q.GroupByFields, fields...)
@@ -584,6 +612,13 @@ func (q SQLiteSelectQuery) Where(predicates ...Predicate) SQLiteSelectQuery {
 	return q
 }
 
+// AllowFullTable marks the SQLiteSelectQuery as meant to read every row of a large
+// table without a LIMIT, so that it is not rejected by a Guard.
+func (q SQLiteSelectQuery) AllowFullTable() SQLiteSelectQuery {
+	q.FullTable = true
+	return q
+}
+
 // GroupBy appends to the GroupByFields field in the SQLiteSelectQuery.
 func (q SQLiteSelectQuery) GroupBy(fields ...Field) SQLiteSelectQuery {
 	q.GroupByFields = append(q.GroupByFields, fields...)
@@ -824,6 +859,13 @@ func (q PostgresSelectQuery) Where(predicates ...Predicate) PostgresSelectQuery
 	return q
 }
 
+// AllowFullTable marks the PostgresSelectQuery as meant to read every row of a large
+// table without a LIMIT, so that it is not rejected by a Guard.
+func (q PostgresSelectQuery) AllowFullTable() PostgresSelectQuery {
+	q.FullTable = true
+	return q
+}
+
 // GroupBy appends to the GroupByFields field in the PostgresSelectQuery.
 func (q PostgresSelectQuery) GroupBy(fields ...Field) PostgresSelectQuery {
 	q.GroupByFields = append(q.GroupByFields, fields...)
@@ -1077,6 +1119,13 @@ func (q MySQLSelectQuery) Where(predicates ...Predicate) MySQLSelectQuery {
 	return q
 }
 
+// AllowFullTable marks the MySQLSelectQuery as meant to read every row of a large
+// table without a LIMIT, so that it is not rejected by a Guard.
+func (q MySQLSelectQuery) AllowFullTable() MySQLSelectQuery {
+	q.FullTable = true
+	return q
+}
+
 // GroupBy appends to the GroupByFields field in the MySQLSelectQuery.
 func (q MySQLSelectQuery) GroupBy(fields ...Field) MySQLSelectQuery {
 	q.GroupByFields = append(q.GroupByFields, fields...)
@@ -1324,6 +1373,13 @@ func (q SQLServerSelectQuery) Where(predicates ...Predicate) SQLServerSelectQuer
 	return q
 }
 
+// AllowFullTable marks the SQLServerSelectQuery as meant to read every row of a large
+// table without a LIMIT, so that it is not rejected by a Guard.
+func (q SQLServerSelectQuery) AllowFullTable() SQLServerSelectQuery {
+	q.FullTable = true
+	return q
+}
+
 // GroupBy appends to the GroupByFields field in the SQLServerSelectQuery.
 func (q SQLServerSelectQuery) GroupBy(fields ...Field) SQLServerSelectQuery {
 	q.GroupByFields = append(q.GroupByFields, fields...)
@@ -1431,3 +1487,522 @@ func (q SQLServerSelectQuery) IsTime() {}
 
 // IsUUID implements the UUID interface.
 func (q SQLServerSelectQuery) IsUUID() {}
+
+// DuckDBSelectQuery represents a DuckDB SELECT query.
+type DuckDBSelectQuery SelectQuery
+
+var _ interface {
+	Query
+	Table
+	Field
+	Any
+} = (*DuckDBSelectQuery)(nil)
+
+// WriteSQL implements the SQLWriter interface.
+func (q DuckDBSelectQuery) WriteSQL(ctx context.Context, dialect string, buf *bytes.Buffer, args *[]any, params map[string][]int) error {
+	return SelectQuery(q).WriteSQL(ctx, dialect, buf, args, params)
+}
+
+// Select creates a new DuckDBSelectQuery.
+func (b duckdbQueryBuilder) Select(fields ...Field) DuckDBSelectQuery {
+	q := DuckDBSelectQuery{
+		CTEs:         b.ctes,
+		SelectFields: fields,
+	}
+	if q.Dialect == "" {
+		q.Dialect = DialectDuckDB
+	}
+	return q
+}
+
+// SelectDistinct creates a new DuckDBSelectQuery.
+func (b duckdbQueryBuilder) SelectDistinct(fields ...Field) DuckDBSelectQuery {
+	q := DuckDBSelectQuery{
+		CTEs:         b.ctes,
+		SelectFields: fields,
+		Distinct:     true,
+	}
+	if q.Dialect == "" {
+		q.Dialect = DialectDuckDB
+	}
+	return q
+}
+
+// SelectOne creates a new DuckDBSelectQuery.
+func (b duckdbQueryBuilder) SelectOne() DuckDBSelectQuery {
+	q := DuckDBSelectQuery{
+		CTEs:         b.ctes,
+		SelectFields: Fields{Expr("1")},
+	}
+	if q.Dialect == "" {
+		q.Dialect = DialectDuckDB
+	}
+	return q
+}
+
+// From creates a new DuckDBSelectQuery.
+func (b duckdbQueryBuilder) From(table Table) DuckDBSelectQuery {
+	q := DuckDBSelectQuery{
+		CTEs:      b.ctes,
+		FromTable: table,
+	}
+	if q.Dialect == "" {
+		q.Dialect = DialectDuckDB
+	}
+	return q
+}
+
+// Select appends to the SelectFields in the DuckDBSelectQuery.
+func (q DuckDBSelectQuery) Select(fields ...Field) DuckDBSelectQuery {
+	q.SelectFields = append(q.SelectFields, fields...)
+	return q
+}
+
+// SelectDistinct sets the SelectFields in the DuckDBSelectQuery.
+func (q DuckDBSelectQuery) SelectDistinct(fields ...Field) DuckDBSelectQuery {
+	q.SelectFields = fields
+	q.Distinct = true
+	return q
+}
+
+// DistinctOn sets the DistinctOnFields in the DuckDBSelectQuery.
+func (q DuckDBSelectQuery) DistinctOn(fields ...Field) DuckDBSelectQuery {
+	q.DistinctOnFields = fields
+	return q
+}
+
+// SelectOne sets the DuckDBSelectQuery to SELECT 1.
+func (q DuckDBSelectQuery) SelectOne(fields ...Field) DuckDBSelectQuery {
+	q.SelectFields = Fields{Expr("1")}
+	return q
+}
+
+// From sets the FromTable field in the DuckDBSelectQuery.
+func (q DuckDBSelectQuery) From(table Table) DuckDBSelectQuery {
+	q.FromTable = table
+	return q
+}
+
+// Join joins a new Table to the DuckDBSelectQuery.
+func (q DuckDBSelectQuery) Join(table Table, predicates ...Predicate) DuckDBSelectQuery {
+	q.JoinTables = append(q.JoinTables, Join(table, predicates...))
+	return q
+}
+
+// LeftJoin left joins a new Table to the DuckDBSelectQuery.
+func (q DuckDBSelectQuery) LeftJoin(table Table, predicates ...Predicate) DuckDBSelectQuery {
+	q.JoinTables = append(q.JoinTables, LeftJoin(table, predicates...))
+	return q
+}
+
+// FullJoin full joins a new Table to the DuckDBSelectQuery.
+func (q DuckDBSelectQuery) FullJoin(table Table, predicates ...Predicate) DuckDBSelectQuery {
+	q.JoinTables = append(q.JoinTables, FullJoin(table, predicates...))
+	return q
+}
+
+// CrossJoin cross joins a new Table to the DuckDBSelectQuery.
+func (q DuckDBSelectQuery) CrossJoin(table Table) DuckDBSelectQuery {
+	q.JoinTables = append(q.JoinTables, CrossJoin(table))
+	return q
+}
+
+// CustomJoin joins a new Table to the DuckDBSelectQuery with a custom join
+// operator.
+func (q DuckDBSelectQuery) CustomJoin(joinOperator string, table Table, predicates ...Predicate) DuckDBSelectQuery {
+	q.JoinTables = append(q.JoinTables, CustomJoin(joinOperator, table, predicates...))
+	return q
+}
+
+// JoinUsing joins a new Table to the DuckDBSelectQuery with the USING operator.
+func (q DuckDBSelectQuery) JoinUsing(table Table, fields ...Field) DuckDBSelectQuery {
+	q.JoinTables = append(q.JoinTables, JoinUsing(table, fields...))
+	return q
+}
+
+// Where appends to the WherePredicate field in the DuckDBSelectQuery.
+func (q DuckDBSelectQuery) Where(predicates ...Predicate) DuckDBSelectQuery {
+	q.WherePredicate = appendPredicates(q.WherePredicate, predicates)
+	return q
+}
+
+// AllowFullTable marks the DuckDBSelectQuery as meant to read every row of a large
+// table without a LIMIT, so that it is not rejected by a Guard.
+func (q DuckDBSelectQuery) AllowFullTable() DuckDBSelectQuery {
+	q.FullTable = true
+	return q
+}
+
+// GroupBy appends to the GroupByFields field in the DuckDBSelectQuery.
+func (q DuckDBSelectQuery) GroupBy(fields ...Field) DuckDBSelectQuery {
+	q.GroupByFields = append(q.GroupByFields, fields...)
+	return q
+}
+
+// Having appends to the HavingPredicate field in the DuckDBSelectQuery.
+func (q DuckDBSelectQuery) Having(predicates ...Predicate) DuckDBSelectQuery {
+	q.HavingPredicate = appendPredicates(q.HavingPredicate, predicates)
+	return q
+}
+
+// Qualify appends to the QualifyPredicate field in the DuckDBSelectQuery.
+func (q DuckDBSelectQuery) Qualify(predicates ...Predicate) DuckDBSelectQuery {
+	q.QualifyPredicate = appendPredicates(q.QualifyPredicate, predicates)
+	return q
+}
+
+// OrderBy appends to the OrderByFields field in the DuckDBSelectQuery.
+func (q DuckDBSelectQuery) OrderBy(fields ...Field) DuckDBSelectQuery {
+	q.OrderByFields = append(q.OrderByFields, fields...)
+	return q
+}
+
+// Limit sets the LimitRows field in the DuckDBSelectQuery.
+func (q DuckDBSelectQuery) Limit(limit any) DuckDBSelectQuery {
+	q.LimitRows = limit
+	return q
+}
+
+// Offset sets the OffsetRows field in the DuckDBSelectQuery.
+func (q DuckDBSelectQuery) Offset(offset any) DuckDBSelectQuery {
+	q.OffsetRows = offset
+	return q
+}
+
+// FetchNext sets the FetchNextRows field in the DuckDBSelectQuery.
+func (q DuckDBSelectQuery) FetchNext(n any) DuckDBSelectQuery {
+	q.FetchNextRows = n
+	return q
+}
+
+// As returns a new DuckDBSelectQuery with the table alias (and optionally
+// column aliases).
+func (q DuckDBSelectQuery) As(alias string, columns ...string) DuckDBSelectQuery {
+	q.Alias = alias
+	q.Columns = columns
+	return q
+}
+
+// Field returns a new field qualified by the DuckDBSelectQuery's alias.
+func (q DuckDBSelectQuery) Field(name string) AnyField {
+	return NewAnyField(name, TableStruct{alias: q.Alias})
+}
+
+// SetFetchableFields implements the Query interface.
+func (q DuckDBSelectQuery) SetFetchableFields(fields []Field) (query Query, ok bool) {
+	if len(q.SelectFields) == 0 {
+		q.SelectFields = fields
+		return q, true
+	}
+	return q, false
+}
+
+// GetFetchableFields returns the fetchable fields of the query.
+func (q DuckDBSelectQuery) GetFetchableFields() []Field {
+	return q.SelectFields
+}
+
+// GetDialect implements the Query interface.
+func (q DuckDBSelectQuery) GetDialect() string { return q.Dialect }
+
+// SetDialect sets the dialect of the query.
+func (q DuckDBSelectQuery) SetDialect(dialect string) DuckDBSelectQuery {
+	q.Dialect = dialect
+	return q
+}
+
+// GetAlias returns the alias of the DuckDBSelectQuery.
+func (q DuckDBSelectQuery) GetAlias() string { return q.Alias }
+
+// IsTable implements the Table interface.
+func (q DuckDBSelectQuery) IsTable() {}
+
+// IsField implements the Field interface.
+func (q DuckDBSelectQuery) IsField() {}
+
+// IsArray implements the Array interface.
+func (q DuckDBSelectQuery) IsArray() {}
+
+// IsBinary implements the Binary interface.
+func (q DuckDBSelectQuery) IsBinary() {}
+
+// IsBoolean implements the Boolean interface.
+func (q DuckDBSelectQuery) IsBoolean() {}
+
+// IsEnum implements the Enum interface.
+func (q DuckDBSelectQuery) IsEnum() {}
+
+// IsJSON implements the JSON interface.
+func (q DuckDBSelectQuery) IsJSON() {}
+
+// IsNumber implements the Number interface.
+func (q DuckDBSelectQuery) IsNumber() {}
+
+// IsString implements the String interface.
+func (q DuckDBSelectQuery) IsString() {}
+
+// IsTime implements the Time interface.
+func (q DuckDBSelectQuery) IsTime() {}
+
+// IsUUID implements the UUID interface.
+func (q DuckDBSelectQuery) IsUUID() {}
+
+// OracleSelectQuery represents an Oracle SELECT query.
+type OracleSelectQuery SelectQuery
+
+var _ interface {
+	Query
+	Table
+	Field
+	Any
+} = (*OracleSelectQuery)(nil)
+
+// WriteSQL implements the SQLWriter interface.
+func (q OracleSelectQuery) WriteSQL(ctx context.Context, dialect string, buf *bytes.Buffer, args *[]any, params map[string][]int) error {
+	return SelectQuery(q).WriteSQL(ctx, dialect, buf, args, params)
+}
+
+// Select creates a new OracleSelectQuery.
+func (b oracleQueryBuilder) Select(fields ...Field) OracleSelectQuery {
+	q := OracleSelectQuery{
+		CTEs:         b.ctes,
+		SelectFields: fields,
+	}
+	if q.Dialect == "" {
+		q.Dialect = DialectOracle
+	}
+	return q
+}
+
+// SelectDistinct creates a new OracleSelectQuery.
+func (b oracleQueryBuilder) SelectDistinct(fields ...Field) OracleSelectQuery {
+	q := OracleSelectQuery{
+		CTEs:         b.ctes,
+		SelectFields: fields,
+		Distinct:     true,
+	}
+	if q.Dialect == "" {
+		q.Dialect = DialectOracle
+	}
+	return q
+}
+
+// SelectOne creates a new OracleSelectQuery.
+func (b oracleQueryBuilder) SelectOne() OracleSelectQuery {
+	q := OracleSelectQuery{
+		CTEs:         b.ctes,
+		SelectFields: Fields{Expr("1")},
+	}
+	if q.Dialect == "" {
+		q.Dialect = DialectOracle
+	}
+	return q
+}
+
+// From creates a new OracleSelectQuery.
+func (b oracleQueryBuilder) From(table Table) OracleSelectQuery {
+	q := OracleSelectQuery{
+		CTEs:      b.ctes,
+		FromTable: table,
+	}
+	if q.Dialect == "" {
+		q.Dialect = DialectOracle
+	}
+	return q
+}
+
+// Select appends to the SelectFields in the OracleSelectQuery.
+func (q OracleSelectQuery) Select(fields ...Field) OracleSelectQuery {
+	q.SelectFields = append(q.SelectFields, fields...)
+	return q
+}
+
+// SelectDistinct sets the SelectFields in the OracleSelectQuery.
+func (q OracleSelectQuery) SelectDistinct(fields ...Field) OracleSelectQuery {
+	q.SelectFields = fields
+	q.Distinct = true
+	return q
+}
+
+// SelectOne sets the OracleSelectQuery to SELECT 1.
+func (q OracleSelectQuery) SelectOne(fields ...Field) OracleSelectQuery {
+	q.SelectFields = Fields{Expr("1")}
+	return q
+}
+
+// From sets the FromTable field in the OracleSelectQuery.
+func (q OracleSelectQuery) From(table Table) OracleSelectQuery {
+	q.FromTable = table
+	return q
+}
+
+// Join joins a new Table to the OracleSelectQuery.
+func (q OracleSelectQuery) Join(table Table, predicates ...Predicate) OracleSelectQuery {
+	q.JoinTables = append(q.JoinTables, Join(table, predicates...))
+	return q
+}
+
+// LeftJoin left joins a new Table to the OracleSelectQuery.
+func (q OracleSelectQuery) LeftJoin(table Table, predicates ...Predicate) OracleSelectQuery {
+	q.JoinTables = append(q.JoinTables, LeftJoin(table, predicates...))
+	return q
+}
+
+// FullJoin full joins a new Table to the OracleSelectQuery.
+func (q OracleSelectQuery) FullJoin(table Table, predicates ...Predicate) OracleSelectQuery {
+	q.JoinTables = append(q.JoinTables, FullJoin(table, predicates...))
+	return q
+}
+
+// CrossJoin cross joins a new Table to the OracleSelectQuery.
+func (q OracleSelectQuery) CrossJoin(table Table) OracleSelectQuery {
+	q.JoinTables = append(q.JoinTables, CrossJoin(table))
+	return q
+}
+
+// CustomJoin joins a new Table to the OracleSelectQuery with a custom join
+// operator.
+func (q OracleSelectQuery) CustomJoin(joinOperator string, table Table, predicates ...Predicate) OracleSelectQuery {
+	q.JoinTables = append(q.JoinTables, CustomJoin(joinOperator, table, predicates...))
+	return q
+}
+
+// JoinUsing joins a new Table to the OracleSelectQuery with the USING operator.
+func (q OracleSelectQuery) JoinUsing(table Table, fields ...Field) OracleSelectQuery {
+	q.JoinTables = append(q.JoinTables, JoinUsing(table, fields...))
+	return q
+}
+
+// Where appends to the WherePredicate field in the OracleSelectQuery.
+func (q OracleSelectQuery) Where(predicates ...Predicate) OracleSelectQuery {
+	q.WherePredicate = appendPredicates(q.WherePredicate, predicates)
+	return q
+}
+
+// AllowFullTable marks the OracleSelectQuery as meant to read every row of a large
+// table without a LIMIT, so that it is not rejected by a Guard.
+func (q OracleSelectQuery) AllowFullTable() OracleSelectQuery {
+	q.FullTable = true
+	return q
+}
+
+// GroupBy appends to the GroupByFields field in the OracleSelectQuery.
+func (q OracleSelectQuery) GroupBy(fields ...Field) OracleSelectQuery {
+	q.GroupByFields = append(q.GroupByFields, fields...)
+	return q
+}
+
+// Having appends to the HavingPredicate field in the OracleSelectQuery.
+func (q OracleSelectQuery) Having(predicates ...Predicate) OracleSelectQuery {
+	q.HavingPredicate = appendPredicates(q.HavingPredicate, predicates)
+	return q
+}
+
+// OrderBy appends to the OrderByFields field in the OracleSelectQuery.
+func (q OracleSelectQuery) OrderBy(fields ...Field) OracleSelectQuery {
+	q.OrderByFields = append(q.OrderByFields, fields...)
+	return q
+}
+
+// Limit sets the LimitRows field in the OracleSelectQuery.
+func (q OracleSelectQuery) Limit(limit any) OracleSelectQuery {
+	q.LimitRows = limit
+	return q
+}
+
+// Offset sets the OffsetRows field in the OracleSelectQuery.
+func (q OracleSelectQuery) Offset(offset any) OracleSelectQuery {
+	q.OffsetRows = offset
+	return q
+}
+
+// FetchNext sets the FetchNextRows field in the OracleSelectQuery.
+func (q OracleSelectQuery) FetchNext(n any) OracleSelectQuery {
+	q.FetchNextRows = n
+	return q
+}
+
+// WithTies enables the FetchWithTies field in the OracleSelectQuery.
+func (q OracleSelectQuery) WithTies() OracleSelectQuery {
+	q.FetchWithTies = true
+	return q
+}
+
+// LockRows sets the lock clause of the OracleSelectQuery.
+func (q OracleSelectQuery) LockRows(lockClause string, lockValues ...any) OracleSelectQuery {
+	q.LockClause = lockClause
+	q.LockValues = lockValues
+	return q
+}
+
+// As returns a new OracleSelectQuery with the table alias (and optionally
+// column aliases).
+func (q OracleSelectQuery) As(alias string, columns ...string) OracleSelectQuery {
+	q.Alias = alias
+	q.Columns = columns
+	return q
+}
+
+// Field returns a new field qualified by the OracleSelectQuery's alias.
+func (q OracleSelectQuery) Field(name string) AnyField {
+	return NewAnyField(name, TableStruct{alias: q.Alias})
+}
+
+// SetFetchableFields implements the Query interface.
+func (q OracleSelectQuery) SetFetchableFields(fields []Field) (query Query, ok bool) {
+	if len(q.SelectFields) == 0 {
+		q.SelectFields = fields
+		return q, true
+	}
+	return q, false
+}
+
+// GetFetchableFields returns the fetchable fields of the query.
+func (q OracleSelectQuery) GetFetchableFields() []Field {
+	return q.SelectFields
+}
+
+// GetDialect implements the Query interface.
+func (q OracleSelectQuery) GetDialect() string { return q.Dialect }
+
+// SetDialect sets the dialect of the query.
+func (q OracleSelectQuery) SetDialect(dialect string) OracleSelectQuery {
+	q.Dialect = dialect
+	return q
+}
+
+// GetAlias returns the alias of the OracleSelectQuery.
+func (q OracleSelectQuery) GetAlias() string { return q.Alias }
+
+// IsTable implements the Table interface.
+func (q OracleSelectQuery) IsTable() {}
+
+// IsField implements the Field interface.
+func (q OracleSelectQuery) IsField() {}
+
+// IsArray implements the Array interface.
+func (q OracleSelectQuery) IsArray() {}
+
+// IsBinary implements the Binary interface.
+func (q OracleSelectQuery) IsBinary() {}
+
+// IsBoolean implements the Boolean interface.
+func (q OracleSelectQuery) IsBoolean() {}
+
+// IsEnum implements the Enum interface.
+func (q OracleSelectQuery) IsEnum() {}
+
+// IsJSON implements the JSON interface.
+func (q OracleSelectQuery) IsJSON() {}
+
+// IsNumber implements the Number interface.
+func (q OracleSelectQuery) IsNumber() {}
+
+// IsString implements the String interface.
+func (q OracleSelectQuery) IsString() {}
+
+// IsTime implements the Time interface.
+func (q OracleSelectQuery) IsTime() {}
+
+// IsUUID implements the UUID interface.
+func (q OracleSelectQuery) IsUUID() {}
@@ -415,6 +415,99 @@ func TestSQLServerSelectQuery(t *testing.T) {
 	})
 }
 
+func TestDuckDBSelectQuery(t *testing.T) {
+	type ACTOR struct {
+		TableStruct
+		ACTOR_ID    NumberField
+		FIRST_NAME  StringField
+		LAST_NAME   StringField
+		LAST_UPDATE TimeField
+	}
+	a := New[ACTOR]("a")
+
+	t.Run("basic", func(t *testing.T) {
+		t.Parallel()
+		q1 := DuckDB.From(a).Select(a.FIRST_NAME).SetDialect("lorem ipsum").As("q1")
+		if diff := testutil.Diff(q1.GetDialect(), "lorem ipsum"); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		if diff := testutil.Diff(q1.GetAlias(), "q1"); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		q1 = q1.SetDialect(DialectDuckDB)
+		fields := q1.GetFetchableFields()
+		if diff := testutil.Diff(fields, []Field{a.FIRST_NAME}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		_, ok := q1.SetFetchableFields([]Field{a.LAST_NAME})
+		if ok {
+			t.Fatal(testutil.Callers(), "field should not have been set")
+		}
+		q1.SelectFields = q1.SelectFields[:0]
+		_, ok = q1.SetFetchableFields([]Field{a.LAST_NAME})
+		if !ok {
+			t.Fatal(testutil.Callers(), "field should have been set")
+		}
+	})
+
+	t.Run("DistinctOn Qualify", func(t *testing.T) {
+		t.Parallel()
+		var tt TestTable
+		tt.item = DuckDB.
+			With(NewCTE("cte", nil, Queryf("SELECT 1"))).
+			From(a).
+			DistinctOn(a.FIRST_NAME).
+			Select(a.FIRST_NAME, a.LAST_NAME).
+			Where(a.ACTOR_ID.GtInt(10)).
+			Qualify(Expr("ROW_NUMBER() OVER (PARTITION BY {} ORDER BY {}) = {}", a.FIRST_NAME, a.LAST_UPDATE, 1)).
+			OrderBy(a.FIRST_NAME).
+			Limit(10).
+			Offset(20)
+		tt.wantQuery = "WITH cte AS (SELECT 1)" +
+			" SELECT DISTINCT ON (a.first_name) a.first_name, a.last_name" +
+			" FROM actor AS a" +
+			" WHERE a.actor_id > $1" +
+			" QUALIFY ROW_NUMBER() OVER (PARTITION BY a.first_name ORDER BY a.last_update) = $2" +
+			" ORDER BY a.first_name" +
+			" LIMIT $3" +
+			" OFFSET $4"
+		tt.wantArgs = []any{10, 1, 10, 20}
+		tt.assert(t)
+	})
+
+	t.Run("FetchNext", func(t *testing.T) {
+		t.Parallel()
+		var tt TestTable
+		tt.item = DuckDB.
+			From(a).
+			Select(a.FIRST_NAME).
+			FullJoin(a, Expr("1 = 1")).
+			OrderBy(a.ACTOR_ID).
+			Offset(10).
+			FetchNext(20)
+		tt.wantQuery = "SELECT a.first_name" +
+			" FROM actor AS a" +
+			" FULL JOIN actor AS a ON 1 = 1" +
+			" ORDER BY a.actor_id" +
+			" OFFSET $1" +
+			" FETCH NEXT $2 ROWS ONLY"
+		tt.wantArgs = []any{10, 20}
+		tt.assert(t)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		t.Parallel()
+		_, _, err := ToSQL(DialectDuckDB, Postgres.From(a).Select(a.FIRST_NAME).OrderBy(a.ACTOR_ID).FetchNext(5).WithTies(), nil)
+		if err == nil {
+			t.Fatal(testutil.Callers(), "expected error but got nil")
+		}
+		_, _, err = ToSQL(DialectSQLite, DuckDB.From(a).Select(a.FIRST_NAME).Qualify(Expr("1 = 1")), nil)
+		if err == nil {
+			t.Fatal(testutil.Callers(), "expected error but got nil")
+		}
+	})
+}
+
 func TestSelectQuery(t *testing.T) {
 	t.Run("basic", func(t *testing.T) {
 		t.Parallel()
@@ -0,0 +1,667 @@
+package sq
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoShardKey is returned by a ShardedDB when it cannot tell which shard a
+// query belongs to.
+var ErrNoShardKey = errors.New("sq: no shard key in context or query")
+
+// ShardedDB is a DB that spreads its data across several DBs (shards) by a
+// shard key. The shard a query runs on is picked using the shard key carried
+// in the context (see WithShardKey) or, failing that, the shard key found in
+// the query itself:
+//
+//   - a top-level ShardKey = value predicate in the WHERE clause of a SELECT,
+//     UPDATE or DELETE query.
+//   - the ShardKey column of an INSERT query (all rows must belong to the
+//     same shard).
+//
+// If neither is present, ErrNoShardKey is returned. To run a query on every
+// shard, use FetchAllShards.
+type ShardedDB struct {
+	// Shards are the underlying DBs.
+	Shards []DB
+
+	// ShardKey is the field that holds the shard key.
+	ShardKey Field
+
+	// ShardFunc maps a shard key to the index of its shard, which must be
+	// between 0 and len(Shards)-1. Keys wrapped in an sql.NamedArg or
+	// implementing driver.Valuer are unwrapped first, so that a key routes
+	// the same way however it is passed. Defaults to the FNV-1a hash of
+	// fmt.Sprint(key) modulo the number of shards.
+	ShardFunc func(key any) int
+}
+
+var _ interface {
+	DB
+	Txer
+} = (*ShardedDB)(nil)
+
+// NewShardedDB creates a new ShardedDB that shards data across shards by
+// shardKey.
+func NewShardedDB(shardKey Field, shards ...DB) *ShardedDB {
+	return &ShardedDB{
+		Shards:   shards,
+		ShardKey: shardKey,
+	}
+}
+
+type shardKeyContextKey struct{}
+
+// WithShardKey returns a copy of ctx that carries the shard key. A ShardedDB
+// runs every query made with the returned context on the key's shard.
+func WithShardKey(ctx context.Context, key any) context.Context {
+	return context.WithValue(ctx, shardKeyContextKey{}, key)
+}
+
+// Shard returns the shard that key belongs to.
+func (db *ShardedDB) Shard(key any) (DB, error) {
+	index, err := db.shardIndex(key)
+	if err != nil {
+		return nil, err
+	}
+	return db.Shards[index], nil
+}
+
+func (db *ShardedDB) shardIndex(key any) (int, error) {
+	if len(db.Shards) == 0 {
+		return 0, fmt.Errorf("sq: ShardedDB has no shards")
+	}
+	key, err := shardKeyValue(key)
+	if err != nil {
+		return 0, err
+	}
+	if db.ShardFunc != nil {
+		index := db.ShardFunc(key)
+		if index < 0 || index >= len(db.Shards) {
+			return 0, fmt.Errorf("sq: ShardFunc returned shard %d for key %v, want 0 to %d", index, key, len(db.Shards)-1)
+		}
+		return index, nil
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(fmt.Sprint(key)))
+	return int(h.Sum32() % uint32(len(db.Shards))), nil
+}
+
+// shardKeyValue unwraps the value of a shard key wrapped in an sql.NamedArg
+// or a driver.Valuer.
+func shardKeyValue(key any) (any, error) {
+	for {
+		switch value := key.(type) {
+		case sql.NamedArg:
+			key = value.Value
+		case driver.Valuer:
+			if rv := reflect.ValueOf(value); rv.Kind() == reflect.Pointer && rv.IsNil() {
+				return nil, nil
+			}
+			v, err := value.Value()
+			if err != nil {
+				return nil, fmt.Errorf("sq: shard key: %w", err)
+			}
+			key = v
+		default:
+			return key, nil
+		}
+	}
+}
+
+// resolve returns the shard that the query run with ctx belongs to.
+func (db *ShardedDB) resolve(ctx context.Context) (DB, error) {
+	if len(db.Shards) == 0 {
+		return nil, fmt.Errorf("sq: ShardedDB has no shards")
+	}
+	if key := ctx.Value(shardKeyContextKey{}); key != nil {
+		return db.Shard(key)
+	}
+	if query, ok := QueryFromContext(ctx); ok {
+		index, ok, err := db.queryShardIndex(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return db.Shards[index], nil
+		}
+	}
+	return nil, ErrNoShardKey
+}
+
+// QueryContext implements the DB interface.
+func (db *ShardedDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	shard, err := db.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return shard.QueryContext(ctx, query, args...)
+}
+
+// ExecContext implements the DB interface.
+func (db *ShardedDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	shard, err := db.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return shard.ExecContext(ctx, query, args...)
+}
+
+// PrepareContext implements the DB interface. The shard key must be carried
+// in ctx.
+func (db *ShardedDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	shard, err := db.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return shard.PrepareContext(ctx, query)
+}
+
+// Begin implements the Txer interface. It always fails because Begin takes
+// no context to carry the shard key; use BeginTx instead.
+func (db *ShardedDB) Begin() (*sql.Tx, error) {
+	return db.BeginTx(context.Background(), nil)
+}
+
+// BeginTx implements the Txer interface. The shard key must be carried in
+// ctx.
+func (db *ShardedDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	shard, err := db.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	txer, ok := shard.(Txer)
+	if !ok {
+		return nil, fmt.Errorf("sq: %T cannot begin transactions", shard)
+	}
+	return txer.BeginTx(ctx, opts)
+}
+
+// GetDialect returns the dialect of the shards.
+func (db *ShardedDB) GetDialect() string {
+	if len(db.Shards) == 0 {
+		return ""
+	}
+	return dbDialect(db.Shards[0])
+}
+
+// queryShardIndex returns the index of the shard that query belongs to, if
+// the query contains the shard key.
+func (db *ShardedDB) queryShardIndex(ctx context.Context, query Query) (index int, ok bool, err error) {
+	if db.ShardKey == nil {
+		return 0, false, nil
+	}
+	dialect := query.GetDialect()
+	if dialect == "" {
+		dialect = db.GetDialect()
+	}
+	shardKey, err := sqlString(ctx, dialect, db.ShardKey)
+	if err != nil {
+		return 0, false, err
+	}
+	switch q := query.(type) {
+	case SelectQuery:
+		return db.predicateShardIndex(ctx, dialect, shardKey, q.WherePredicate)
+	case SQLiteSelectQuery:
+		return db.predicateShardIndex(ctx, dialect, shardKey, q.WherePredicate)
+	case PostgresSelectQuery:
+		return db.predicateShardIndex(ctx, dialect, shardKey, q.WherePredicate)
+	case MySQLSelectQuery:
+		return db.predicateShardIndex(ctx, dialect, shardKey, q.WherePredicate)
+	case SQLServerSelectQuery:
+		return db.predicateShardIndex(ctx, dialect, shardKey, q.WherePredicate)
+	case DuckDBSelectQuery:
+		return db.predicateShardIndex(ctx, dialect, shardKey, q.WherePredicate)
+	case OracleSelectQuery:
+		return db.predicateShardIndex(ctx, dialect, shardKey, q.WherePredicate)
+	case UpdateQuery:
+		return db.predicateShardIndex(ctx, dialect, shardKey, q.WherePredicate)
+	case SQLiteUpdateQuery:
+		return db.predicateShardIndex(ctx, dialect, shardKey, q.WherePredicate)
+	case PostgresUpdateQuery:
+		return db.predicateShardIndex(ctx, dialect, shardKey, q.WherePredicate)
+	case MySQLUpdateQuery:
+		return db.predicateShardIndex(ctx, dialect, shardKey, q.WherePredicate)
+	case SQLServerUpdateQuery:
+		return db.predicateShardIndex(ctx, dialect, shardKey, q.WherePredicate)
+	case DuckDBUpdateQuery:
+		return db.predicateShardIndex(ctx, dialect, shardKey, q.WherePredicate)
+	case OracleUpdateQuery:
+		return db.predicateShardIndex(ctx, dialect, shardKey, q.WherePredicate)
+	case DeleteQuery:
+		return db.predicateShardIndex(ctx, dialect, shardKey, q.WherePredicate)
+	case SQLiteDeleteQuery:
+		return db.predicateShardIndex(ctx, dialect, shardKey, q.WherePredicate)
+	case PostgresDeleteQuery:
+		return db.predicateShardIndex(ctx, dialect, shardKey, q.WherePredicate)
+	case MySQLDeleteQuery:
+		return db.predicateShardIndex(ctx, dialect, shardKey, q.WherePredicate)
+	case SQLServerDeleteQuery:
+		return db.predicateShardIndex(ctx, dialect, shardKey, q.WherePredicate)
+	case DuckDBDeleteQuery:
+		return db.predicateShardIndex(ctx, dialect, shardKey, q.WherePredicate)
+	case OracleDeleteQuery:
+		return db.predicateShardIndex(ctx, dialect, shardKey, q.WherePredicate)
+	case InsertQuery:
+		return db.insertShardIndex(ctx, dialect, shardKey, q)
+	case SQLiteInsertQuery:
+		return db.insertShardIndex(ctx, dialect, shardKey, InsertQuery(q))
+	case PostgresInsertQuery:
+		return db.insertShardIndex(ctx, dialect, shardKey, InsertQuery(q))
+	case MySQLInsertQuery:
+		return db.insertShardIndex(ctx, dialect, shardKey, InsertQuery(q))
+	case SQLServerInsertQuery:
+		return db.insertShardIndex(ctx, dialect, shardKey, InsertQuery(q))
+	case DuckDBInsertQuery:
+		return db.insertShardIndex(ctx, dialect, shardKey, InsertQuery(q))
+	case OracleInsertQuery:
+		return db.insertShardIndex(ctx, dialect, shardKey, InsertQuery(q))
+	}
+	return 0, false, nil
+}
+
+// predicateShardIndex looks for a ShardKey = value comparison among the
+// top-level AND-ed predicates of predicate.
+func (db *ShardedDB) predicateShardIndex(ctx context.Context, dialect string, shardKey string, predicate Predicate) (index int, ok bool, err error) {
+	switch p := predicate.(type) {
+	case VariadicPredicate:
+		if p.IsDisjunction && len(p.Predicates) > 1 {
+			return 0, false, nil
+		}
+		for _, predicate := range p.Predicates {
+			index, ok, err = db.predicateShardIndex(ctx, dialect, shardKey, predicate)
+			if err != nil || ok {
+				return index, ok, err
+			}
+		}
+	case Expression:
+		if p.format != "{} = {}" || len(p.values) != 2 {
+			return 0, false, nil
+		}
+		for i, value := range p.values {
+			field, isField := value.(Field)
+			if !isField {
+				continue
+			}
+			str, err := sqlString(ctx, dialect, field)
+			if err != nil {
+				return 0, false, err
+			}
+			if str != shardKey {
+				continue
+			}
+			key := p.values[1-i]
+			if _, ok := key.(SQLWriter); ok {
+				return 0, false, nil
+			}
+			index, err := db.shardIndex(key)
+			if err != nil {
+				return 0, false, err
+			}
+			return index, true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+// insertShardIndex looks for the ShardKey column in an INSERT query.
+func (db *ShardedDB) insertShardIndex(ctx context.Context, dialect string, shardKey string, q InsertQuery) (index int, ok bool, err error) {
+	if q.ColumnMapper != nil {
+		col := &Column{dialect: q.Dialect}
+		defer mapperFunctionPanicked(&err)
+		q.ColumnMapper(ctx, col)
+		q.InsertColumns, q.RowValues = col.insertColumns, col.rowValues
+	}
+	position := -1
+	for i, field := range q.InsertColumns {
+		str, err := sqlString(ctx, dialect, field)
+		if err != nil {
+			return 0, false, err
+		}
+		// Insert columns may be written without their table qualifier.
+		if str == shardKey || strings.HasSuffix(shardKey, "."+str) {
+			position = i
+			break
+		}
+	}
+	if position < 0 || len(q.RowValues) == 0 {
+		return 0, false, nil
+	}
+	for i, rowValue := range q.RowValues {
+		if position >= len(rowValue) {
+			return 0, false, nil
+		}
+		rowIndex, err := db.shardIndex(rowValue[position])
+		if err != nil {
+			return 0, false, err
+		}
+		if i > 0 && rowIndex != index {
+			return 0, false, fmt.Errorf("sq: INSERT rows belong to different shards")
+		}
+		index = rowIndex
+	}
+	return index, true, nil
+}
+
+// sqlString renders a Field as an SQL string.
+func sqlString(ctx context.Context, dialect string, field Field) (string, error) {
+	buf := bufPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer bufPool.Put(buf)
+	var args []any
+	err := field.WriteSQL(ctx, dialect, buf, &args, nil)
+	if err != nil {
+		return "", err
+	}
+	if len(args) > 0 {
+		return Sprintf(dialect, buf.String(), args)
+	}
+	return buf.String(), nil
+}
+
+// FetchAllShards runs the query on every shard of db concurrently and
+// returns the results of all shards, in shard order. The rowMapper may be
+// called concurrently.
+func FetchAllShards[T any](ctx context.Context, db *ShardedDB, query Query, rowMapper RowMapper[T]) ([]T, error) {
+	shardResults, err := fetchAllShards(ctx, db, query, rowMapper, nil)
+	if err != nil {
+		return nil, err
+	}
+	var results []T
+	for _, shardResult := range shardResults {
+		results = append(results, shardResult.result)
+	}
+	return results, nil
+}
+
+// FetchAllShardsOrdered is like FetchAllShards, but the results of all shards
+// are merged in the order given by the query's ORDER BY fields. If the query
+// has a LIMIT (or TOP or FETCH NEXT) and an OFFSET, each shard is asked for
+// the first OFFSET+LIMIT rows and the OFFSET and LIMIT are applied to the
+// merged results. They must be integers, and percentages and WITH TIES are
+// not supported. The query must be a SELECT query.
+func FetchAllShardsOrdered[T any](ctx context.Context, db *ShardedDB, query Query, rowMapper RowMapper[T]) ([]T, error) {
+	var q SelectQuery
+	var wrap func(SelectQuery) Query
+	switch query := query.(type) {
+	case SelectQuery:
+		q, wrap = query, func(q SelectQuery) Query { return q }
+	case SQLiteSelectQuery:
+		q, wrap = SelectQuery(query), func(q SelectQuery) Query { return SQLiteSelectQuery(q) }
+	case PostgresSelectQuery:
+		q, wrap = SelectQuery(query), func(q SelectQuery) Query { return PostgresSelectQuery(q) }
+	case MySQLSelectQuery:
+		q, wrap = SelectQuery(query), func(q SelectQuery) Query { return MySQLSelectQuery(q) }
+	case SQLServerSelectQuery:
+		q, wrap = SelectQuery(query), func(q SelectQuery) Query { return SQLServerSelectQuery(q) }
+	case DuckDBSelectQuery:
+		q, wrap = SelectQuery(query), func(q SelectQuery) Query { return DuckDBSelectQuery(q) }
+	case OracleSelectQuery:
+		q, wrap = SelectQuery(query), func(q SelectQuery) Query { return OracleSelectQuery(q) }
+	default:
+		return nil, fmt.Errorf("sq: cannot merge the results of %T in order, only SELECT queries are supported", query)
+	}
+	if q.LimitTopPercent != nil || q.FetchWithTies {
+		return nil, fmt.Errorf("sq: cannot merge the results of a query with TOP PERCENT or WITH TIES in order")
+	}
+	var offset, limit int64
+	var ok bool
+	hasLimit := false
+	if q.OffsetRows != nil {
+		if offset, ok = shardRowCount(q.OffsetRows); !ok {
+			return nil, fmt.Errorf("sq: cannot merge the results of a query with OFFSET %v in order, OFFSET must be an integer", q.OffsetRows)
+		}
+	}
+	for _, rows := range []any{q.LimitRows, q.LimitTop, q.FetchNextRows} {
+		if rows == nil {
+			continue
+		}
+		n, ok := shardRowCount(rows)
+		if !ok {
+			return nil, fmt.Errorf("sq: cannot merge the results of a query with LIMIT %v in order, LIMIT must be an integer", rows)
+		}
+		if !hasLimit || n < limit {
+			limit, hasLimit = n, true
+		}
+	}
+	// Each shard returns the rows that may end up in the merged results,
+	// which are then offset and limited.
+	shardQuery := q
+	if q.OffsetRows != nil {
+		shardQuery.OffsetRows = nil
+		if q.FetchNextRows != nil {
+			// FETCH NEXT requires an OFFSET in some dialects.
+			shardQuery.OffsetRows = 0
+		}
+		if q.LimitRows != nil {
+			shardQuery.LimitRows = offset + limit
+		}
+		if q.LimitTop != nil {
+			shardQuery.LimitTop = offset + limit
+		}
+		if q.FetchNextRows != nil {
+			shardQuery.FetchNextRows = offset + limit
+		}
+	}
+	dialect := queryDialect(db, query)
+	orders := make([]shardOrder, 0, len(q.OrderByFields))
+	for _, field := range q.OrderByFields {
+		order, err := newShardOrder(ctx, dialect, field)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	merged, err := fetchAllShards(ctx, db, wrap(shardQuery), rowMapper, orders)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortStableFunc(merged, func(a, b shardResult[T]) int {
+		for i, order := range orders {
+			if c := order.compare(a.sortValues[i], b.sortValues[i]); c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+	merged = merged[min(offset, int64(len(merged))):]
+	if hasLimit && limit < int64(len(merged)) {
+		merged = merged[:limit]
+	}
+	results := make([]T, len(merged))
+	for i := range merged {
+		results[i] = merged[i].result
+	}
+	return results, nil
+}
+
+// shardRowCount returns the number of rows of a LIMIT, OFFSET, TOP or FETCH
+// NEXT clause, if it is a non-negative integer.
+func shardRowCount(rows any) (int64, bool) {
+	value := reflect.ValueOf(rows)
+	switch {
+	case value.CanInt() && value.Int() >= 0:
+		return value.Int(), true
+	case value.CanUint() && value.Uint() <= 1<<63-1:
+		return int64(value.Uint()), true
+	}
+	return 0, false
+}
+
+type shardResult[T any] struct {
+	result     T
+	sortValues []any
+}
+
+func fetchAllShards[T any](ctx context.Context, db *ShardedDB, query Query, rowMapper RowMapper[T], orders []shardOrder) ([]shardResult[T], error) {
+	if rowMapper == nil {
+		return nil, fmt.Errorf("rowMapper is nil")
+	}
+	_, isStatic := query.SetFetchableFields(nil)
+	isStatic = !isStatic
+	mapper := func(ctx context.Context, row *Row) shardResult[T] {
+		result := shardResult[T]{result: rowMapper(ctx, row)}
+		for _, order := range orders {
+			if isStatic {
+				result.sortValues = append(result.sortValues, row.Value(order.name))
+			} else {
+				result.sortValues = append(result.sortValues, row.Value("{}", order.expr))
+			}
+		}
+		return result
+	}
+	shardResults := make([][]shardResult[T], len(db.Shards))
+	errs := make([]error, len(db.Shards))
+	var wg sync.WaitGroup
+	for i, shard := range db.Shards {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			shardResults[i], errs[i] = FetchAllContext(ctx, shard, query, mapper)
+			if errs[i] != nil {
+				errs[i] = fmt.Errorf("shard %d: %w", i, errs[i])
+			}
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	var results []shardResult[T]
+	for _, shardResult := range shardResults {
+		results = append(results, shardResult...)
+	}
+	return results, nil
+}
+
+// shardOrder is an ORDER BY field of a query whose results are merged across
+// shards.
+type shardOrder struct {
+	expr       rawSQL // the field without its ordering e.g. "tbl.name"
+	name       string // the column name e.g. "name"
+	desc       bool
+	nullsFirst bool
+}
+
+func newShardOrder(ctx context.Context, dialect string, field Field) (shardOrder, error) {
+	str, err := sqlString(ctx, dialect, field)
+	if err != nil {
+		return shardOrder{}, err
+	}
+	var order shardOrder
+	// Postgres and Oracle sort NULLs as if they were larger than any other
+	// value, the other dialects sort them as smaller.
+	nullsLarger := dialect == DialectPostgres || dialect == DialectOracle
+	nullsOrderSet := false
+	if s, ok := strings.CutSuffix(str, " NULLS FIRST"); ok {
+		str, order.nullsFirst, nullsOrderSet = s, true, true
+	} else if s, ok := strings.CutSuffix(str, " NULLS LAST"); ok {
+		str, order.nullsFirst, nullsOrderSet = s, false, true
+	}
+	if s, ok := strings.CutSuffix(str, " DESC"); ok {
+		str, order.desc = s, true
+	} else {
+		str = strings.TrimSuffix(str, " ASC")
+	}
+	if !nullsOrderSet {
+		order.nullsFirst = nullsLarger == order.desc
+	}
+	order.expr = rawSQL(str)
+	order.name = str
+	if named, ok := field.(interface{ GetName() string }); ok {
+		order.name = named.GetName()
+	}
+	return order, nil
+}
+
+// rawSQL is SQL that is written as it is, so that it is not parsed as a
+// format string.
+type rawSQL string
+
+// WriteSQL implements the SQLWriter interface.
+func (s rawSQL) WriteSQL(ctx context.Context, dialect string, buf *bytes.Buffer, args *[]any, params map[string][]int) error {
+	buf.WriteString(string(s))
+	return nil
+}
+
+func (order shardOrder) compare(a, b any) int {
+	if a == nil || b == nil {
+		switch {
+		case a == nil && b == nil:
+			return 0
+		case (a == nil) == order.nullsFirst:
+			return -1
+		default:
+			return 1
+		}
+	}
+	c := compareValues(a, b)
+	if order.desc {
+		return -c
+	}
+	return c
+}
+
+// compareValues compares two values returned by a database driver.
+func compareValues(a, b any) int {
+	switch a := a.(type) {
+	case int64:
+		switch b := b.(type) {
+		case int64:
+			return compareOrdered(a, b)
+		case float64:
+			return compareOrdered(float64(a), b)
+		}
+	case float64:
+		switch b := b.(type) {
+		case float64:
+			return compareOrdered(a, b)
+		case int64:
+			return compareOrdered(a, float64(b))
+		}
+	case string:
+		if b, ok := b.(string); ok {
+			return strings.Compare(a, b)
+		}
+	case []byte:
+		if b, ok := b.([]byte); ok {
+			return bytes.Compare(a, b)
+		}
+	case time.Time:
+		if b, ok := b.(time.Time); ok {
+			return a.Compare(b)
+		}
+	case bool:
+		if b, ok := b.(bool); ok {
+			switch {
+			case a == b:
+				return 0
+			case !a:
+				return -1
+			default:
+				return 1
+			}
+		}
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func compareOrdered[T int64 | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
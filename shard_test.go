@@ -0,0 +1,283 @@
+package sq
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/blink-io/sq/internal/testutil"
+)
+
+func TestShardedDB(t *testing.T) {
+	type Item struct {
+		TenantID int
+		Name     string
+		Score    int
+	}
+	ITEM := New[struct {
+		TableStruct `sq:"item"`
+		TENANT_ID   NumberField
+		NAME        StringField
+		SCORE       NumberField
+	}]("")
+	itemRowMapper := func(ctx context.Context, row *Row) Item {
+		return Item{
+			TenantID: row.IntField(ITEM.TENANT_ID),
+			Name:     row.StringField(ITEM.NAME),
+			Score:    row.IntField(ITEM.SCORE),
+		}
+	}
+	newShardedDB := func(t *testing.T) *ShardedDB {
+		var shards []DB
+		for i := 0; i < 3; i++ {
+			shards = append(shards, newSQLiteDB(t, "CREATE TABLE item (tenant_id INT, name TEXT, score INT)"))
+		}
+		db := NewShardedDB(ITEM.TENANT_ID, shards...)
+		// Tenant n lives on shard n % 3.
+		db.ShardFunc = func(key any) int {
+			switch key := key.(type) {
+			case int:
+				return key % 3
+			case int64:
+				return int(key % 3)
+			}
+			return 0
+		}
+		return db
+	}
+	// countRows returns the number of rows in each shard.
+	countRows := func(t *testing.T, db *ShardedDB) []int {
+		t.Helper()
+		var counts []int
+		for _, shard := range db.Shards {
+			count, err := FetchOne(shard, SQLite.From(ITEM), func(ctx context.Context, row *Row) int {
+				return row.Int("COUNT(*)")
+			})
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			counts = append(counts, count)
+		}
+		return counts
+	}
+	ctx := context.Background()
+
+	t.Run("shard key from query", func(t *testing.T) {
+		db := newShardedDB(t)
+		_, err := Exec(db, SQLite.InsertInto(ITEM).
+			Columns(ITEM.TENANT_ID, ITEM.NAME, ITEM.SCORE).
+			Values(1, "a", 10).
+			Values(1, "b", 20),
+		)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		_, err = Exec(db, SQLite.InsertInto(ITEM).ColumnValues(func(ctx context.Context, col *Column) {
+			col.SetInt(ITEM.TENANT_ID, 2)
+			col.SetString(ITEM.NAME, "c")
+			col.SetInt(ITEM.SCORE, 30)
+		}))
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if diff := testutil.Diff(countRows(t, db), []int{0, 2, 1}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		items, err := FetchAll(db, SQLite.
+			From(ITEM).
+			Where(ITEM.NAME.NeString("z"), ITEM.TENANT_ID.EqInt(1)).
+			OrderBy(ITEM.NAME),
+			itemRowMapper,
+		)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if diff := testutil.Diff(items, []Item{{1, "a", 10}, {1, "b", 20}}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		_, err = Exec(db, SQLite.Update(ITEM).Set(ITEM.SCORE.SetInt(0)).Where(ITEM.TENANT_ID.EqInt(2)))
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		_, err = Exec(db, SQLite.DeleteFrom(ITEM).Where(ITEM.TENANT_ID.EqInt(1), ITEM.NAME.EqString("a")))
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if diff := testutil.Diff(countRows(t, db), []int{0, 1, 1}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("shard key from context", func(t *testing.T) {
+		db := newShardedDB(t)
+		_, err := ExecContext(WithShardKey(ctx, 5), db, SQLite.Queryf("INSERT INTO item (tenant_id, name, score) VALUES (5, 'a', 1)"))
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if diff := testutil.Diff(countRows(t, db), []int{0, 0, 1}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("wrapped shard keys", func(t *testing.T) {
+		db := newShardedDB(t)
+		_, err := Exec(db, SQLite.InsertInto(ITEM).
+			Columns(ITEM.TENANT_ID, ITEM.NAME, ITEM.SCORE).
+			Values(sql.Named("tenant_id", 1), "a", 10),
+		)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		_, err = Exec(db, SQLite.InsertInto(ITEM).
+			Columns(ITEM.TENANT_ID, ITEM.NAME, ITEM.SCORE).
+			Values(sql.NullInt64{Int64: 1, Valid: true}, "b", 20),
+		)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if diff := testutil.Diff(countRows(t, db), []int{0, 2, 0}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		// The default ShardFunc hashes the unwrapped key too.
+		db.ShardFunc = nil
+		want, err := db.Shard(int64(42))
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		for _, key := range []any{sql.Named("tenant_id", int64(42)), sql.NullInt64{Int64: 42, Valid: true}} {
+			got, err := db.Shard(key)
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			if got != want {
+				t.Errorf(testutil.Callers()+" %#v: got a different shard than the bare key", key)
+			}
+		}
+	})
+
+	t.Run("ShardFunc out of range", func(t *testing.T) {
+		db := newShardedDB(t)
+		db.ShardFunc = func(key any) int { return 3 }
+		_, err := db.Shard(1)
+		if err == nil {
+			t.Error(testutil.Callers(), "expected an error")
+		}
+		_, err = FetchAll(db, SQLite.From(ITEM).Where(ITEM.TENANT_ID.EqInt(1)), itemRowMapper)
+		if err == nil {
+			t.Error(testutil.Callers(), "expected an error")
+		}
+	})
+
+	t.Run("no shard key", func(t *testing.T) {
+		db := newShardedDB(t)
+		_, err := FetchAll(db, SQLite.From(ITEM).Where(ITEM.SCORE.GtInt(1)), itemRowMapper)
+		if !errors.Is(err, ErrNoShardKey) {
+			t.Errorf(testutil.Callers()+" expected ErrNoShardKey, got %v", err)
+		}
+		// A disjunction does not pin the query to a shard.
+		_, err = FetchAll(db, SQLite.From(ITEM).Where(Or(ITEM.TENANT_ID.EqInt(1), ITEM.TENANT_ID.EqInt(2))), itemRowMapper)
+		if !errors.Is(err, ErrNoShardKey) {
+			t.Errorf(testutil.Callers()+" expected ErrNoShardKey, got %v", err)
+		}
+		_, err = Exec(db, SQLite.InsertInto(ITEM).
+			Columns(ITEM.TENANT_ID, ITEM.NAME).
+			Values(1, "a").
+			Values(2, "b"),
+		)
+		if err == nil {
+			t.Error(testutil.Callers(), "expected an error for rows spanning shards")
+		}
+	})
+
+	t.Run("scatter-gather", func(t *testing.T) {
+		db := newShardedDB(t)
+		for tenantID := 0; tenantID < 6; tenantID++ {
+			_, err := Exec(db, SQLite.InsertInto(ITEM).
+				Columns(ITEM.TENANT_ID, ITEM.NAME, ITEM.SCORE).
+				Values(tenantID, fmt.Sprintf("item%d", tenantID), (tenantID*7)%6),
+			)
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+		}
+		items, err := FetchAllShards(ctx, db, SQLite.From(ITEM).OrderBy(ITEM.TENANT_ID), itemRowMapper)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		var tenantIDs []int
+		for _, item := range items {
+			tenantIDs = append(tenantIDs, item.TenantID)
+		}
+		// Shard order.
+		if diff := testutil.Diff(tenantIDs, []int{0, 3, 1, 4, 2, 5}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+
+		items, err = FetchAllShardsOrdered(ctx, db, SQLite.From(ITEM).OrderBy(ITEM.SCORE.Desc()).Limit(4), itemRowMapper)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		var scores []int
+		for _, item := range items {
+			scores = append(scores, item.Score)
+		}
+		if diff := testutil.Diff(scores, []int{5, 4, 3, 2}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+
+		// OFFSET is applied once to the merged results.
+		for _, tt := range []struct {
+			query      SQLiteSelectQuery
+			wantScores []int
+		}{
+			{SQLite.From(ITEM).OrderBy(ITEM.SCORE.Desc()).Offset(1).Limit(3), []int{4, 3, 2}},
+			{SQLite.From(ITEM).OrderBy(ITEM.SCORE.Desc()).Offset(int64(4)), []int{1, 0}},
+			{SQLite.From(ITEM).OrderBy(ITEM.SCORE).Offset(5).Limit(3), []int{5}},
+		} {
+			items, err = FetchAllShardsOrdered(ctx, db, tt.query, itemRowMapper)
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			scores = scores[:0]
+			for _, item := range items {
+				scores = append(scores, item.Score)
+			}
+			if diff := testutil.Diff(scores, tt.wantScores); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+		}
+		_, err = FetchAllShardsOrdered(ctx, db, SQLite.From(ITEM).OrderBy(ITEM.SCORE).Offset(Param("offset", 1)), itemRowMapper)
+		if err == nil {
+			t.Error(testutil.Callers(), "expected an error for a non-integer OFFSET")
+		}
+
+		// Order expressions are not parsed as format strings.
+		names, err := FetchAllShardsOrdered(ctx, db, SQLite.From(ITEM).OrderBy(Expr("({} % 4) || {}", ITEM.SCORE, "{}"), ITEM.NAME), func(ctx context.Context, row *Row) string {
+			return row.StringField(ITEM.NAME)
+		})
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if diff := testutil.Diff(names, []string{"item0", "item4", "item1", "item5", "item2", "item3"}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+
+		// Queries with explicit fields are sorted by column name.
+		names, err = FetchAllShardsOrdered(ctx, db, SQLite.Select(ITEM.NAME).From(ITEM).OrderBy(ITEM.NAME.Desc()), func(ctx context.Context, row *Row) string {
+			return row.String("name")
+		})
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if diff := testutil.Diff(names, []string{"item5", "item4", "item3", "item2", "item1", "item0"}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+
+		_, err = FetchAllShardsOrdered(ctx, db, SQLite.Queryf("SELECT {*} FROM item"), itemRowMapper)
+		if err == nil {
+			t.Error(testutil.Callers(), "expected an error for a non-SELECT query")
+		}
+	})
+}
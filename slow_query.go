@@ -0,0 +1,219 @@
+package sq
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bokwoon95/sq"
+)
+
+// SlowQueryConfig is the config used for a SlowQueryLogger.
+type SlowQueryConfig struct {
+	// Queries that take at least Threshold are logged. Defaults to 100ms.
+	Threshold time.Duration
+
+	// If true, the plan of slow queries is captured with the EXPLAIN of
+	// their dialect and logged along with them. Only queries logged through
+	// a DB wrapped with LogSlowQueries can be explained.
+	Explain bool
+
+	// Timeout of the EXPLAIN statement. Defaults to 5s.
+	ExplainTimeout time.Duration
+
+	// Fraction (between 0 and 1) of slow queries that are logged. Defaults
+	// to 1, i.e. every slow query.
+	SampleRate float64
+
+	// Maximum number of slow queries logged per RateInterval. Slow queries
+	// beyond it are counted and the count is reported in the next entry. If
+	// zero, there is no limit.
+	RateLimit int
+
+	// Interval of RateLimit. Defaults to a minute.
+	RateInterval time.Duration
+
+	// Dispatch logging asynchronously, so that capturing the plan does not
+	// block function calls (logs may arrive out of order).
+	LogAsynchronously bool
+
+	// If true, logs are shown as plaintext (no color).
+	NoColor bool
+}
+
+// SlowQueryLogger is a Logger that only logs queries that take longer than
+// a threshold, optionally along with their query plan. Sampling and rate
+// limits keep it from overwhelming the logs (and the database, since every
+// plan is an extra statement) when many queries are slow at once.
+type SlowQueryLogger struct {
+	logger *log.Logger
+	config SlowQueryConfig
+
+	mu          sync.Mutex
+	windowStart time.Time
+	windowCount int
+	suppressed  int
+}
+
+var _ Logger = (*SlowQueryLogger)(nil)
+
+// NewSlowQueryLogger returns a new SlowQueryLogger. The out, prefix and flag
+// arguments are passed to log.New.
+func NewSlowQueryLogger(w io.Writer, prefix string, flag int, config SlowQueryConfig) *SlowQueryLogger {
+	if config.Threshold == 0 {
+		config.Threshold = 100 * time.Millisecond
+	}
+	if config.ExplainTimeout == 0 {
+		config.ExplainTimeout = 5 * time.Second
+	}
+	if config.SampleRate == 0 {
+		config.SampleRate = 1
+	}
+	if config.RateInterval == 0 {
+		config.RateInterval = time.Minute
+	}
+	return &SlowQueryLogger{
+		logger: log.New(w, prefix, flag),
+		config: config,
+	}
+}
+
+// LogSettings implements the Logger interface.
+func (l *SlowQueryLogger) LogSettings(ctx context.Context, settings *LogSettings) {
+	settings.LogAsynchronously = l.config.LogAsynchronously
+	settings.IncludeTime = true
+	settings.IncludeCaller = true
+}
+
+// LogQuery implements the Logger interface. Plans are not captured, since
+// the Logger does not know the DB that ran the query; use LogSlowQueries
+// for that.
+func (l *SlowQueryLogger) LogQuery(ctx context.Context, queryStats QueryStats) {
+	l.logQuery(ctx, nil, queryStats)
+}
+
+// logQuery logs queryStats if the query is slow, capturing its plan on db if
+// db is not nil.
+func (l *SlowQueryLogger) logQuery(ctx context.Context, db DB, queryStats QueryStats) {
+	if queryStats.TimeTaken < l.config.Threshold {
+		return
+	}
+	if l.config.SampleRate < 1 && rand.Float64() >= l.config.SampleRate {
+		return
+	}
+	suppressed, ok := l.allow(time.Now())
+	if !ok {
+		return
+	}
+
+	var reset, yellow, blue, purple string
+	envNoColor, _ := strconv.ParseBool(os.Getenv("NO_COLOR"))
+	if !l.config.NoColor && !envNoColor {
+		reset, yellow, blue, purple = colorReset, colorYellow, colorBlue, colorPurple
+	}
+	buf := bufPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer bufPool.Put(buf)
+	buf.WriteString(yellow + "[SLOW]" + reset + " " + queryStats.Query + ";")
+	if queryStats.Err != nil {
+		buf.WriteString(blue + " err" + reset + "={" + queryStats.Err.Error() + "}")
+	}
+	buf.WriteString(blue + " timeTaken" + reset + "=" + queryStats.TimeTaken.String())
+	if queryStats.RowCount.Valid {
+		buf.WriteString(blue + " rowCount" + reset + "=" + strconv.FormatInt(queryStats.RowCount.Int64, 10))
+	}
+	if queryStats.RowsAffected.Valid {
+		buf.WriteString(blue + " rowsAffected" + reset + "=" + strconv.FormatInt(queryStats.RowsAffected.Int64, 10))
+	}
+	if queryStats.CallerFile != "" {
+		buf.WriteString(blue + " caller" + reset + "=" + queryStats.CallerFile + ":" + strconv.Itoa(queryStats.CallerLine) + ":" + filepath.Base(queryStats.CallerFunction))
+	}
+	if suppressed > 0 {
+		buf.WriteString(blue + " suppressed" + reset + "=" + strconv.Itoa(suppressed))
+	}
+	// Failed queries are not explained, the plan would likely fail too.
+	if l.config.Explain && db != nil && queryStats.Err == nil {
+		explainCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), l.config.ExplainTimeout)
+		// The args of queryStats may be redacted, the plan needs the
+		// original ones.
+		plan, err := explainQuery(explainCtx, db, queryStats.Dialect, queryStats.Query, unredactedArgs(ctx, queryStats))
+		cancel()
+		buf.WriteString("\n" + purple + "----[ Query plan ]----" + reset + "\n")
+		if err != nil {
+			buf.WriteString("%!(error=" + err.Error() + ")")
+		} else {
+			buf.WriteString(plan)
+		}
+	}
+	l.logger.Println(buf.String())
+}
+
+// allow reports whether a slow query seen at now may be logged under the
+// rate limit, and how many slow queries were suppressed since the last one
+// that was.
+func (l *SlowQueryLogger) allow(now time.Time) (suppressed int, ok bool) {
+	if l.config.RateLimit <= 0 {
+		return 0, true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.windowStart) >= l.config.RateInterval {
+		l.windowStart = now
+		l.windowCount = 0
+	}
+	if l.windowCount >= l.config.RateLimit {
+		l.suppressed++
+		return 0, false
+	}
+	l.windowCount++
+	suppressed, l.suppressed = l.suppressed, 0
+	return suppressed, true
+}
+
+// LogSlowQueries wraps a DB so that its slow queries are logged with logger,
+// with their plan captured on db if logger is configured to Explain them. If
+// db has a Logger, every query is still logged with it. Like Chain.Then,
+// LogSlowQueries keeps the optional interfaces of db.
+func LogSlowQueries(db DB, logger *SlowQueryLogger) DB {
+	return NewChain(func(db sq.DB) sq.DB {
+		sdb := slowQueryDB{DB: db, slow: logger}
+		sdb.logger, _ = dbLogger(db)
+		return sdb
+	}).Then(db)
+}
+
+type slowQueryDB struct {
+	DB
+	slow   *SlowQueryLogger
+	logger Logger
+}
+
+var _ Logger = slowQueryDB{}
+
+// Unwrap returns the underlying DB.
+func (db slowQueryDB) Unwrap() DB { return db.DB }
+
+// LogSettings implements the Logger interface.
+func (db slowQueryDB) LogSettings(ctx context.Context, settings *LogSettings) {
+	if db.logger != nil {
+		db.logger.LogSettings(ctx, settings)
+	}
+	async := settings.LogAsynchronously
+	db.slow.LogSettings(ctx, settings)
+	settings.LogAsynchronously = settings.LogAsynchronously || async
+}
+
+// LogQuery implements the Logger interface.
+func (db slowQueryDB) LogQuery(ctx context.Context, queryStats QueryStats) {
+	if db.logger != nil {
+		db.logger.LogQuery(ctx, queryStats)
+	}
+	db.slow.logQuery(ctx, db.DB, queryStats)
+}
@@ -0,0 +1,104 @@
+package sq
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blink-io/sq/internal/testutil"
+)
+
+func TestSlowQueryLogger(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("explain", func(t *testing.T) {
+		buf := &strings.Builder{}
+		logger := NewSlowQueryLogger(buf, "", 0, SlowQueryConfig{
+			Threshold: time.Nanosecond,
+			Explain:   true,
+			NoColor:   true,
+		})
+		db := LogSlowQueries(newActorDB(t), logger)
+		_, err := FetchAllContext(ctx, db, SQLite.From(ACTOR).Where(ACTOR.LAST_NAME.EqString("CHASE")), actorRowMapper)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if len(lines) != 4 {
+			t.Fatalf(testutil.Callers()+" expected 4 lines, got %q", buf.String())
+		}
+		if !strings.HasPrefix(lines[0], "[SLOW] SELECT actor.actor_id") || !strings.Contains(lines[0], "rowCount=1") || !strings.Contains(lines[0], "caller=") {
+			t.Errorf(testutil.Callers()+" unexpected entry %q", lines[0])
+		}
+		if diff := testutil.Diff(lines[1:], []string{"----[ Query plan ]----", "QUERY PLAN", "|--SCAN actor"}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("threshold", func(t *testing.T) {
+		buf := &strings.Builder{}
+		logger := NewSlowQueryLogger(buf, "", 0, SlowQueryConfig{Threshold: time.Hour})
+		db := LogSlowQueries(newActorDB(t), logger)
+		_, err := FetchAllContext(ctx, db, SQLite.From(ACTOR), actorRowMapper)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if buf.Len() != 0 {
+			t.Errorf(testutil.Callers()+" expected nothing to be logged, got %q", buf.String())
+		}
+	})
+
+	t.Run("sampling and rate limit", func(t *testing.T) {
+		buf := &strings.Builder{}
+		logger := NewSlowQueryLogger(buf, "", 0, SlowQueryConfig{
+			Threshold:  time.Nanosecond,
+			SampleRate: 1e-12,
+		})
+		db := LogSlowQueries(newActorDB(t), logger)
+		_, err := FetchAllContext(ctx, db, SQLite.From(ACTOR), actorRowMapper)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if buf.Len() != 0 {
+			t.Errorf(testutil.Callers()+" expected nothing to be logged, got %q", buf.String())
+		}
+
+		logger = NewSlowQueryLogger(buf, "", 0, SlowQueryConfig{RateLimit: 2, RateInterval: time.Second})
+		start := time.Now()
+		var allowed []bool
+		var suppressed []int
+		for _, offset := range []time.Duration{0, 1, 2, 3, time.Second, time.Second + 1} {
+			n, ok := logger.allow(start.Add(offset))
+			allowed = append(allowed, ok)
+			suppressed = append(suppressed, n)
+		}
+		if diff := testutil.Diff(allowed, []bool{true, true, false, false, true, true}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		if diff := testutil.Diff(suppressed, []int{0, 0, 0, 0, 2, 0}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("logger", func(t *testing.T) {
+		var logged int
+		buf := &strings.Builder{}
+		logger := NewSlowQueryLogger(buf, "", 0, SlowQueryConfig{Threshold: time.Nanosecond, Explain: true, NoColor: true})
+		db := LogSlowQueries(struct {
+			DB
+			Logger
+		}{newActorDB(t), &loggerStruct{logQuery: func(context.Context, QueryStats) { logged++ }}}, logger)
+		_, err := ExecContext(ctx, db, SQLite.Queryf("DELETE FROM nonexistent"))
+		if err == nil {
+			t.Fatal(testutil.Callers(), "expected an error")
+		}
+		if logged != 1 {
+			t.Errorf(testutil.Callers()+" expected 1 log, got %d", logged)
+		}
+		// Failed queries are logged but not explained.
+		if !strings.Contains(buf.String(), "err={") || strings.Contains(buf.String(), "Query plan") {
+			t.Errorf(testutil.Callers()+" unexpected entry %q", buf.String())
+		}
+	})
+}
@@ -26,6 +26,8 @@ const (
 	DialectPostgres  = "postgres"
 	DialectMySQL     = "mysql"
 	DialectSQLServer = "sqlserver"
+	DialectDuckDB    = "duckdb"
+	DialectOracle    = "oracle"
 )
 
 // SQLWriter is anything that can be converted to SQL.
@@ -277,6 +279,33 @@ func writeFieldsWithPrefix(ctx context.Context, dialect string, buf *bytes.Buffe
 	return nil
 }
 
+// writeReturningInto writes the fields of an Oracle RETURNING clause followed
+// by the INTO clause, binding every destination as an sql.Out.
+func writeReturningInto(ctx context.Context, dialect string, buf *bytes.Buffer, args *[]any, params map[string][]int, fields []Field, dest []any) error {
+	if len(dest) != len(fields) {
+		return fmt.Errorf("%s RETURNING has %d fields but %d INTO destinations", dialect, len(fields), len(dest))
+	}
+	err := writeFields(ctx, dialect, buf, args, params, fields, false)
+	if err != nil {
+		return err
+	}
+	buf.WriteString(" INTO ")
+	for i, d := range dest {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		out, ok := d.(sql.Out)
+		if !ok {
+			out = sql.Out{Dest: d}
+		}
+		err = WriteValue(ctx, dialect, buf, args, params, out)
+		if err != nil {
+			return fmt.Errorf("INTO #%d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
 func writeFields(ctx context.Context, dialect string, buf *bytes.Buffer, args *[]any, params map[string][]int, fields []Field, includeAlias bool) error {
 	var err error
 	var alias string
@@ -370,6 +399,16 @@ func (v *arrayValue) DialectValuer(dialect string) (driver.Valuer, error) {
 	return v, nil
 }
 
+// arrayCast returns the cast that must follow the placeholder of an
+// ArrayValue. DuckDB does not unquote strings when casting a VARCHAR to a
+// LIST, but it is able to cast a JSON array into any LIST type.
+func arrayCast(dialect string, value any) string {
+	if _, ok := value.(*arrayValue); ok && dialect == DialectDuckDB {
+		return "::JSON"
+	}
+	return ""
+}
+
 // EnumValue takes in an Enumeration and returns a driver.Valuer which
 // serializes the enum into a string and additionally checks if the enum is
 // valid.
@@ -502,7 +541,7 @@ func (v *uuidValue) Value() (driver.Value, error) {
 			uuid[i] = value.Index(i).Interface().(byte)
 		}
 	}
-	if v.dialect != DialectPostgres {
+	if v.dialect != DialectPostgres && v.dialect != DialectDuckDB {
 		return uuid[:], nil
 	}
 	var buf [36]byte
@@ -0,0 +1,205 @@
+package sq
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// StmtCacheStats are the statistics of a StmtCacheDB.
+type StmtCacheStats struct {
+	// Hits is the number of queries that reused a cached statement.
+	Hits int64
+
+	// Misses is the number of queries that had to prepare a statement.
+	Misses int64
+
+	// Evictions is the number of statements evicted from the cache.
+	Evictions int64
+
+	// Size is the number of statements currently in the cache.
+	Size int
+}
+
+// StmtCacheDB is a DB that transparently prepares the queries run through it
+// and keeps the most recently used prepared statements in an LRU cache keyed
+// by query string. It is created with StmtCache.
+//
+// Transactions started from a StmtCacheDB do not use the cache.
+type StmtCacheDB struct {
+	db   DB
+	size int
+
+	mu      sync.Mutex
+	lru     *list.List // of *stmtCacheEntry, most recently used first
+	entries map[string]*list.Element
+	stats   StmtCacheStats
+}
+
+type stmtCacheEntry struct {
+	query   string
+	stmt    *sql.Stmt
+	refs    int  // number of queries currently executing the statement
+	evicted bool // if true, the statement is closed once refs drops to 0
+}
+
+var _ interface {
+	DB
+	Txer
+} = (*StmtCacheDB)(nil)
+
+// StmtCache wraps a DB so that every query run through it is prepared once
+// and the prepared statement reused afterwards. At most size statements are
+// kept (100 if size <= 0); the least recently used statement is evicted and
+// closed once no query is using it any more.
+//
+// If the DB is a Logger, wrap the StmtCacheDB with Log again to keep
+// logging.
+func StmtCache(db DB, size int) *StmtCacheDB {
+	if size <= 0 {
+		size = 100
+	}
+	return &StmtCacheDB{
+		db:      db,
+		size:    size,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// acquire returns the cached statement for query, preparing it if necessary.
+// The returned entry must be released after use.
+func (c *StmtCacheDB) acquire(ctx context.Context, query string) (*stmtCacheEntry, error) {
+	c.mu.Lock()
+	if element, ok := c.entries[query]; ok {
+		entry := element.Value.(*stmtCacheEntry)
+		entry.refs++
+		c.lru.MoveToFront(element)
+		c.stats.Hits++
+		c.mu.Unlock()
+		return entry, nil
+	}
+	c.stats.Misses++
+	c.mu.Unlock()
+
+	// Prepare outside the lock so that a slow prepare does not block other
+	// queries.
+	stmt, err := c.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[query]; ok {
+		// Another goroutine prepared the same query in the meantime.
+		_ = stmt.Close()
+		entry := element.Value.(*stmtCacheEntry)
+		entry.refs++
+		c.lru.MoveToFront(element)
+		return entry, nil
+	}
+	entry := &stmtCacheEntry{query: query, stmt: stmt, refs: 1}
+	c.entries[query] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.size {
+		c.evict(c.lru.Back())
+	}
+	return entry, nil
+}
+
+// evict removes element from the cache. The caller must hold c.mu.
+func (c *StmtCacheDB) evict(element *list.Element) {
+	entry := c.lru.Remove(element).(*stmtCacheEntry)
+	delete(c.entries, entry.query)
+	entry.evicted = true
+	c.stats.Evictions++
+	if entry.refs == 0 {
+		_ = entry.stmt.Close()
+	}
+}
+
+func (c *StmtCacheDB) release(entry *stmtCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry.refs--
+	if entry.evicted && entry.refs == 0 {
+		_ = entry.stmt.Close()
+	}
+}
+
+// QueryContext implements the DB interface.
+func (c *StmtCacheDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	entry, err := c.acquire(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	// The rows keep the statement open until they are closed, even if it is
+	// evicted and closed in the meantime.
+	defer c.release(entry)
+	return entry.stmt.QueryContext(ctx, args...)
+}
+
+// ExecContext implements the DB interface.
+func (c *StmtCacheDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	entry, err := c.acquire(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer c.release(entry)
+	return entry.stmt.ExecContext(ctx, args...)
+}
+
+// PrepareContext implements the DB interface. The statement is prepared on
+// the underlying DB and is not cached, since the caller is responsible for
+// closing it.
+func (c *StmtCacheDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return c.db.PrepareContext(ctx, query)
+}
+
+// Begin implements the Txer interface.
+func (c *StmtCacheDB) Begin() (*sql.Tx, error) {
+	return c.BeginTx(context.Background(), nil)
+}
+
+// BeginTx implements the Txer interface.
+func (c *StmtCacheDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	txer, ok := c.db.(Txer)
+	if !ok {
+		return nil, fmt.Errorf("sq: %T cannot begin transactions", c.db)
+	}
+	return txer.BeginTx(ctx, opts)
+}
+
+// GetDialect returns the dialect of the underlying DB.
+func (c *StmtCacheDB) GetDialect() string { return dbDialect(c.db) }
+
+// Stats returns the cache statistics.
+func (c *StmtCacheDB) Stats() StmtCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Size = c.lru.Len()
+	return stats
+}
+
+// Close evicts and closes every cached statement. It does not close the
+// underlying DB. The StmtCacheDB remains usable afterwards.
+func (c *StmtCacheDB) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var errs []error
+	for element := c.lru.Front(); element != nil; element = c.lru.Front() {
+		entry := c.lru.Remove(element).(*stmtCacheEntry)
+		delete(c.entries, entry.query)
+		entry.evicted = true
+		if entry.refs == 0 {
+			if err := entry.stmt.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
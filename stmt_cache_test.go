@@ -0,0 +1,130 @@
+package sq
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/blink-io/sq/internal/testutil"
+)
+
+func TestStmtCache(t *testing.T) {
+	newCache := func(t *testing.T, size int) (*sql.DB, *StmtCacheDB) {
+		sqlDB := newActorDB(t)
+		db := StmtCache(sqlDB, size)
+		t.Cleanup(func() { db.Close() })
+		return sqlDB, db
+	}
+	insertActor := func(firstName string) Query {
+		return SQLite.InsertInto(ACTOR).Columns(ACTOR.FIRST_NAME, ACTOR.LAST_NAME).Values(firstName, "GUINESS")
+	}
+
+	t.Run("hits and misses", func(t *testing.T) {
+		_, db := newCache(t, 10)
+		for _, firstName := range []string{"PENELOPE", "NICK", "ED"} {
+			_, err := Exec(db, insertActor(firstName))
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+		}
+		for i := 0; i < 2; i++ {
+			actors, err := FetchAll(db, SQLite.From(ACTOR).OrderBy(ACTOR.ACTOR_ID), actorRowMapper)
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			if len(actors) != 6 {
+				t.Fatalf(testutil.Callers()+" expected 6 actors, got %d", len(actors))
+			}
+		}
+		if diff := testutil.Diff(db.Stats(), StmtCacheStats{Hits: 3, Misses: 2, Size: 2}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		if dialect := dbDialect(db); dialect != DialectSQLite {
+			t.Errorf(testutil.Callers()+" expected %q, got %q", DialectSQLite, dialect)
+		}
+	})
+
+	t.Run("eviction", func(t *testing.T) {
+		_, db := newCache(t, 2)
+		queries := []string{
+			"SELECT 1",
+			"SELECT 2",
+			"SELECT 1",
+			"SELECT 3", // evicts SELECT 2
+			"SELECT 2", // evicts SELECT 1
+		}
+		for _, query := range queries {
+			rows, err := db.QueryContext(context.Background(), query)
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			rows.Close()
+		}
+		if diff := testutil.Diff(db.Stats(), StmtCacheStats{Hits: 1, Misses: 4, Evictions: 2, Size: 2}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("evicted while in use", func(t *testing.T) {
+		_, db := newCache(t, 1)
+		rows, err := db.QueryContext(context.Background(), "SELECT first_name FROM actor ORDER BY actor_id")
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		defer rows.Close()
+		// Evict the statement that rows is still reading from.
+		rows2, err := db.QueryContext(context.Background(), "SELECT 1")
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		rows2.Close()
+		var firstNames []string
+		for rows.Next() {
+			var firstName string
+			if err := rows.Scan(&firstName); err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			firstNames = append(firstNames, firstName)
+		}
+		if err := rows.Err(); err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if diff := testutil.Diff(firstNames, []string{"PENELOPE", "NICK", "ED"}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		sqlDB, db := newCache(t, 2)
+		sqlDB.SetMaxOpenConns(4)
+		_, err := Exec(db, insertActor("PENELOPE"))
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		var wg sync.WaitGroup
+		errs := make(chan error, 40)
+		for i := 0; i < 40; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				// Cycle through more queries than the cache holds so that
+				// statements are evicted while other goroutines use them.
+				_, err := FetchAll(db, SQLite.From(ACTOR).Where(Expr(fmt.Sprintf("actor.actor_id > %d", i%4-4))), actorRowMapper)
+				if err != nil {
+					errs <- err
+				}
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			t.Error(testutil.Callers(), err)
+		}
+		stats := db.Stats()
+		if stats.Hits+stats.Misses != 41 {
+			t.Errorf(testutil.Callers()+" expected 41 lookups, got %+v", stats)
+		}
+	})
+}
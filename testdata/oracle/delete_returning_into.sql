@@ -0,0 +1,2 @@
+DELETE FROM actor a WHERE a.actor_id = :1 RETURNING a.first_name INTO :2
+-- args: 1 ''
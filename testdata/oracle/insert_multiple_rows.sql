@@ -0,0 +1,2 @@
+INSERT INTO actor a (actor_id, first_name, last_name) SELECT :1, :2, :3 FROM DUAL UNION ALL SELECT :4, :5, :6 FROM DUAL
+-- args: 1 'bob' 'the builder' 2 'alice' 'in wonderland'
@@ -0,0 +1,2 @@
+INSERT INTO actor a (first_name, last_name) VALUES (:1, :2) RETURNING a.actor_id, a.first_name INTO :3, :4
+-- args: 'bob' 'the builder' 0 ''
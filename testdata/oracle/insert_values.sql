@@ -0,0 +1,2 @@
+INSERT INTO actor a (actor_id, first_name, last_name) VALUES (:1, :2, :3)
+-- args: 1 'bob' 'the builder'
@@ -0,0 +1,3 @@
+SELECT :1, :2, :3, :4, :5 FROM DUAL
+-- args: 1 HEXTORAW('DEADBEEF') TIMESTAMP '2006-01-02 15:04:05' 'it''s' || CHR(10) || 'multiline' NULL
+SELECT 1, HEXTORAW('DEADBEEF'), TIMESTAMP '2006-01-02 15:04:05', 'it''s' || CHR(10) || 'multiline', NULL FROM DUAL
@@ -0,0 +1,2 @@
+MERGE INTO actor USING (SELECT :1 AS actor_id, :2 AS first_name FROM DUAL UNION ALL SELECT :3, :4 FROM DUAL) EXCLUDED ON (actor.actor_id = EXCLUDED.actor_id) WHEN NOT MATCHED THEN INSERT (actor_id, first_name) VALUES (EXCLUDED.actor_id, EXCLUDED.first_name)
+-- args: 1 'bob' 2 'alice'
@@ -0,0 +1,2 @@
+MERGE INTO actor a USING (SELECT :1 AS actor_id, :2 AS first_name, :3 AS last_name FROM DUAL) EXCLUDED ON (a.actor_id = EXCLUDED.actor_id) WHEN MATCHED THEN UPDATE SET first_name = EXCLUDED.first_name, last_name = EXCLUDED.last_name WHERE a.last_name IS NOT NULL WHEN NOT MATCHED THEN INSERT (actor_id, first_name, last_name) VALUES (EXCLUDED.actor_id, EXCLUDED.first_name, EXCLUDED.last_name)
+-- args: 1 'bob' 'the builder'
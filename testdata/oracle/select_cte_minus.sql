@@ -0,0 +1,2 @@
+WITH cte (n) AS (SELECT 1 FROM DUAL UNION ALL SELECT n + 1 FROM cte WHERE n < :1) SELECT n FROM cte WHERE n IN ((SELECT 1 FROM DUAL MINUS SELECT 2 FROM DUAL))
+-- args: 10
@@ -0,0 +1,2 @@
+SELECT a.actor_id, a."level" FROM actor a WHERE a.actor_id IN (:1, :2, :3) ORDER BY a.actor_id FETCH FIRST :4 ROWS ONLY
+-- args: 1 2 3 10
@@ -0,0 +1,2 @@
+SELECT a.first_name, b.last_name FROM actor a JOIN actor b ON a.actor_id = b.actor_id ORDER BY a.first_name OFFSET :1 ROWS FETCH NEXT :2 ROWS ONLY
+-- args: 20 10
@@ -0,0 +1,2 @@
+SELECT a.actor_id FROM actor a ORDER BY a.last_update OFFSET :1 ROWS FETCH NEXT :2 ROWS WITH TIES
+-- args: 5 10
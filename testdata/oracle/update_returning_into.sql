@@ -0,0 +1,2 @@
+UPDATE actor a SET first_name = :1 WHERE a.actor_id = :2 RETURNING a.actor_id INTO :3
+-- args: 'bob' 1 0
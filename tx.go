@@ -3,6 +3,12 @@ package sq
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"math/rand/v2"
+	"reflect"
+	"strconv"
+	"time"
+
 	"github.com/bokwoon95/sq"
 )
 
@@ -27,7 +33,7 @@ type (
 )
 
 func (db txDB) RunInTx(ctx context.Context, opts *sql.TxOptions, fn func(context.Context, sq.DB) error) error {
-	return RunInTx(ctx, db, opts, fn)
+	return RunInTx(ctx, db.TxDB, opts, fn)
 }
 
 func InTx(txdb TxDB) interface {
@@ -37,24 +43,409 @@ func InTx(txdb TxDB) interface {
 	return txDB{TxDB: txdb}
 }
 
-func RunInTx(ctx context.Context, db TxDB, opts *sql.TxOptions, fn func(context.Context, sq.DB) error) error {
-	tx, err := db.BeginTx(ctx, opts)
+// RunInTx runs fn inside a transaction, committing it if fn returns nil and
+// rolling it back otherwise.
+//
+// If ctx already carries a transaction begun on db (because RunInTx is being
+// called from inside another RunInTx on the same DB, or see WithTx) or db is
+// itself a *sql.Tx (or a DB wrapper around one), no new transaction is
+// started. Instead fn runs inside a savepoint of the existing transaction,
+// which is released if fn returns nil and rolled back to otherwise. This lets
+// functions that call RunInTx be composed freely. opts is ignored for
+// savepoints. Savepoints are written in the dialect of db (or the
+// DefaultDialect), and an error is returned if it is unknown. Transactions of
+// other DBs carried by ctx are left alone, and a new transaction is begun on
+// db.
+//
+// If db was wrapped in middleware with Chain.Then (or Hooks or Intercept),
+// the DB passed to fn is wrapped in the same middleware. Hooks are also
+// called for the commit or rollback.
+func RunInTx(ctx context.Context, db DB, opts *sql.TxOptions, fn func(context.Context, sq.DB) error) error {
+	outer, _ := ctx.Value(txContextKey{}).(*txState)
+	if state := outer.find(db); state != nil {
+		return runInSavepoint(ctx, state, fn)
+	}
+	if tx, ok := dbTx(db); ok {
+		return runInSavepoint(ctx, &txState{tx: tx, db: db, origin: tx, dialect: dbDialect(db), outer: outer}, fn)
+	}
+	txer, ok := db.(Txer)
+	if !ok {
+		return fmt.Errorf("sq: %T cannot begin transactions", db)
+	}
+	begin := &txBegin{}
+	tx, err := txer.BeginTx(context.WithValue(ctx, txBeginContextKey{}, begin), opts)
 	if err != nil {
 		return err
 	}
 
+	// If db was wrapped in middleware, so is the transaction. Hooks see its
+	// commit or rollback too, and fn is passed the context returned by their
+	// BeforeQuery methods for the begin.
+	dialect := dbDialect(db)
+	txdb := chainTx(db, tx, dialect)
+	if begin.ctx != nil {
+		ctx = begin.ctx
+	}
+
 	var done bool
 
 	defer func() {
 		if !done {
-			_ = tx.Rollback()
+			_ = begin.end(tx, false)
 		}
 	}()
 
-	if err := fn(ctx, tx); err != nil {
+	ctx = context.WithValue(ctx, txContextKey{}, &txState{tx: tx, db: txdb, origin: rootDB(db), dialect: dialect, outer: outer})
+	if err := fn(ctx, txdb); err != nil {
 		return err
 	}
 
 	done = true
-	return tx.Commit()
+	if err := begin.end(tx, true); err != nil {
+		return err
+	}
+	txCommitted(ctx, txdb)
+	return nil
+}
+
+// txCommitted tells the middleware wrapping txdb (see chainTx) that its
+// transaction was committed.
+func txCommitted(ctx context.Context, txdb DB) {
+	for txdb != nil {
+		if committer, ok := txdb.(interface{ txCommitted(context.Context) }); ok {
+			committer.txCommitted(ctx)
+		}
+		unwrapper, ok := txdb.(interface{ Unwrap() DB })
+		if !ok {
+			break
+		}
+		txdb = unwrapper.Unwrap()
+	}
+}
+
+// dbTx returns the *sql.Tx that db is, or wraps, looking through DB wrappers
+// that expose the DB they wrap with an Unwrap() DB method.
+func dbTx(db DB) (*sql.Tx, bool) {
+	for db != nil {
+		if tx, ok := db.(*sql.Tx); ok {
+			return tx, true
+		}
+		unwrapper, ok := db.(interface{ Unwrap() DB })
+		if !ok {
+			break
+		}
+		db = unwrapper.Unwrap()
+	}
+	return nil, false
+}
+
+// rootDB returns the innermost DB that db wraps, looking through middleware
+// chains and DB wrappers that expose the DB they wrap with an Unwrap() DB
+// method.
+func rootDB(db DB) DB {
+	for db != nil {
+		if cdb, ok := db.(interface{ chainInner() DB }); ok {
+			db = cdb.chainInner()
+			continue
+		}
+		unwrapper, ok := db.(interface{ Unwrap() DB })
+		if !ok {
+			break
+		}
+		db = unwrapper.Unwrap()
+	}
+	return db
+}
+
+// sameDB reports whether a and b are the same DB. DBs whose type is not
+// comparable are never the same.
+func sameDB(a, b DB) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	typ := reflect.TypeOf(a)
+	if typ != reflect.TypeOf(b) || !typ.Comparable() {
+		return false
+	}
+	return a == b
+}
+
+// txContextKey is the context key under which the *txState of the
+// transaction that RunInTx is currently running in is stored.
+type txContextKey struct{}
+
+type txState struct {
+	tx      *sql.Tx
+	db      sq.DB // the DB passed to fn, which runs its queries on tx
+	origin  sq.DB // the innermost DB that tx was begun on (see rootDB)
+	dialect string
+	depth   int      // number of enclosing savepoints
+	outer   *txState // the state that was in the context before this one
+}
+
+// find returns the innermost state in the chain starting at state whose
+// transaction was begun on db, or is db. It returns nil if there is none.
+func (state *txState) find(db DB) *txState {
+	root := rootDB(db)
+	for ; state != nil; state = state.outer {
+		if tx, ok := root.(*sql.Tx); ok {
+			if tx == state.tx {
+				return state
+			}
+		} else if sameDB(root, state.origin) {
+			return state
+		}
+	}
+	return nil
+}
+
+func runInSavepoint(ctx context.Context, state *txState, fn func(context.Context, sq.DB) error) (err error) {
+	dialect := state.dialect
+	if dialect == "" {
+		if defaultDialect := DefaultDialect.Load(); defaultDialect != nil {
+			dialect = *defaultDialect
+		}
+	}
+	if dialect == "" {
+		return fmt.Errorf("sq: cannot set a savepoint on a transaction of unknown dialect, use WithDialect to set it")
+	}
+	savepoint := "sq_savepoint_" + strconv.Itoa(state.depth+1)
+	setStmt, rollbackStmt, releaseStmt := savepointStatements(dialect, savepoint)
+	_, err = state.tx.ExecContext(ctx, setStmt)
+	if err != nil {
+		return err
+	}
+
+	var done bool
+
+	defer func() {
+		if !done {
+			// Use a context that outlives ctx, so that the savepoint is still
+			// rolled back if ctx was canceled.
+			_, _ = state.tx.ExecContext(context.WithoutCancel(ctx), rollbackStmt)
+			if releaseStmt != "" {
+				_, _ = state.tx.ExecContext(context.WithoutCancel(ctx), releaseStmt)
+			}
+		}
+	}()
+
+	// The savepoint is run on the same (possibly wrapped) DB as its
+	// transaction.
+	var txdb sq.DB = state.tx
+	if state.db != nil {
+		txdb = state.db
+	}
+	outer, _ := ctx.Value(txContextKey{}).(*txState)
+	ctx = context.WithValue(ctx, txContextKey{}, &txState{tx: state.tx, db: state.db, origin: state.origin, dialect: state.dialect, depth: state.depth + 1, outer: outer})
+	if err := fn(ctx, txdb); err != nil {
+		return err
+	}
+
+	done = true
+	if releaseStmt != "" {
+		_, err = state.tx.ExecContext(ctx, releaseStmt)
+	}
+	return err
+}
+
+// WithTx returns a copy of ctx that carries tx, which must have been begun on
+// db. DBs wrapped with ContextDB around db run their queries on tx when given
+// the returned context, and RunInTx calls on db given the returned context run
+// in a savepoint of tx instead of starting a new transaction. The savepoints
+// are written in the dialect of db.
+//
+// RunInTx already puts its transaction into the context it passes to fn, so
+// WithTx is only needed for transactions started elsewhere.
+func WithTx(ctx context.Context, db DB, tx *sql.Tx) context.Context {
+	outer, _ := ctx.Value(txContextKey{}).(*txState)
+	if outer != nil && outer.tx == tx {
+		return ctx
+	}
+	dialect := dbDialect(db)
+	return context.WithValue(ctx, txContextKey{}, &txState{tx: tx, db: chainTx(db, tx, dialect), origin: rootDB(db), dialect: dialect, outer: outer})
+}
+
+// TxFromContext returns the transaction carried by ctx, if any.
+func TxFromContext(ctx context.Context) (*sql.Tx, bool) {
+	state, ok := ctx.Value(txContextKey{}).(*txState)
+	if !ok {
+		return nil, false
+	}
+	return state.tx, true
+}
+
+// ContextDB wraps a DB so that every query run with a context carrying a
+// transaction begun on the DB (see WithTx and RunInTx) is run on that
+// transaction instead of the DB. Transactions of other DBs are ignored. This
+// lets code that takes a DB, such as an Executor, participate in a
+// surrounding transaction without the transaction being threaded through
+// every call. Like other middleware, the returned DB keeps the optional
+// interfaces of db (see Chain.Then).
+func ContextDB(db DB) DB {
+	return NewChain(func(db sq.DB) sq.DB {
+		return ctxDB{DB: db}
+	}).Then(db)
+}
+
+type ctxDB struct {
+	DB
+}
+
+// resolve returns the DB that a query run with ctx should be run on: the
+// transaction in ctx that was begun on db.DB (wrapped in the same middleware
+// as the DB that RunInTx passes to fn), or db.DB itself.
+func (db ctxDB) resolve(ctx context.Context) DB {
+	if _, ok := rootDB(db.DB).(*sql.Tx); ok {
+		// Already runs on a transaction, e.g. the ContextDB was used as
+		// middleware that RunInTx wrapped its transaction in.
+		return db.DB
+	}
+	state, _ := ctx.Value(txContextKey{}).(*txState)
+	if state = state.find(db.DB); state != nil {
+		return state.db
+	}
+	return db.DB
+}
+
+// Unwrap returns the underlying DB.
+func (db ctxDB) Unwrap() DB { return db.DB }
+
+// QueryContext implements the DB interface.
+func (db ctxDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return db.resolve(ctx).QueryContext(ctx, query, args...)
+}
+
+// ExecContext implements the DB interface.
+func (db ctxDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return db.resolve(ctx).ExecContext(ctx, query, args...)
+}
+
+// PrepareContext implements the DB interface.
+func (db ctxDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return db.resolve(ctx).PrepareContext(ctx, query)
+}
+
+// savepointStatements returns the statements that set, roll back to and
+// release a savepoint. releaseStmt is empty for dialects that have no way of
+// releasing a savepoint.
+func savepointStatements(dialect, savepoint string) (setStmt, rollbackStmt, releaseStmt string) {
+	switch dialect {
+	case DialectSQLServer:
+		return "SAVE TRANSACTION " + savepoint, "ROLLBACK TRANSACTION " + savepoint, ""
+	case DialectOracle:
+		return "SAVEPOINT " + savepoint, "ROLLBACK TO SAVEPOINT " + savepoint, ""
+	}
+	return "SAVEPOINT " + savepoint, "ROLLBACK TO SAVEPOINT " + savepoint, "RELEASE SAVEPOINT " + savepoint
+}
+
+// TxRetryPolicy controls how RunInTxRetry retries a transaction. The zero
+// value is a usable policy.
+type TxRetryPolicy struct {
+	// MaxAttempts is the maximum number of times the transaction is run,
+	// including the first attempt. Defaults to 3.
+	MaxAttempts int
+
+	// InitialBackoff is the delay before the first retry. Defaults to 10ms.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the delay between retries. Defaults to 1s.
+	MaxBackoff time.Duration
+
+	// Multiplier is the factor the delay grows by after every retry.
+	// Defaults to 2.
+	Multiplier float64
+
+	// Jitter is the fraction (between 0 and 1) of each delay that is
+	// randomized, so that conflicting transactions do not retry in lockstep.
+	// A Jitter of 0.5 means each delay is picked from [delay/2, delay].
+	Jitter float64
+
+	// ShouldRetry reports whether the transaction should be retried after
+	// failing with err. Defaults to IsRetryableTxError.
+	ShouldRetry func(err error) bool
+
+	// OnAttempt, if non-nil, is called before every attempt. Attempts are
+	// numbered from 1.
+	OnAttempt func(ctx context.Context, attempt int)
+
+	// OnRetry, if non-nil, is called after an attempt fails with a
+	// retryable error, before waiting delay for the next attempt.
+	OnRetry func(ctx context.Context, attempt int, err error, delay time.Duration)
+}
+
+// IsRetryableTxError reports whether a transaction that failed with err is
+// worth retrying from the start i.e. the error is a serialization failure, a
+// deadlock or a lock timeout (which includes SQLITE_BUSY).
+func IsRetryableTxError(err error) bool {
+	switch ErrorKindOf(err) {
+	case SerializationFailure, Deadlock, LockTimeout:
+		return true
+	}
+	return false
+}
+
+// RunInTxRetry is like RunInTx, but if the transaction fails with an error
+// that policy.ShouldRetry accepts the whole transaction is rolled back and fn
+// is run again in a new transaction after a backoff delay, up to
+// policy.MaxAttempts times. fn must therefore be safe to run more than once.
+//
+// If ctx is canceled while waiting to retry, the context's error is returned.
+// Otherwise the error of the last attempt is returned.
+//
+// When called inside an existing transaction of db (ctx carries one, or db is
+// or wraps a *sql.Tx) fn is run only once and is not retried, since a
+// serialization failure or deadlock aborts the enclosing transaction as well;
+// the outermost RunInTxRetry is the one that retries. Transactions of other
+// DBs carried by ctx do not prevent retries, since RunInTx begins a new
+// transaction on db.
+func RunInTxRetry(ctx context.Context, db DB, opts *sql.TxOptions, policy TxRetryPolicy, fn func(context.Context, sq.DB) error) error {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = 3
+	}
+	outer, _ := ctx.Value(txContextKey{}).(*txState)
+	if outer.find(db) != nil {
+		policy.MaxAttempts = 1
+	} else if _, ok := dbTx(db); ok {
+		policy.MaxAttempts = 1
+	}
+	if policy.InitialBackoff <= 0 {
+		policy.InitialBackoff = 10 * time.Millisecond
+	}
+	if policy.MaxBackoff <= 0 {
+		policy.MaxBackoff = time.Second
+	}
+	if policy.Multiplier < 1 {
+		policy.Multiplier = 2
+	}
+	if policy.ShouldRetry == nil {
+		policy.ShouldRetry = IsRetryableTxError
+	}
+	backoff := policy.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if policy.OnAttempt != nil {
+			policy.OnAttempt(ctx, attempt)
+		}
+		err := RunInTx(ctx, db, opts, fn)
+		if err == nil || attempt >= policy.MaxAttempts || !policy.ShouldRetry(err) {
+			return err
+		}
+		delay := backoff
+		if policy.Jitter > 0 {
+			delay -= time.Duration(rand.Float64() * min(policy.Jitter, 1) * float64(delay))
+		}
+		if policy.OnRetry != nil {
+			policy.OnRetry(ctx, attempt, err, delay)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		backoff = min(time.Duration(float64(backoff)*policy.Multiplier), policy.MaxBackoff)
+	}
 }
@@ -0,0 +1,583 @@
+package sq
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/blink-io/sq/internal/testutil"
+	"github.com/bokwoon95/sq"
+	"github.com/mattn/go-sqlite3"
+)
+
+func TestRunInTxRetry(t *testing.T) {
+	errBusy := sqlite3.Error{Code: sqlite3.ErrBusy, ExtendedCode: sqlite3.ErrNoExtended(sqlite3.ErrBusy)}
+	ctx := context.Background()
+
+	t.Run("retries until success", func(t *testing.T) {
+		db := newDB(t)
+		db.SetMaxOpenConns(1)
+		var attempts []int
+		var delays []time.Duration
+		policy := TxRetryPolicy{
+			MaxAttempts:    5,
+			InitialBackoff: time.Millisecond,
+			OnAttempt: func(ctx context.Context, attempt int) {
+				attempts = append(attempts, attempt)
+			},
+			OnRetry: func(ctx context.Context, attempt int, err error, delay time.Duration) {
+				if !errors.Is(err, errBusy) {
+					t.Errorf(testutil.Callers()+" unexpected error %v", err)
+				}
+				delays = append(delays, delay)
+			},
+		}
+		err := RunInTxRetry(ctx, db, nil, policy, func(ctx context.Context, tx sq.DB) error {
+			_, err := tx.ExecContext(ctx, "INSERT INTO actor (first_name, last_name) VALUES ('PENELOPE', 'GUINESS')")
+			if err != nil {
+				return err
+			}
+			if len(attempts) < 3 {
+				return errBusy
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if diff := testutil.Diff(attempts, []int{1, 2, 3}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		if diff := testutil.Diff(delays, []time.Duration{time.Millisecond, 2 * time.Millisecond}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		// Failed attempts must have been rolled back.
+		var count int
+		err = db.QueryRow("SELECT COUNT(*) FROM actor").Scan(&count)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if count != 1 {
+			t.Errorf(testutil.Callers()+" expected 1 actor, got %d", count)
+		}
+	})
+
+	t.Run("max attempts", func(t *testing.T) {
+		db := newDB(t)
+		var attempts int
+		err := RunInTxRetry(ctx, db, nil, TxRetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}, func(ctx context.Context, tx sq.DB) error {
+			attempts++
+			return errBusy
+		})
+		if !IsLockTimeout(err) {
+			t.Errorf(testutil.Callers()+" expected lock timeout, got %v", err)
+		}
+		if attempts != 2 {
+			t.Errorf(testutil.Callers()+" expected 2 attempts, got %d", attempts)
+		}
+	})
+
+	t.Run("non-retryable error", func(t *testing.T) {
+		db := newDB(t)
+		var attempts int
+		err := RunInTxRetry(ctx, db, nil, TxRetryPolicy{}, func(ctx context.Context, tx sq.DB) error {
+			attempts++
+			_, err := tx.ExecContext(ctx, "INSERT INTO actor (first_name) VALUES (NULL)")
+			return err
+		})
+		if !IsNotNullViolation(err) {
+			t.Errorf(testutil.Callers()+" expected not null violation, got %v", err)
+		}
+		if attempts != 1 {
+			t.Errorf(testutil.Callers()+" expected 1 attempt, got %d", attempts)
+		}
+	})
+
+	t.Run("jitter", func(t *testing.T) {
+		db := newDB(t)
+		policy := TxRetryPolicy{
+			MaxAttempts:    10,
+			InitialBackoff: time.Millisecond,
+			MaxBackoff:     4 * time.Millisecond,
+			Jitter:         0.5,
+			OnRetry: func(ctx context.Context, attempt int, err error, delay time.Duration) {
+				backoff := min(time.Millisecond<<(attempt-1), 4*time.Millisecond)
+				if delay < backoff/2 || delay > backoff {
+					t.Errorf(testutil.Callers()+" attempt %d: delay %s outside [%s, %s]", attempt, delay, backoff/2, backoff)
+				}
+			},
+		}
+		_ = RunInTxRetry(ctx, db, nil, policy, func(ctx context.Context, tx sq.DB) error {
+			return errBusy
+		})
+	})
+
+	t.Run("transaction of another DB", func(t *testing.T) {
+		db, other := newDB(t), newDB(t)
+		var attempts int
+		err := RunInTx(ctx, other, nil, func(ctx context.Context, _ sq.DB) error {
+			return RunInTxRetry(ctx, db, nil, TxRetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}, func(ctx context.Context, tx sq.DB) error {
+				attempts++
+				if attempts < 3 {
+					return errBusy
+				}
+				return nil
+			})
+		})
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if attempts != 3 {
+			t.Errorf(testutil.Callers()+" expected 3 attempts, got %d", attempts)
+		}
+	})
+
+	t.Run("context canceled", func(t *testing.T) {
+		db := newDB(t)
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		var attempts int
+		policy := TxRetryPolicy{
+			MaxAttempts:    5,
+			InitialBackoff: time.Hour,
+			OnRetry: func(ctx context.Context, attempt int, err error, delay time.Duration) {
+				cancel()
+			},
+		}
+		err := RunInTxRetry(ctx, db, &sql.TxOptions{}, policy, func(ctx context.Context, tx sq.DB) error {
+			attempts++
+			return errBusy
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf(testutil.Callers()+" expected context.Canceled, got %v", err)
+		}
+		if attempts != 1 {
+			t.Errorf(testutil.Callers()+" expected 1 attempt, got %d", attempts)
+		}
+	})
+}
+
+func TestRunInTxSavepoint(t *testing.T) {
+	ctx := context.Background()
+	errRollback := errors.New("rollback")
+	insertActor := func(firstName string) func(context.Context, sq.DB) error {
+		return func(ctx context.Context, tx sq.DB) error {
+			_, err := Exec(tx, SQLite.
+				InsertInto(ACTOR).
+				Columns(ACTOR.FIRST_NAME, ACTOR.LAST_NAME).
+				Values(firstName, "GUINESS"),
+			)
+			return err
+		}
+	}
+	assertActors := func(t *testing.T, db *sql.DB, wantNames []string) {
+		t.Helper()
+		gotNames, err := FetchAll(db, SQLite.From(ACTOR).OrderBy(ACTOR.ACTOR_ID), func(ctx context.Context, row *Row) string {
+			return row.StringField(ACTOR.FIRST_NAME)
+		})
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if diff := testutil.Diff(gotNames, wantNames); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	}
+
+	t.Run("nested commit", func(t *testing.T) {
+		db := newDB(t)
+		db.SetMaxOpenConns(1)
+		err := RunInTx(ctx, db, nil, func(ctx context.Context, tx sq.DB) error {
+			err := insertActor("PENELOPE")(ctx, tx)
+			if err != nil {
+				return err
+			}
+			return RunInTx(ctx, db, nil, func(ctx context.Context, tx sq.DB) error {
+				err := insertActor("NICK")(ctx, tx)
+				if err != nil {
+					return err
+				}
+				return RunInTx(ctx, db, nil, insertActor("ED"))
+			})
+		})
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		assertActors(t, db, []string{"PENELOPE", "NICK", "ED"})
+	})
+
+	t.Run("inner rollback", func(t *testing.T) {
+		db := newDB(t)
+		db.SetMaxOpenConns(1)
+		err := RunInTx(ctx, db, nil, func(ctx context.Context, tx sq.DB) error {
+			err := insertActor("PENELOPE")(ctx, tx)
+			if err != nil {
+				return err
+			}
+			err = RunInTx(ctx, db, nil, func(ctx context.Context, tx sq.DB) error {
+				err := insertActor("NICK")(ctx, tx)
+				if err != nil {
+					return err
+				}
+				return errRollback
+			})
+			if !errors.Is(err, errRollback) {
+				t.Errorf(testutil.Callers()+" expected errRollback, got %v", err)
+			}
+			return insertActor("ED")(ctx, tx)
+		})
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		assertActors(t, db, []string{"PENELOPE", "ED"})
+	})
+
+	t.Run("outer rollback", func(t *testing.T) {
+		db := newDB(t)
+		db.SetMaxOpenConns(1)
+		err := RunInTx(ctx, db, nil, func(ctx context.Context, tx sq.DB) error {
+			err := RunInTx(ctx, db, nil, insertActor("PENELOPE"))
+			if err != nil {
+				return err
+			}
+			return errRollback
+		})
+		if !errors.Is(err, errRollback) {
+			t.Errorf(testutil.Callers()+" expected errRollback, got %v", err)
+		}
+		assertActors(t, db, nil)
+	})
+
+	t.Run("existing *sql.Tx", func(t *testing.T) {
+		db := newDB(t)
+		db.SetMaxOpenConns(1)
+		tx, err := db.Begin()
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		defer tx.Rollback()
+		err = insertActor("PENELOPE")(ctx, tx)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		err = RunInTx(ctx, WithDialect(tx, DialectSQLite), nil, func(ctx context.Context, tx sq.DB) error {
+			err := insertActor("NICK")(ctx, tx)
+			if err != nil {
+				return err
+			}
+			return errRollback
+		})
+		if !errors.Is(err, errRollback) {
+			t.Errorf(testutil.Callers()+" expected errRollback, got %v", err)
+		}
+		err = RunInTx(ctx, WithDialect(tx, DialectSQLite), nil, insertActor("ED"))
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		err = tx.Commit()
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		assertActors(t, db, []string{"PENELOPE", "ED"})
+	})
+
+	t.Run("unknown dialect", func(t *testing.T) {
+		db := newDB(t)
+		tx, err := db.Begin()
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		defer tx.Rollback()
+		err = RunInTx(ctx, tx, nil, insertActor("PENELOPE"))
+		if err == nil {
+			t.Fatal(testutil.Callers(), "expected error")
+		}
+	})
+
+	t.Run("nested on another DB", func(t *testing.T) {
+		db1, db2 := newDB(t), newDB(t)
+		db1.SetMaxOpenConns(1)
+		db2.SetMaxOpenConns(1)
+		err := RunInTx(ctx, db1, nil, func(ctx context.Context, tx sq.DB) error {
+			err := insertActor("PENELOPE")(ctx, tx)
+			if err != nil {
+				return err
+			}
+			// Runs in a new transaction on db2, not a savepoint of db1's.
+			err = RunInTx(ctx, db2, nil, insertActor("NICK"))
+			if err != nil {
+				return err
+			}
+			// Runs in a savepoint of db1's transaction again.
+			err = RunInTx(ctx, db1, nil, insertActor("ED"))
+			if err != nil {
+				return err
+			}
+			return errRollback
+		})
+		if !errors.Is(err, errRollback) {
+			t.Errorf(testutil.Callers()+" expected errRollback, got %v", err)
+		}
+		assertActors(t, db1, nil)
+		assertActors(t, db2, []string{"NICK"})
+	})
+
+	t.Run("nested retry does not retry", func(t *testing.T) {
+		db := newDB(t)
+		db.SetMaxOpenConns(1)
+		var attempts int
+		errBusy := sqlite3.Error{Code: sqlite3.ErrBusy, ExtendedCode: sqlite3.ErrNoExtended(sqlite3.ErrBusy)}
+		err := RunInTx(ctx, db, nil, func(ctx context.Context, tx sq.DB) error {
+			return RunInTxRetry(ctx, db, nil, TxRetryPolicy{MaxAttempts: 5}, func(ctx context.Context, tx sq.DB) error {
+				attempts++
+				return errBusy
+			})
+		})
+		if !IsLockTimeout(err) {
+			t.Errorf(testutil.Callers()+" expected lock timeout, got %v", err)
+		}
+		if attempts != 1 {
+			t.Errorf(testutil.Callers()+" expected 1 attempt, got %d", attempts)
+		}
+	})
+
+	t.Run("retry on wrapped *sql.Tx does not retry", func(t *testing.T) {
+		db := newDB(t)
+		db.SetMaxOpenConns(1)
+		tx, err := db.Begin()
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		defer tx.Rollback()
+		var attempts int
+		errBusy := sqlite3.Error{Code: sqlite3.ErrBusy, ExtendedCode: sqlite3.ErrNoExtended(sqlite3.ErrBusy)}
+		err = RunInTxRetry(ctx, WithDialect(tx, DialectSQLite), nil, TxRetryPolicy{MaxAttempts: 5}, func(ctx context.Context, tx sq.DB) error {
+			attempts++
+			return errBusy
+		})
+		if !IsLockTimeout(err) {
+			t.Errorf(testutil.Callers()+" expected lock timeout, got %v", err)
+		}
+		if attempts != 1 {
+			t.Errorf(testutil.Callers()+" expected 1 attempt, got %d", attempts)
+		}
+	})
+
+	t.Run("cannot begin", func(t *testing.T) {
+		err := RunInTx(ctx, struct{ DB }{newDB(t)}, nil, insertActor("PENELOPE"))
+		if err == nil {
+			t.Fatal(testutil.Callers(), "expected error")
+		}
+	})
+}
+
+func Test_savepointStatements(t *testing.T) {
+	type TT struct {
+		dialect               string
+		wantSet, wantRollback string
+		wantRelease           string
+	}
+	tests := []TT{
+		{DialectSQLite, "SAVEPOINT sp", "ROLLBACK TO SAVEPOINT sp", "RELEASE SAVEPOINT sp"},
+		{DialectPostgres, "SAVEPOINT sp", "ROLLBACK TO SAVEPOINT sp", "RELEASE SAVEPOINT sp"},
+		{DialectMySQL, "SAVEPOINT sp", "ROLLBACK TO SAVEPOINT sp", "RELEASE SAVEPOINT sp"},
+		{DialectSQLServer, "SAVE TRANSACTION sp", "ROLLBACK TRANSACTION sp", ""},
+		{DialectOracle, "SAVEPOINT sp", "ROLLBACK TO SAVEPOINT sp", ""},
+	}
+	for _, tt := range tests {
+		gotSet, gotRollback, gotRelease := savepointStatements(tt.dialect, "sp")
+		if diff := testutil.Diff([]string{gotSet, gotRollback, gotRelease}, []string{tt.wantSet, tt.wantRollback, tt.wantRelease}); diff != "" {
+			t.Error(testutil.Callers(), tt.dialect, diff)
+		}
+	}
+}
+
+type actorTable struct {
+	TableStruct `sq:"actor"`
+	ACTOR_ID    NumberField
+	FIRST_NAME  StringField
+	LAST_NAME   StringField
+	LAST_UPDATE TimeField
+}
+
+func (t actorTable) ColumnMapper(actors ...Actor) ColumnMapper {
+	return func(ctx context.Context, col *Column) {
+		for _, actor := range actors {
+			col.SetString(t.FIRST_NAME, actor.FirstName)
+			col.SetString(t.LAST_NAME, actor.LastName)
+		}
+	}
+}
+
+func (t actorTable) RowMapper(ctx context.Context, row *Row) Actor {
+	return Actor{
+		ActorID:   row.IntField(t.ACTOR_ID),
+		FirstName: row.StringField(t.FIRST_NAME),
+		LastName:  row.StringField(t.LAST_NAME),
+	}
+}
+
+func TestContextDB(t *testing.T) {
+	ctx := context.Background()
+	errRollback := errors.New("rollback")
+	a := New[actorTable]("")
+	actors := NewExecutor[actorTable, Actor, Actor](a)
+
+	t.Run("optional interfaces", func(t *testing.T) {
+		db := ContextDB(struct {
+			*sql.DB
+			Logger
+		}{newActorDB(t), &loggerStruct{}})
+		if _, ok := db.(RunInTxer); !ok {
+			t.Error(testutil.Callers(), "expected a RunInTxer")
+		}
+		if _, ok := db.(io.Closer); !ok {
+			t.Error(testutil.Callers(), "expected an io.Closer")
+		}
+		if _, ok := db.(Logger); !ok {
+			t.Error(testutil.Callers(), "expected a Logger")
+		}
+	})
+
+	t.Run("RunInTx", func(t *testing.T) {
+		sqlDB := newDB(t)
+		sqlDB.SetMaxOpenConns(1)
+		db := ContextDB(sqlDB)
+		err := RunInTx(ctx, db, nil, func(ctx context.Context, tx sq.DB) error {
+			_, err := actors.Insert(ctx, db, Actor{FirstName: "PENELOPE", LastName: "GUINESS"})
+			if err != nil {
+				return err
+			}
+			// With only one connection, this would block forever if it did
+			// not run inside the transaction.
+			actor, err := actors.One(ctx, db, a.FIRST_NAME.EqString("PENELOPE"))
+			if err != nil {
+				return err
+			}
+			if diff := testutil.Diff(actor, Actor{ActorID: 1, FirstName: "PENELOPE", LastName: "GUINESS"}); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+			return errRollback
+		})
+		if !errors.Is(err, errRollback) {
+			t.Fatalf(testutil.Callers()+" expected errRollback, got %v", err)
+		}
+		gotActors, err := actors.All(ctx, db, a.ACTOR_ID.IsNotNull())
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if len(gotActors) != 0 {
+			t.Errorf(testutil.Callers()+" insert was not rolled back: %v", gotActors)
+		}
+	})
+
+	t.Run("WithTx", func(t *testing.T) {
+		sqlDB := newDB(t)
+		sqlDB.SetMaxOpenConns(1)
+		db := ContextDB(sqlDB)
+		tx, err := sqlDB.Begin()
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		defer tx.Rollback()
+		txCtx := WithTx(ctx, sqlDB, tx)
+		if gotTx, ok := TxFromContext(txCtx); !ok || gotTx != tx {
+			t.Fatal(testutil.Callers(), "TxFromContext did not return the transaction")
+		}
+		if _, ok := TxFromContext(ctx); ok {
+			t.Fatal(testutil.Callers(), "unexpected transaction in context")
+		}
+		_, err = actors.Insert(txCtx, db, Actor{FirstName: "PENELOPE", LastName: "GUINESS"})
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		// Nested RunInTx uses a savepoint of the context's transaction.
+		err = RunInTx(txCtx, db, nil, func(ctx context.Context, _ sq.DB) error {
+			_, err := actors.Insert(ctx, db, Actor{FirstName: "NICK", LastName: "WAHLBERG"})
+			if err != nil {
+				return err
+			}
+			return errRollback
+		})
+		if !errors.Is(err, errRollback) {
+			t.Fatalf(testutil.Callers()+" expected errRollback, got %v", err)
+		}
+		_, err = actors.Update(txCtx, db, a.FIRST_NAME.EqString("PENELOPE"), Actor{FirstName: "ED", LastName: "CHASE"})
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		err = tx.Commit()
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		gotActors, err := FetchAllContext(ctx, db, From(a).OrderBy(a.ACTOR_ID), a.RowMapper)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if diff := testutil.Diff(gotActors, []Actor{{ActorID: 1, FirstName: "ED", LastName: "CHASE"}}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("other DB", func(t *testing.T) {
+		sqlDB1, sqlDB2 := newDB(t), newDB(t)
+		sqlDB2.SetMaxOpenConns(1)
+		db1 := ContextDB(sqlDB1)
+		err := RunInTx(ctx, sqlDB2, nil, func(ctx context.Context, tx sq.DB) error {
+			// Runs on sqlDB1, not on sqlDB2's transaction.
+			_, err := actors.Insert(ctx, db1, Actor{FirstName: "PENELOPE", LastName: "GUINESS"})
+			if err != nil {
+				return err
+			}
+			return errRollback
+		})
+		if !errors.Is(err, errRollback) {
+			t.Fatalf(testutil.Callers()+" expected errRollback, got %v", err)
+		}
+		for _, tt := range []struct {
+			db   DB
+			want int
+		}{{sqlDB1, 1}, {sqlDB2, 0}} {
+			gotActors, err := actors.All(ctx, tt.db, a.ACTOR_ID.IsNotNull())
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			if len(gotActors) != tt.want {
+				t.Errorf(testutil.Callers()+" expected %d actors, got %v", tt.want, gotActors)
+			}
+		}
+	})
+
+	t.Run("runs through middleware", func(t *testing.T) {
+		sqlDB := newDB(t)
+		sqlDB.SetMaxOpenConns(1)
+		hook := &recordingHook{}
+		hooked := Hooks(sqlDB, hook)
+		db := ContextDB(hooked)
+		err := RunInTx(ctx, hooked, nil, func(ctx context.Context, tx sq.DB) error {
+			_, err := actors.Insert(ctx, db, Actor{FirstName: "PENELOPE", LastName: "GUINESS"})
+			return err
+		})
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if diff := testutil.Diff(hook.events, []string{"before begin", "after begin", "before exec", "after exec", "before commit", "after commit"}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("preserves Logger and dialect", func(t *testing.T) {
+		db := ContextDB(Log(newDB(t)))
+		if _, ok := db.(Logger); !ok {
+			t.Error(testutil.Callers(), "Logger not preserved")
+		}
+		if _, ok := db.(Txer); !ok {
+			t.Error(testutil.Callers(), "Txer not implemented")
+		}
+		if dialect := dbDialect(ContextDB(newDB(t))); dialect != DialectSQLite {
+			t.Errorf(testutil.Callers()+" expected %q, got %q", DialectSQLite, dialect)
+		}
+	})
+}
@@ -27,6 +27,10 @@ type UpdateQuery struct {
 	LimitRows any
 	// RETURNING
 	ReturningFields []Field
+	ReturningInto   []any
+	// FullTable marks the query as meant to update every row of the table,
+	// see Guard.
+	FullTable bool
 }
 
 var _ Query = (*UpdateQuery)(nil)
@@ -85,7 +89,7 @@ func (q UpdateQuery) WriteSQL(ctx context.Context, dialect string, buf *bytes.Bu
 	}
 	if dialect != DialectSQLServer {
 		if alias := getAlias(q.UpdateTable); alias != "" {
-			buf.WriteString(" AS " + QuoteIdentifier(dialect, alias))
+			buf.WriteString(tableAliasAS(dialect) + QuoteIdentifier(dialect, alias))
 		}
 	}
 	if len(q.Assignments) == 0 {
@@ -118,7 +122,7 @@ func (q UpdateQuery) WriteSQL(ctx context.Context, dialect string, buf *bytes.Bu
 			return fmt.Errorf("FROM: %w", err)
 		}
 		if alias := getAlias(q.FromTable); alias != "" {
-			buf.WriteString(" AS " + QuoteIdentifier(dialect, alias) + quoteTableColumns(dialect, q.FromTable))
+			buf.WriteString(tableAliasAS(dialect) + QuoteIdentifier(dialect, alias) + quoteTableColumns(dialect, q.FromTable))
 		}
 	}
 	// JOIN
@@ -181,11 +185,15 @@ func (q UpdateQuery) WriteSQL(ctx context.Context, dialect string, buf *bytes.Bu
 	}
 	// RETURNING
 	if len(q.ReturningFields) > 0 && dialect != DialectSQLServer {
-		if dialect != DialectPostgres && dialect != DialectSQLite {
+		if !Supports(dialect, CapabilityUpdateReturning) {
 			return fmt.Errorf("%s UPDATE does not support RETURNING", dialect)
 		}
 		buf.WriteString(" RETURNING ")
-		err = writeFields(ctx, dialect, buf, args, params, q.ReturningFields, true)
+		if dialect == DialectOracle {
+			err = writeReturningInto(ctx, dialect, buf, args, params, q.ReturningFields, q.ReturningInto)
+		} else {
+			err = writeFields(ctx, dialect, buf, args, params, q.ReturningFields, true)
+		}
 		if err != nil {
 			return fmt.Errorf("RETURNING: %w", err)
 		}
@@ -216,28 +224,31 @@ func (q UpdateQuery) Where(predicates ...Predicate) UpdateQuery {
 	return q
 }
 
+// AllowFullTable marks the UpdateQuery as meant to update every row of the
+// table, so that it is not rejected by a Guard for having no WHERE clause.
+func (q UpdateQuery) AllowFullTable() UpdateQuery {
+	q.FullTable = true
+	return q
+}
+
 // SetFetchableFields implements the Query interface.
 func (q UpdateQuery) SetFetchableFields(fields []Field) (query Query, ok bool) {
-	switch q.Dialect {
-	case DialectPostgres, DialectSQLite:
-		if len(q.ReturningFields) == 0 {
-			q.ReturningFields = fields
-			return q, true
-		}
-		return q, false
-	default:
+	if !supportsFetchableReturning(q.Dialect, CapabilityUpdateReturning) {
 		return q, false
 	}
+	if len(q.ReturningFields) == 0 {
+		q.ReturningFields = fields
+		return q, true
+	}
+	return q, false
 }
 
 // GetFetchableFields returns the fetchable fields of the query.
 func (q UpdateQuery) GetFetchableFields() []Field {
-	switch q.Dialect {
-	case DialectPostgres, DialectSQLite:
-		return q.ReturningFields
-	default:
+	if !supportsFetchableReturning(q.Dialect, CapabilityUpdateReturning) {
 		return nil
 	}
+	return q.ReturningFields
 }
 
 // GetDialect implements the Query interface.
@@ -323,6 +334,13 @@ func (q SQLiteUpdateQuery) Where(predicates ...Predicate) SQLiteUpdateQuery {
 	return q
 }
 
+// AllowFullTable marks the SQLiteUpdateQuery as meant to update every row of the
+// table, so that it is not rejected by a Guard for having no WHERE clause.
+func (q SQLiteUpdateQuery) AllowFullTable() SQLiteUpdateQuery {
+	q.FullTable = true
+	return q
+}
+
 // Returning sets the ReturningFields field of the SQLiteUpdateQuery.
 func (q SQLiteUpdateQuery) Returning(fields ...Field) SQLiteUpdateQuery {
 	q.ReturningFields = append(q.ReturningFields, fields...)
@@ -428,6 +446,13 @@ func (q PostgresUpdateQuery) Where(predicates ...Predicate) PostgresUpdateQuery
 	return q
 }
 
+// AllowFullTable marks the PostgresUpdateQuery as meant to update every row of the
+// table, so that it is not rejected by a Guard for having no WHERE clause.
+func (q PostgresUpdateQuery) AllowFullTable() PostgresUpdateQuery {
+	q.FullTable = true
+	return q
+}
+
 // Returning sets the ReturningFields field of the PostgresUpdateQuery.
 func (q PostgresUpdateQuery) Returning(fields ...Field) PostgresUpdateQuery {
 	q.ReturningFields = append(q.ReturningFields, fields...)
@@ -528,6 +553,13 @@ func (q MySQLUpdateQuery) Where(predicates ...Predicate) MySQLUpdateQuery {
 	return q
 }
 
+// AllowFullTable marks the MySQLUpdateQuery as meant to update every row of the
+// table, so that it is not rejected by a Guard for having no WHERE clause.
+func (q MySQLUpdateQuery) AllowFullTable() MySQLUpdateQuery {
+	q.FullTable = true
+	return q
+}
+
 // OrderBy sets the OrderByFields of the MySQLUpdateQuery.
 func (q MySQLUpdateQuery) OrderBy(fields ...Field) MySQLUpdateQuery {
 	q.OrderByFields = append(q.OrderByFields, fields...)
@@ -633,6 +665,13 @@ func (q SQLServerUpdateQuery) Where(predicates ...Predicate) SQLServerUpdateQuer
 	return q
 }
 
+// AllowFullTable marks the SQLServerUpdateQuery as meant to update every row of the
+// table, so that it is not rejected by a Guard for having no WHERE clause.
+func (q SQLServerUpdateQuery) AllowFullTable() SQLServerUpdateQuery {
+	q.FullTable = true
+	return q
+}
+
 // SetFetchableFields implements the Query interface.
 func (q SQLServerUpdateQuery) SetFetchableFields(fields []Field) (query Query, ok bool) {
 	return UpdateQuery(q).SetFetchableFields(fields)
@@ -651,3 +690,191 @@ func (q SQLServerUpdateQuery) SetDialect(dialect string) SQLServerUpdateQuery {
 	q.Dialect = dialect
 	return q
 }
+
+// DuckDBUpdateQuery represents a DuckDB UPDATE query.
+type DuckDBUpdateQuery UpdateQuery
+
+var _ Query = (*DuckDBUpdateQuery)(nil)
+
+// WriteSQL implements the SQLWriter interface.
+func (q DuckDBUpdateQuery) WriteSQL(ctx context.Context, dialect string, buf *bytes.Buffer, args *[]any, params map[string][]int) error {
+	return UpdateQuery(q).WriteSQL(ctx, dialect, buf, args, params)
+}
+
+// Update returns a new DuckDBUpdateQuery.
+func (b duckdbQueryBuilder) Update(table Table) DuckDBUpdateQuery {
+	return DuckDBUpdateQuery{
+		Dialect:     DialectDuckDB,
+		CTEs:        b.ctes,
+		UpdateTable: table,
+	}
+}
+
+// Set sets the Assignments field of the DuckDBUpdateQuery.
+func (q DuckDBUpdateQuery) Set(assignments ...Assignment) DuckDBUpdateQuery {
+	q.Assignments = append(q.Assignments, assignments...)
+	return q
+}
+
+// SetFunc sets the ColumnMapper of the DuckDBUpdateQuery.
+func (q DuckDBUpdateQuery) SetFunc(columnMapper ColumnMapper) DuckDBUpdateQuery {
+	q.ColumnMapper = columnMapper
+	return q
+}
+
+// From sets the FromTable field of the DuckDBUpdateQuery.
+func (q DuckDBUpdateQuery) From(table Table) DuckDBUpdateQuery {
+	q.FromTable = table
+	return q
+}
+
+// Join joins a new Table to the DuckDBUpdateQuery.
+func (q DuckDBUpdateQuery) Join(table Table, predicates ...Predicate) DuckDBUpdateQuery {
+	q.JoinTables = append(q.JoinTables, Join(table, predicates...))
+	return q
+}
+
+// LeftJoin left joins a new Table to the DuckDBUpdateQuery.
+func (q DuckDBUpdateQuery) LeftJoin(table Table, predicates ...Predicate) DuckDBUpdateQuery {
+	q.JoinTables = append(q.JoinTables, LeftJoin(table, predicates...))
+	return q
+}
+
+// FullJoin full joins a new Table to the DuckDBUpdateQuery.
+func (q DuckDBUpdateQuery) FullJoin(table Table, predicates ...Predicate) DuckDBUpdateQuery {
+	q.JoinTables = append(q.JoinTables, FullJoin(table, predicates...))
+	return q
+}
+
+// CrossJoin cross joins a new Table to the DuckDBUpdateQuery.
+func (q DuckDBUpdateQuery) CrossJoin(table Table) DuckDBUpdateQuery {
+	q.JoinTables = append(q.JoinTables, CrossJoin(table))
+	return q
+}
+
+// CustomJoin joins a new Table to the DuckDBUpdateQuery with a custom join
+// operator.
+func (q DuckDBUpdateQuery) CustomJoin(joinOperator string, table Table, predicates ...Predicate) DuckDBUpdateQuery {
+	q.JoinTables = append(q.JoinTables, CustomJoin(joinOperator, table, predicates...))
+	return q
+}
+
+// JoinUsing joins a new Table to the DuckDBUpdateQuery with the USING operator.
+func (q DuckDBUpdateQuery) JoinUsing(table Table, fields ...Field) DuckDBUpdateQuery {
+	q.JoinTables = append(q.JoinTables, JoinUsing(table, fields...))
+	return q
+}
+
+// Where appends to the WherePredicate field of the DuckDBUpdateQuery.
+func (q DuckDBUpdateQuery) Where(predicates ...Predicate) DuckDBUpdateQuery {
+	q.WherePredicate = appendPredicates(q.WherePredicate, predicates)
+	return q
+}
+
+// AllowFullTable marks the DuckDBUpdateQuery as meant to update every row of the
+// table, so that it is not rejected by a Guard for having no WHERE clause.
+func (q DuckDBUpdateQuery) AllowFullTable() DuckDBUpdateQuery {
+	q.FullTable = true
+	return q
+}
+
+// Returning sets the ReturningFields field of the DuckDBUpdateQuery.
+func (q DuckDBUpdateQuery) Returning(fields ...Field) DuckDBUpdateQuery {
+	q.ReturningFields = append(q.ReturningFields, fields...)
+	return q
+}
+
+// SetFetchableFields implements the Query interface.
+func (q DuckDBUpdateQuery) SetFetchableFields(fields []Field) (query Query, ok bool) {
+	return UpdateQuery(q).SetFetchableFields(fields)
+}
+
+// GetFetchableFields returns the fetchable fields of the DuckDBUpdateQuery.
+func (q DuckDBUpdateQuery) GetFetchableFields() []Field {
+	return UpdateQuery(q).GetFetchableFields()
+}
+
+// GetDialect implements the Query interface.
+func (q DuckDBUpdateQuery) GetDialect() string { return q.Dialect }
+
+// SetDialect sets the dialect of the DuckDBUpdateQuery.
+func (q DuckDBUpdateQuery) SetDialect(dialect string) DuckDBUpdateQuery {
+	q.Dialect = dialect
+	return q
+}
+
+// OracleUpdateQuery represents an Oracle UPDATE query.
+type OracleUpdateQuery UpdateQuery
+
+var _ Query = (*OracleUpdateQuery)(nil)
+
+// WriteSQL implements the SQLWriter interface.
+func (q OracleUpdateQuery) WriteSQL(ctx context.Context, dialect string, buf *bytes.Buffer, args *[]any, params map[string][]int) error {
+	return UpdateQuery(q).WriteSQL(ctx, dialect, buf, args, params)
+}
+
+// Update returns a new OracleUpdateQuery.
+func (b oracleQueryBuilder) Update(table Table) OracleUpdateQuery {
+	return OracleUpdateQuery{
+		Dialect:     DialectOracle,
+		CTEs:        b.ctes,
+		UpdateTable: table,
+	}
+}
+
+// Set sets the Assignments field of the OracleUpdateQuery.
+func (q OracleUpdateQuery) Set(assignments ...Assignment) OracleUpdateQuery {
+	q.Assignments = append(q.Assignments, assignments...)
+	return q
+}
+
+// SetFunc sets the ColumnMapper of the OracleUpdateQuery.
+func (q OracleUpdateQuery) SetFunc(columnMapper ColumnMapper) OracleUpdateQuery {
+	q.ColumnMapper = columnMapper
+	return q
+}
+
+// Where appends to the WherePredicate field of the OracleUpdateQuery.
+func (q OracleUpdateQuery) Where(predicates ...Predicate) OracleUpdateQuery {
+	q.WherePredicate = appendPredicates(q.WherePredicate, predicates)
+	return q
+}
+
+// AllowFullTable marks the OracleUpdateQuery as meant to update every row of the
+// table, so that it is not rejected by a Guard for having no WHERE clause.
+func (q OracleUpdateQuery) AllowFullTable() OracleUpdateQuery {
+	q.FullTable = true
+	return q
+}
+
+// Returning sets the ReturningFields field of the OracleUpdateQuery.
+func (q OracleUpdateQuery) Returning(fields ...Field) OracleUpdateQuery {
+	q.ReturningFields = append(q.ReturningFields, fields...)
+	return q
+}
+
+// Into adds destinations to the INTO clause of the OracleUpdateQuery's
+// RETURNING clause. There must be one destination for every RETURNING field.
+func (q OracleUpdateQuery) Into(dest ...any) OracleUpdateQuery {
+	q.ReturningInto = append(q.ReturningInto, dest...)
+	return q
+}
+
+// SetFetchableFields implements the Query interface.
+func (q OracleUpdateQuery) SetFetchableFields(fields []Field) (query Query, ok bool) {
+	return UpdateQuery(q).SetFetchableFields(fields)
+}
+
+// GetFetchableFields returns the fetchable fields of the OracleUpdateQuery.
+func (q OracleUpdateQuery) GetFetchableFields() []Field {
+	return UpdateQuery(q).GetFetchableFields()
+}
+
+// GetDialect implements the Query interface.
+func (q OracleUpdateQuery) GetDialect() string { return q.Dialect }
+
+// SetDialect sets the dialect of the OracleUpdateQuery.
+func (q OracleUpdateQuery) SetDialect(dialect string) OracleUpdateQuery {
+	q.Dialect = dialect
+	return q
+}
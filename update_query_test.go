@@ -417,6 +417,58 @@ func TestSQLServerUpdateQuery(t *testing.T) {
 	})
 }
 
+func TestDuckDBUpdateQuery(t *testing.T) {
+	type ACTOR struct {
+		TableStruct
+		ACTOR_ID    NumberField
+		FIRST_NAME  StringField
+		LAST_NAME   StringField
+		LAST_UPDATE TimeField
+	}
+	a := New[ACTOR]("a")
+
+	t.Run("basic", func(t *testing.T) {
+		t.Parallel()
+		q1 := DuckDB.Update(a).Returning(a.FIRST_NAME).SetDialect("lorem ipsum")
+		if diff := testutil.Diff(q1.GetDialect(), "lorem ipsum"); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		q1 = q1.SetDialect(DialectDuckDB)
+		fields := q1.GetFetchableFields()
+		if diff := testutil.Diff(fields, []Field{a.FIRST_NAME}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		_, ok := q1.SetFetchableFields([]Field{a.LAST_NAME})
+		if ok {
+			t.Fatal(testutil.Callers(), "field should not have been set")
+		}
+		q1.ReturningFields = q1.ReturningFields[:0]
+		_, ok = q1.SetFetchableFields([]Field{a.LAST_NAME})
+		if !ok {
+			t.Fatal(testutil.Callers(), "field should have been set")
+		}
+	})
+
+	t.Run("From Returning", func(t *testing.T) {
+		t.Parallel()
+		b := New[ACTOR]("b")
+		var tt TestTable
+		tt.item = DuckDB.
+			Update(a).
+			Set(Set(a.FIRST_NAME, b.FIRST_NAME)).
+			From(b).
+			Where(a.ACTOR_ID.Eq(b.ACTOR_ID), a.LAST_NAME.EqString("x")).
+			Returning(a.ACTOR_ID)
+		tt.wantQuery = "UPDATE actor AS a" +
+			" SET first_name = b.first_name" +
+			" FROM actor AS b" +
+			" WHERE a.actor_id = b.actor_id AND a.last_name = $1" +
+			" RETURNING a.actor_id"
+		tt.wantArgs = []any{"x"}
+		tt.assert(t)
+	})
+}
+
 func TestUpdateQuery(t *testing.T) {
 	t.Run("basic", func(t *testing.T) {
 		t.Parallel()
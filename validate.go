@@ -0,0 +1,528 @@
+package sq
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+)
+
+// Capability is an SQL construct that is only supported by some dialects.
+type Capability string
+
+// Capabilities that are checked by Validate.
+const (
+	CapabilitySelectTop        Capability = "SELECT TOP"
+	CapabilityDistinctOn       Capability = "SELECT DISTINCT ON"
+	CapabilityLimit            Capability = "LIMIT"
+	CapabilityFetchNext        Capability = "FETCH NEXT"
+	CapabilityFetchWithTies    Capability = "FETCH NEXT ... WITH TIES"
+	CapabilityLockClause       Capability = "FOR UPDATE/FOR SHARE"
+	CapabilityRightJoin        Capability = "RIGHT JOIN"
+	CapabilityFullJoin         Capability = "FULL JOIN"
+	CapabilityNamedWindow      Capability = "WINDOW"
+	CapabilityQualify          Capability = "QUALIFY"
+	CapabilityMaterializedCTE  Capability = "MATERIALIZED CTE"
+	CapabilityIntersectAll     Capability = "INTERSECT ALL"
+	CapabilityExceptAll        Capability = "EXCEPT ALL"
+	CapabilityInsertCTE        Capability = "INSERT with CTE"
+	CapabilityInsertIgnore     Capability = "INSERT IGNORE"
+	CapabilityInsertAlias      Capability = "INSERT table alias"
+	CapabilityInsertRowAlias   Capability = "INSERT row alias"
+	CapabilityOnConflict       Capability = "ON CONFLICT"
+	CapabilityOnDuplicateKey   Capability = "ON DUPLICATE KEY UPDATE"
+	CapabilityInsertReturning  Capability = "INSERT ... RETURNING"
+	CapabilityUpdateFrom       Capability = "UPDATE ... FROM"
+	CapabilityUpdateOrderBy    Capability = "UPDATE ... ORDER BY"
+	CapabilityUpdateLimit      Capability = "UPDATE ... LIMIT"
+	CapabilityUpdateReturning  Capability = "UPDATE ... RETURNING"
+	CapabilityDeleteJoin       Capability = "DELETE ... JOIN"
+	CapabilityDeleteOrderBy    Capability = "DELETE ... ORDER BY"
+	CapabilityDeleteLimit      Capability = "DELETE ... LIMIT"
+	CapabilityDeleteReturning  Capability = "DELETE ... RETURNING"
+	CapabilityMultiTableDelete Capability = "multi-table DELETE"
+)
+
+var dialectCapabilities = map[string]map[Capability]bool{
+	DialectSQLite: {
+		CapabilityLimit:           true,
+		CapabilityNamedWindow:     true,
+		CapabilityInsertCTE:       true,
+		CapabilityInsertAlias:     true,
+		CapabilityOnConflict:      true,
+		CapabilityInsertReturning: true,
+		CapabilityUpdateFrom:      true,
+		CapabilityUpdateReturning: true,
+		CapabilityDeleteReturning: true,
+	},
+	DialectPostgres: {
+		CapabilityDistinctOn:      true,
+		CapabilityLimit:           true,
+		CapabilityFetchNext:       true,
+		CapabilityFetchWithTies:   true,
+		CapabilityLockClause:      true,
+		CapabilityRightJoin:       true,
+		CapabilityFullJoin:        true,
+		CapabilityNamedWindow:     true,
+		CapabilityMaterializedCTE: true,
+		CapabilityIntersectAll:    true,
+		CapabilityExceptAll:       true,
+		CapabilityInsertCTE:       true,
+		CapabilityInsertAlias:     true,
+		CapabilityOnConflict:      true,
+		CapabilityInsertReturning: true,
+		CapabilityUpdateFrom:      true,
+		CapabilityUpdateReturning: true,
+		CapabilityDeleteJoin:      true,
+		CapabilityDeleteReturning: true,
+	},
+	DialectMySQL: {
+		CapabilityLimit:            true,
+		CapabilityLockClause:       true,
+		CapabilityRightJoin:        true,
+		CapabilityNamedWindow:      true,
+		CapabilityIntersectAll:     true,
+		CapabilityExceptAll:        true,
+		CapabilityInsertIgnore:     true,
+		CapabilityInsertRowAlias:   true,
+		CapabilityOnDuplicateKey:   true,
+		CapabilityUpdateOrderBy:    true,
+		CapabilityUpdateLimit:      true,
+		CapabilityDeleteJoin:       true,
+		CapabilityDeleteOrderBy:    true,
+		CapabilityDeleteLimit:      true,
+		CapabilityMultiTableDelete: true,
+	},
+	DialectSQLServer: {
+		CapabilitySelectTop:       true,
+		CapabilityFetchNext:       true,
+		CapabilityRightJoin:       true,
+		CapabilityFullJoin:        true,
+		CapabilityNamedWindow:     true,
+		CapabilityInsertCTE:       true,
+		CapabilityInsertReturning: true,
+		CapabilityUpdateFrom:      true,
+		CapabilityUpdateReturning: true,
+		CapabilityDeleteJoin:      true,
+		CapabilityDeleteReturning: true,
+	},
+	DialectDuckDB: {
+		CapabilityDistinctOn:      true,
+		CapabilityLimit:           true,
+		CapabilityFetchNext:       true,
+		CapabilityRightJoin:       true,
+		CapabilityFullJoin:        true,
+		CapabilityNamedWindow:     true,
+		CapabilityQualify:         true,
+		CapabilityMaterializedCTE: true,
+		CapabilityIntersectAll:    true,
+		CapabilityExceptAll:       true,
+		CapabilityInsertCTE:       true,
+		CapabilityInsertAlias:     true,
+		CapabilityOnConflict:      true,
+		CapabilityInsertReturning: true,
+		CapabilityUpdateFrom:      true,
+		CapabilityUpdateReturning: true,
+		CapabilityDeleteJoin:      true,
+		CapabilityDeleteReturning: true,
+	},
+	DialectOracle: {
+		CapabilityLimit:           true,
+		CapabilityFetchNext:       true,
+		CapabilityFetchWithTies:   true,
+		CapabilityLockClause:      true,
+		CapabilityRightJoin:       true,
+		CapabilityFullJoin:        true,
+		CapabilityInsertAlias:     true,
+		CapabilityOnConflict:      true,
+		CapabilityInsertReturning: true,
+		CapabilityUpdateReturning: true,
+		CapabilityDeleteReturning: true,
+	},
+}
+
+// Supports reports whether the dialect supports the given Capability. Dialects
+// registered with RegisterDialect are consulted through Dialect.Supports.
+// Unknown dialects do not support any Capability.
+func Supports(dialect string, capability Capability) bool {
+	if capabilities, ok := dialectCapabilities[dialect]; ok {
+		return capabilities[capability]
+	}
+	if d := customDialect(dialect); d != nil {
+		return d.Supports(capability)
+	}
+	return false
+}
+
+// ValidationError is an SQL construct in a query that is not supported by
+// the dialect.
+type ValidationError struct {
+	// Dialect that was validated against.
+	Dialect string
+
+	// Capability that the dialect does not support.
+	Capability Capability
+
+	// Path to the offending node in the query tree e.g.
+	// "SELECT > WITH cte > SELECT > JOIN #2".
+	Path string
+}
+
+// Error implements the error interface.
+func (e ValidationError) Error() string {
+	return e.Path + ": " + e.Dialect + " does not support " + string(e.Capability)
+}
+
+// ValidationErrors is a list of ValidationErrors returned by Validate.
+type ValidationErrors []ValidationError
+
+// Error implements the error interface.
+func (errs ValidationErrors) Error() string {
+	var b strings.Builder
+	for i, err := range errs {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(err.Error())
+	}
+	return b.String()
+}
+
+// Validate statically checks the query (and all of its subqueries) for SQL
+// constructs that are not supported by the dialect. If the dialect is empty,
+// the query's own dialect is used. All unsupported constructs are reported
+// in a ValidationErrors, otherwise Validate returns nil.
+//
+// Validate does not guarantee that the query will build successfully: it
+// only checks the constructs listed in the Capability constants.
+func Validate(dialect string, query Query) error {
+	if query == nil {
+		return nil
+	}
+	if dialect == "" {
+		dialect = query.GetDialect()
+	}
+	v := &validator{dialect: dialect}
+	v.query("", query)
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
+
+type validator struct {
+	dialect string
+	errs    ValidationErrors
+	// visitTable, if non-nil, is called for every table in the query tree.
+	visitTable func(Table)
+}
+
+func (v *validator) check(path string, capability Capability) {
+	if !Supports(v.dialect, capability) {
+		v.errs = append(v.errs, ValidationError{
+			Dialect:    v.dialect,
+			Capability: capability,
+			Path:       path,
+		})
+	}
+}
+
+func joinPath(path, node string) string {
+	if path == "" {
+		return node
+	}
+	return path + " > " + node
+}
+
+func (v *validator) query(path string, query Query) {
+	switch q := query.(type) {
+	case SelectQuery:
+		v.selectQuery(joinPath(path, "SELECT"), q)
+	case SQLiteSelectQuery:
+		v.selectQuery(joinPath(path, "SELECT"), SelectQuery(q))
+	case PostgresSelectQuery:
+		v.selectQuery(joinPath(path, "SELECT"), SelectQuery(q))
+	case MySQLSelectQuery:
+		v.selectQuery(joinPath(path, "SELECT"), SelectQuery(q))
+	case SQLServerSelectQuery:
+		v.selectQuery(joinPath(path, "SELECT"), SelectQuery(q))
+	case DuckDBSelectQuery:
+		v.selectQuery(joinPath(path, "SELECT"), SelectQuery(q))
+	case OracleSelectQuery:
+		v.selectQuery(joinPath(path, "SELECT"), SelectQuery(q))
+	case InsertQuery:
+		v.insertQuery(joinPath(path, "INSERT"), q)
+	case SQLiteInsertQuery:
+		v.insertQuery(joinPath(path, "INSERT"), InsertQuery(q))
+	case PostgresInsertQuery:
+		v.insertQuery(joinPath(path, "INSERT"), InsertQuery(q))
+	case MySQLInsertQuery:
+		v.insertQuery(joinPath(path, "INSERT"), InsertQuery(q))
+	case SQLServerInsertQuery:
+		v.insertQuery(joinPath(path, "INSERT"), InsertQuery(q))
+	case DuckDBInsertQuery:
+		v.insertQuery(joinPath(path, "INSERT"), InsertQuery(q))
+	case OracleInsertQuery:
+		v.insertQuery(joinPath(path, "INSERT"), InsertQuery(q))
+	case UpdateQuery:
+		v.updateQuery(joinPath(path, "UPDATE"), q)
+	case SQLiteUpdateQuery:
+		v.updateQuery(joinPath(path, "UPDATE"), UpdateQuery(q))
+	case PostgresUpdateQuery:
+		v.updateQuery(joinPath(path, "UPDATE"), UpdateQuery(q))
+	case MySQLUpdateQuery:
+		v.updateQuery(joinPath(path, "UPDATE"), UpdateQuery(q))
+	case SQLServerUpdateQuery:
+		v.updateQuery(joinPath(path, "UPDATE"), UpdateQuery(q))
+	case DuckDBUpdateQuery:
+		v.updateQuery(joinPath(path, "UPDATE"), UpdateQuery(q))
+	case OracleUpdateQuery:
+		v.updateQuery(joinPath(path, "UPDATE"), UpdateQuery(q))
+	case DeleteQuery:
+		v.deleteQuery(joinPath(path, "DELETE"), q)
+	case SQLiteDeleteQuery:
+		v.deleteQuery(joinPath(path, "DELETE"), DeleteQuery(q))
+	case PostgresDeleteQuery:
+		v.deleteQuery(joinPath(path, "DELETE"), DeleteQuery(q))
+	case MySQLDeleteQuery:
+		v.deleteQuery(joinPath(path, "DELETE"), DeleteQuery(q))
+	case SQLServerDeleteQuery:
+		v.deleteQuery(joinPath(path, "DELETE"), DeleteQuery(q))
+	case DuckDBDeleteQuery:
+		v.deleteQuery(joinPath(path, "DELETE"), DeleteQuery(q))
+	case OracleDeleteQuery:
+		v.deleteQuery(joinPath(path, "DELETE"), DeleteQuery(q))
+	case VariadicQuery:
+		operator := q.Operator
+		if operator == "" {
+			operator = QueryUnion
+		}
+		path = joinPath(path, string(operator))
+		switch operator {
+		case QueryIntersectAll:
+			v.check(path, CapabilityIntersectAll)
+		case QueryExceptAll:
+			v.check(path, CapabilityExceptAll)
+		}
+		for i, query := range q.Queries {
+			v.query(joinPath(path, "query #"+strconv.Itoa(i+1)), query)
+		}
+	case CustomQuery:
+		v.values(path, q.Values)
+	case SelectValues:
+		for _, rowValue := range q.RowValues {
+			v.values(path, rowValue)
+		}
+	case TableValues:
+		for _, rowValue := range q.RowValues {
+			v.values(path, rowValue)
+		}
+	}
+}
+
+func (v *validator) ctes(path string, ctes []CTE) {
+	for _, cte := range ctes {
+		ctePath := joinPath(path, "WITH "+cte.name)
+		if cte.materialized.Valid {
+			v.check(ctePath, CapabilityMaterializedCTE)
+		}
+		v.query(ctePath, cte.query)
+	}
+}
+
+func (v *validator) table(path string, table Table) {
+	if table == nil {
+		return
+	}
+	if v.visitTable != nil {
+		v.visitTable(table)
+	}
+	if query, ok := table.(Query); ok {
+		v.query(path, query)
+	}
+}
+
+func (v *validator) joinTables(path string, joinTables []JoinTable) {
+	for i, joinTable := range joinTables {
+		nodePath := joinPath(path, joinTable.JoinOperator+" #"+strconv.Itoa(i+1))
+		switch joinTable.JoinOperator {
+		case JoinRight:
+			v.check(nodePath, CapabilityRightJoin)
+		case JoinFull:
+			v.check(nodePath, CapabilityFullJoin)
+		}
+		v.table(nodePath, joinTable.Table)
+		v.value(nodePath, joinTable.OnPredicate)
+	}
+}
+
+func (v *validator) fields(path string, fields []Field) {
+	for _, field := range fields {
+		v.value(path, field)
+	}
+}
+
+func (v *validator) values(path string, values []any) {
+	for _, value := range values {
+		v.value(path, value)
+	}
+}
+
+// value looks for subqueries nested inside a value.
+func (v *validator) value(path string, value any) {
+	switch value := value.(type) {
+	case nil:
+		return
+	case Query:
+		v.query(joinPath(path, "subquery"), value)
+	case Expression:
+		v.values(path, value.values)
+	case VariadicPredicate:
+		for _, predicate := range value.Predicates {
+			v.value(path, predicate)
+		}
+	case assignment:
+		v.value(path, value.value)
+	case Assignments:
+		for _, assignment := range value {
+			v.value(path, assignment)
+		}
+	case Fields:
+		v.fields(path, value)
+	case RowValue:
+		v.values(path, value)
+	case RowValues:
+		for _, rowValue := range value {
+			v.values(path, rowValue)
+		}
+	case CaseExpression:
+		for _, predicateCase := range value.Cases {
+			v.value(path, predicateCase.Predicate)
+			v.value(path, predicateCase.Result)
+		}
+		v.value(path, value.Default)
+	case SimpleCaseExpression:
+		v.value(path, value.Expression)
+		for _, simpleCase := range value.Cases {
+			v.value(path, simpleCase.Value)
+			v.value(path, simpleCase.Result)
+		}
+		v.value(path, value.Default)
+	case sql.NamedArg:
+		v.value(path, value.Value)
+	}
+}
+
+func (v *validator) selectQuery(path string, q SelectQuery) {
+	v.ctes(path, q.CTEs)
+	if q.LimitTop != nil || q.LimitTopPercent != nil {
+		v.check(path, CapabilitySelectTop)
+	}
+	if len(q.DistinctOnFields) > 0 {
+		v.check(joinPath(path, "DISTINCT ON"), CapabilityDistinctOn)
+	}
+	v.fields(path, q.SelectFields)
+	v.table(joinPath(path, "FROM"), q.FromTable)
+	v.joinTables(path, q.JoinTables)
+	v.value(joinPath(path, "WHERE"), q.WherePredicate)
+	v.value(joinPath(path, "HAVING"), q.HavingPredicate)
+	if len(q.NamedWindows) > 0 {
+		v.check(joinPath(path, "WINDOW"), CapabilityNamedWindow)
+	}
+	if q.QualifyPredicate != nil {
+		v.check(joinPath(path, "QUALIFY"), CapabilityQualify)
+		v.value(joinPath(path, "QUALIFY"), q.QualifyPredicate)
+	}
+	if q.LimitRows != nil {
+		v.check(joinPath(path, "LIMIT"), CapabilityLimit)
+	}
+	if q.FetchNextRows != nil {
+		v.check(joinPath(path, "FETCH NEXT"), CapabilityFetchNext)
+		if q.FetchWithTies {
+			v.check(joinPath(path, "FETCH NEXT"), CapabilityFetchWithTies)
+		}
+	}
+	if q.LockClause != "" {
+		v.check(joinPath(path, q.LockClause), CapabilityLockClause)
+	}
+}
+
+func (v *validator) insertQuery(path string, q InsertQuery) {
+	if len(q.CTEs) > 0 {
+		v.check(joinPath(path, "WITH"), CapabilityInsertCTE)
+	}
+	v.ctes(path, q.CTEs)
+	if q.InsertIgnore {
+		v.check(path, CapabilityInsertIgnore)
+	}
+	if getAlias(q.InsertTable) != "" {
+		v.check(joinPath(path, "INTO"), CapabilityInsertAlias)
+	}
+	v.table(joinPath(path, "INTO"), q.InsertTable)
+	for _, rowValue := range q.RowValues {
+		v.values(joinPath(path, "VALUES"), rowValue)
+	}
+	if q.RowAlias != "" {
+		v.check(joinPath(path, "VALUES"), CapabilityInsertRowAlias)
+	}
+	if q.SelectQuery != nil {
+		v.query(path, q.SelectQuery)
+	}
+	c := q.Conflict
+	if c.ConstraintName != "" || len(c.Fields) > 0 || len(c.Resolution) > 0 || c.DoNothing {
+		isOnDuplicateKey := len(c.Resolution) > 0 && !c.DoNothing && c.ConstraintName == "" && len(c.Fields) == 0
+		if !isOnDuplicateKey || !Supports(v.dialect, CapabilityOnDuplicateKey) {
+			v.check(joinPath(path, "ON CONFLICT"), CapabilityOnConflict)
+		}
+		v.value(joinPath(path, "ON CONFLICT"), Assignments(c.Resolution))
+	}
+	if len(q.ReturningFields) > 0 {
+		v.check(joinPath(path, "RETURNING"), CapabilityInsertReturning)
+	}
+}
+
+func (v *validator) updateQuery(path string, q UpdateQuery) {
+	v.ctes(path, q.CTEs)
+	v.table(path, q.UpdateTable)
+	v.value(joinPath(path, "SET"), Assignments(q.Assignments))
+	if q.FromTable != nil {
+		v.check(joinPath(path, "FROM"), CapabilityUpdateFrom)
+		v.table(joinPath(path, "FROM"), q.FromTable)
+	}
+	v.joinTables(path, q.JoinTables)
+	v.value(joinPath(path, "WHERE"), q.WherePredicate)
+	if len(q.OrderByFields) > 0 {
+		v.check(joinPath(path, "ORDER BY"), CapabilityUpdateOrderBy)
+	}
+	if q.LimitRows != nil {
+		v.check(joinPath(path, "LIMIT"), CapabilityUpdateLimit)
+	}
+	if len(q.ReturningFields) > 0 {
+		v.check(joinPath(path, "RETURNING"), CapabilityUpdateReturning)
+	}
+}
+
+func (v *validator) deleteQuery(path string, q DeleteQuery) {
+	v.ctes(path, q.CTEs)
+	if len(q.DeleteTables) > 1 {
+		v.check(path, CapabilityMultiTableDelete)
+	}
+	v.table(path, q.DeleteTable)
+	for _, table := range q.DeleteTables {
+		v.table(path, table)
+	}
+	if q.UsingTable != nil || len(q.JoinTables) > 0 {
+		v.check(joinPath(path, "USING"), CapabilityDeleteJoin)
+		v.table(joinPath(path, "USING"), q.UsingTable)
+	}
+	v.joinTables(path, q.JoinTables)
+	v.value(joinPath(path, "WHERE"), q.WherePredicate)
+	if len(q.OrderByFields) > 0 {
+		v.check(joinPath(path, "ORDER BY"), CapabilityDeleteOrderBy)
+	}
+	if q.LimitRows != nil {
+		v.check(joinPath(path, "LIMIT"), CapabilityDeleteLimit)
+	}
+	if len(q.ReturningFields) > 0 {
+		v.check(joinPath(path, "RETURNING"), CapabilityDeleteReturning)
+	}
+}
@@ -0,0 +1,203 @@
+package sq
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/blink-io/sq/internal/testutil"
+)
+
+func TestValidate(t *testing.T) {
+	type TT struct {
+		description string
+		dialect     string
+		query       Query
+		wantErrs    ValidationErrors
+	}
+
+	a := NewTableStruct("", "a", "")
+	b := NewTableStruct("", "b", "")
+	aID := NewNumberField("id", a)
+	bID := NewNumberField("id", b)
+	cte := NewCTE("cte", nil, Select(aID).From(a))
+
+	tests := []TT{{
+		description: "portable query",
+		dialect:     DialectSQLite,
+		query: Select(aID).
+			From(a).
+			Join(b, aID.Eq(bID)).
+			Where(aID.In(Select(bID).From(b))),
+		wantErrs: nil,
+	}, {
+		description: "mysql FULL JOIN",
+		dialect:     DialectMySQL,
+		query:       Select(aID).From(a).CustomJoin(JoinFull, b, aID.Eq(bID)),
+		wantErrs: ValidationErrors{{
+			Dialect:    DialectMySQL,
+			Capability: CapabilityFullJoin,
+			Path:       "SELECT > FULL JOIN #1",
+		}},
+	}, {
+		description: "query dialect is used if dialect is empty",
+		query:       SQLite.Select(aID).From(a).CustomJoin(JoinRight, b, aID.Eq(bID)),
+		wantErrs: ValidationErrors{{
+			Dialect:    DialectSQLite,
+			Capability: CapabilityRightJoin,
+			Path:       "SELECT > RIGHT JOIN #1",
+		}},
+	}, {
+		description: "DISTINCT ON outside postgres",
+		dialect:     DialectSQLServer,
+		query:       Postgres.Select(aID).DistinctOn(aID).From(a).Limit(10),
+		wantErrs: ValidationErrors{{
+			Dialect:    DialectSQLServer,
+			Capability: CapabilityDistinctOn,
+			Path:       "SELECT > DISTINCT ON",
+		}, {
+			Dialect:    DialectSQLServer,
+			Capability: CapabilityLimit,
+			Path:       "SELECT > LIMIT",
+		}},
+	}, {
+		description: "mysql INSERT with CTE",
+		dialect:     DialectMySQL,
+		query: InsertQuery{
+			CTEs:        []CTE{cte},
+			InsertTable: b,
+			SelectQuery: Select(cte.Field("id")).From(cte),
+		},
+		wantErrs: ValidationErrors{{
+			Dialect:    DialectMySQL,
+			Capability: CapabilityInsertCTE,
+			Path:       "INSERT > WITH",
+		}},
+	}, {
+		description: "nested subqueries",
+		dialect:     DialectSQLite,
+		query: Select(aID).
+			From(a).
+			Where(Exists(Select(bID).
+				From(b).
+				CustomJoin(JoinFull, a, aID.Eq(bID)),
+			)),
+		wantErrs: ValidationErrors{{
+			Dialect:    DialectSQLite,
+			Capability: CapabilityFullJoin,
+			Path:       "SELECT > WHERE > subquery > SELECT > FULL JOIN #1",
+		}},
+	}, {
+		description: "CTE and set operation",
+		dialect:     DialectSQLServer,
+		query: Select(aID).From(a).Where(aID.In(ExceptAll(
+			SelectQuery{CTEs: []CTE{cte.Materialized()}, SelectFields: []Field{aID}, FromTable: cte},
+			Select(bID).From(b),
+		))),
+		wantErrs: ValidationErrors{{
+			Dialect:    DialectSQLServer,
+			Capability: CapabilityExceptAll,
+			Path:       "SELECT > WHERE > subquery > EXCEPT ALL",
+		}, {
+			Dialect:    DialectSQLServer,
+			Capability: CapabilityMaterializedCTE,
+			Path:       "SELECT > WHERE > subquery > EXCEPT ALL > query #1 > SELECT > WITH cte",
+		}},
+	}, {
+		description: "mysql upsert",
+		dialect:     DialectMySQL,
+		query: MySQL.InsertInto(a).
+			Columns(aID).
+			Values(1).
+			OnDuplicateKeyUpdate(Set(aID, 2)),
+		wantErrs: nil,
+	}, {
+		description: "sqlserver upsert",
+		dialect:     DialectSQLServer,
+		query: Postgres.InsertInto(a).
+			Columns(aID).
+			Values(1).
+			OnConflict(aID).
+			DoNothing(),
+		wantErrs: ValidationErrors{{
+			Dialect:    DialectSQLServer,
+			Capability: CapabilityOnConflict,
+			Path:       "INSERT > ON CONFLICT",
+		}},
+	}, {
+		description: "UPDATE and DELETE",
+		dialect:     DialectPostgres,
+		query: UpdateQuery{
+			UpdateTable:   a,
+			Assignments:   []Assignment{Set(aID, Select(bID).From(b).Limit(1))},
+			OrderByFields: []Field{aID},
+			LimitRows:     5,
+		},
+		wantErrs: ValidationErrors{{
+			Dialect:    DialectPostgres,
+			Capability: CapabilityUpdateOrderBy,
+			Path:       "UPDATE > ORDER BY",
+		}, {
+			Dialect:    DialectPostgres,
+			Capability: CapabilityUpdateLimit,
+			Path:       "UPDATE > LIMIT",
+		}},
+	}, {
+		description: "mysql RETURNING",
+		dialect:     DialectMySQL,
+		query: InsertQuery{
+			InsertTable:     a,
+			InsertColumns:   []Field{aID},
+			RowValues:       []RowValue{{1}},
+			ReturningFields: []Field{aID},
+		},
+		wantErrs: ValidationErrors{{
+			Dialect:    DialectMySQL,
+			Capability: CapabilityInsertReturning,
+			Path:       "INSERT > RETURNING",
+		}},
+	}, {
+		description: "mysql DELETE RETURNING",
+		dialect:     DialectMySQL,
+		query: DeleteQuery{
+			DeleteTable:     a,
+			WherePredicate:  aID.EqInt(1),
+			ReturningFields: []Field{aID},
+		},
+		wantErrs: ValidationErrors{{
+			Dialect:    DialectMySQL,
+			Capability: CapabilityDeleteReturning,
+			Path:       "DELETE > RETURNING",
+		}},
+	}}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.description, func(t *testing.T) {
+			t.Parallel()
+			err := Validate(tt.dialect, tt.query)
+			if tt.wantErrs == nil {
+				if err != nil {
+					t.Fatal(testutil.Callers(), err)
+				}
+				return
+			}
+			var gotErrs ValidationErrors
+			if !errors.As(err, &gotErrs) {
+				t.Fatalf(testutil.Callers()+" expected ValidationErrors, got %#v", err)
+			}
+			if diff := testutil.Diff(gotErrs, tt.wantErrs); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+		})
+	}
+
+	t.Run("Error", func(t *testing.T) {
+		t.Parallel()
+		err := Validate(DialectMySQL, Select(aID).From(a).CustomJoin(JoinFull, b, aID.Eq(bID)).CustomJoin(JoinFull, b, aID.Eq(bID)))
+		wantErr := "SELECT > FULL JOIN #1: mysql does not support FULL JOIN\n" +
+			"SELECT > FULL JOIN #2: mysql does not support FULL JOIN"
+		if diff := testutil.Diff(err.Error(), wantErr); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+}